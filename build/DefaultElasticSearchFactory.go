@@ -3,12 +3,17 @@ package build
 import (
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
 	cbuild "github.com/pip-services3-go/pip-services3-components-go/build"
+	eevents "github.com/pip-services3-go/pip-services3-elasticsearch-go/events"
+	efeed "github.com/pip-services3-go/pip-services3-elasticsearch-go/feed"
+	ekibana "github.com/pip-services3-go/pip-services3-elasticsearch-go/kibana"
 	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	equeue "github.com/pip-services3-go/pip-services3-elasticsearch-go/queue"
 )
 
 /*
 DefaultElasticSearchFactory are creates ElasticSearch components by their descriptors.
-See ElasticSearchLogger
+See ElasticSearchLogger, ElasticSearchMockLogger, ElasticSearchLogAlerter, ElasticSearchLogCurator, ElasticSearchLogReader, KibanaProvisioner,
+ElasticSearchMessageQueue, ElasticSearchChangeFeed, ElasticSearchEventStore
 */
 type DefaultElasticSearchFactory struct {
 	cbuild.Factory
@@ -23,7 +28,37 @@ func NewDefaultElasticSearchFactory() *DefaultElasticSearchFactory {
 
 	elasticSearchLoggerDescriptor := cref.NewDescriptor("pip-services", "logger", "elasticsearch", "*", "1.0")
 
+	elasticSearchMockLoggerDescriptor := cref.NewDescriptor("pip-services", "logger", "elasticsearch-mock", "*", "1.0")
+
+	elasticSearchLogAlerterDescriptor := cref.NewDescriptor("pip-services", "alerter", "elasticsearch", "*", "1.0")
+
+	elasticSearchLogCuratorDescriptor := cref.NewDescriptor("pip-services", "curator", "elasticsearch", "*", "1.0")
+
+	elasticSearchLogReaderDescriptor := cref.NewDescriptor("pip-services", "reader", "elasticsearch", "*", "1.0")
+
+	kibanaProvisionerDescriptor := cref.NewDescriptor("pip-services", "provisioner", "kibana", "*", "1.0")
+
+	elasticSearchMessageQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "elasticsearch", "*", "1.0")
+
+	elasticSearchChangeFeedDescriptor := cref.NewDescriptor("pip-services", "change-feed", "elasticsearch", "*", "1.0")
+
+	elasticSearchEventStoreDescriptor := cref.NewDescriptor("pip-services", "event-store", "elasticsearch", "*", "1.0")
+
 	c.RegisterType(elasticSearchLoggerDescriptor, elog.NewElasticSearchLogger)
+	c.RegisterType(elasticSearchMockLoggerDescriptor, elog.NewElasticSearchMockLogger)
+	c.RegisterType(elasticSearchLogAlerterDescriptor, elog.NewElasticSearchLogAlerter)
+	c.RegisterType(elasticSearchLogCuratorDescriptor, elog.NewElasticSearchLogCurator)
+	c.RegisterType(elasticSearchLogReaderDescriptor, elog.NewElasticSearchLogReader)
+	c.RegisterType(kibanaProvisionerDescriptor, ekibana.NewKibanaProvisioner)
+	c.RegisterType(elasticSearchChangeFeedDescriptor, efeed.NewElasticSearchChangeFeed)
+	c.RegisterType(elasticSearchEventStoreDescriptor, eevents.NewElasticSearchEventStore)
+	c.Register(elasticSearchMessageQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		if descriptor, ok := locator.(*cref.Descriptor); ok {
+			name = descriptor.Name()
+		}
+		return equeue.NewElasticSearchMessageQueue(name)
+	})
 
 	return &c
 }
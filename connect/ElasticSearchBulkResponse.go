@@ -0,0 +1,65 @@
+package connect
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ElasticSearchBulkItem holds the result of a single operation in a bulk request.
+type ElasticSearchBulkItem struct {
+	// Operation type: index, create, update or delete
+	Operation string                  `json:"-"`
+	Index     string                  `json:"_index"`
+	Id        string                  `json:"_id"`
+	Version   int64                   `json:"_version"`
+	Result    string                  `json:"result"`
+	Status    int                     `json:"status"`
+	SeqNo     int64                   `json:"_seq_no"`
+	Error     *ElasticSearchErrorInfo `json:"error,omitempty"`
+}
+
+// Failed checks if the bulk operation was rejected by ElasticSearch.
+func (c *ElasticSearchBulkItem) Failed() bool {
+	return c.Error != nil || c.Status > 299
+}
+
+// ElasticSearchBulkResponse holds a decoded response of ElasticSearch bulk API.
+// Items are returned in the same order as operations were sent in the request.
+type ElasticSearchBulkResponse struct {
+	Took   int                      `json:"took"`
+	Errors bool                     `json:"errors"`
+	Items  []*ElasticSearchBulkItem `json:"-"`
+}
+
+// ReadBulkResponse decodes a successful response of ElasticSearch bulk API.
+// Parameters:
+//   - body io.Reader  response body
+// Returns decoded *ElasticSearchBulkResponse or error if the body cannot be parsed.
+func ReadBulkResponse(body io.Reader) (*ElasticSearchBulkResponse, error) {
+	var raw struct {
+		Took   int                                 `json:"took"`
+		Errors bool                                `json:"errors"`
+		Items  []map[string]*ElasticSearchBulkItem `json:"items"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	result := &ElasticSearchBulkResponse{
+		Took:   raw.Took,
+		Errors: raw.Errors,
+		Items:  make([]*ElasticSearchBulkItem, 0, len(raw.Items)),
+	}
+	for _, entry := range raw.Items {
+		for operation, item := range entry {
+			if item == nil {
+				item = &ElasticSearchBulkItem{}
+			}
+			item.Operation = operation
+			result.Items = append(result.Items, item)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,207 @@
+package connect
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	esapi "github.com/elastic/go-elasticsearch/v8/esapi"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Categories of ElasticSearch error types mapped to pip-services errors
+var (
+	notFoundErrorTypes = []string{
+		"index_not_found_exception",
+		"resource_not_found_exception",
+		"document_missing_exception",
+	}
+	conflictErrorTypes = []string{
+		"version_conflict_engine_exception",
+		"resource_already_exists_exception",
+	}
+	unauthorizedErrorTypes = []string{
+		"security_exception",
+	}
+	badRequestErrorTypes = []string{
+		"parsing_exception",
+		"x_content_parse_exception",
+		"illegal_argument_exception",
+		"mapper_parsing_exception",
+		"strict_dynamic_mapping_exception",
+		"action_request_validation_exception",
+		"query_shard_exception",
+		"search_phase_execution_exception",
+		"invalid_index_name_exception",
+	}
+	invalidStateErrorTypes = []string{
+		"cluster_block_exception",
+		"illegal_state_exception",
+	}
+	noResponseErrorTypes = []string{
+		"unavailable_shards_exception",
+		"no_shard_available_action_exception",
+		"master_not_discovered_exception",
+		"node_not_connected_exception",
+		"node_disconnected_exception",
+	}
+	failedInvocationErrorTypes = []string{
+		"circuit_breaking_exception",
+		"es_rejected_execution_exception",
+		"timeout_exception",
+		"process_cluster_event_timeout_exception",
+	}
+)
+
+// ElasticSearchErrorInfo holds error details returned by ElasticSearch in error responses
+// and failed bulk items.
+type ElasticSearchErrorInfo struct {
+	Type      string                   `json:"type"`
+	Reason    string                   `json:"reason"`
+	Index     string                   `json:"index,omitempty"`
+	RootCause []ElasticSearchErrorInfo `json:"root_cause,omitempty"`
+	CausedBy  *ElasticSearchErrorInfo  `json:"caused_by,omitempty"`
+}
+
+// NewErrorFromResponse reads ElasticSearch error response and converts it into
+// ApplicationError of the matching category. The response body is consumed.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - resp *esapi.Response  ElasticSearch response
+// Returns *cerr.ApplicationError or nil if the response is successful.
+func NewErrorFromResponse(correlationId string, resp *esapi.Response) *cerr.ApplicationError {
+	if resp == nil || !resp.IsError() {
+		return nil
+	}
+
+	var body struct {
+		Error  json.RawMessage `json:"error"`
+		Status int             `json:"status"`
+	}
+
+	info := ElasticSearchErrorInfo{}
+	data, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, &body); err == nil && len(body.Error) > 0 {
+		// Old versions return error as a plain string
+		if json.Unmarshal(body.Error, &info) != nil {
+			var reason string
+			json.Unmarshal(body.Error, &reason)
+			info.Reason = reason
+		}
+	} else if len(data) > 0 {
+		info.Reason = string(data)
+	}
+
+	return NewErrorFromInfo(correlationId, resp.StatusCode, &info)
+}
+
+// NewErrorFromInfo converts ElasticSearch error details into ApplicationError of the matching category.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - status int  HTTP status code of the response or bulk item
+//   - info *ElasticSearchErrorInfo  error details returned by ElasticSearch
+// Returns *cerr.ApplicationError
+func NewErrorFromInfo(correlationId string, status int, info *ElasticSearchErrorInfo) *cerr.ApplicationError {
+	if info == nil {
+		info = &ElasticSearchErrorInfo{}
+	}
+
+	errType := info.Type
+	code := strings.ToUpper(errType)
+	if code == "" {
+		code = "ELASTICSEARCH_ERROR"
+	}
+	message := info.Reason
+	if message == "" {
+		message = "ElasticSearch request failed with status " + strconv.Itoa(status)
+	}
+
+	var err *cerr.ApplicationError
+	switch categoryOf(errType, status) {
+	case cerr.Unauthorized:
+		err = cerr.NewUnauthorizedError(correlationId, code, message)
+		if status == 403 {
+			err = err.WithStatus(status)
+		}
+	case cerr.NotFound:
+		err = cerr.NewNotFoundError(correlationId, code, message)
+	case cerr.Conflict:
+		err = cerr.NewConflictError(correlationId, code, message)
+	case cerr.BadRequest:
+		err = cerr.NewBadRequestError(correlationId, code, message)
+	case cerr.InvalidState:
+		err = cerr.NewInvalidStateError(correlationId, code, message)
+	case cerr.NoResponse:
+		err = cerr.NewConnectionError(correlationId, code, message).WithStatus(status)
+	case cerr.FailedInvocation:
+		err = cerr.NewInvocationError(correlationId, code, message).WithStatus(status)
+	default:
+		err = cerr.NewUnknownError(correlationId, code, message)
+		if status > 0 {
+			err = err.WithStatus(status)
+		}
+	}
+
+	if errType != "" {
+		err = err.WithDetails("type", errType)
+	}
+	if info.Index != "" {
+		err = err.WithDetails("index", info.Index)
+	}
+	if info.CausedBy != nil && info.CausedBy.Reason != "" {
+		err = err.WithCauseString(info.CausedBy.Reason)
+	} else if len(info.RootCause) > 0 && info.RootCause[0].Reason != message {
+		err = err.WithCauseString(info.RootCause[0].Reason)
+	}
+
+	return err
+}
+
+// categoryOf detects error category by ElasticSearch error type and falls back to HTTP status.
+func categoryOf(errType string, status int) string {
+	switch {
+	case contains(unauthorizedErrorTypes, errType):
+		return cerr.Unauthorized
+	case contains(notFoundErrorTypes, errType):
+		return cerr.NotFound
+	case contains(conflictErrorTypes, errType):
+		return cerr.Conflict
+	case contains(badRequestErrorTypes, errType):
+		return cerr.BadRequest
+	case contains(invalidStateErrorTypes, errType):
+		return cerr.InvalidState
+	case contains(noResponseErrorTypes, errType):
+		return cerr.NoResponse
+	case contains(failedInvocationErrorTypes, errType):
+		return cerr.FailedInvocation
+	}
+
+	switch status {
+	case 400:
+		return cerr.BadRequest
+	case 401, 403:
+		return cerr.Unauthorized
+	case 404:
+		return cerr.NotFound
+	case 409:
+		return cerr.Conflict
+	case 429:
+		return cerr.FailedInvocation
+	case 502, 503, 504:
+		return cerr.NoResponse
+	}
+	return cerr.Unknown
+}
+
+func contains(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,470 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+const (
+	// AnyVersion appends events to the end of the stream without checking its version
+	AnyVersion int64 = -1
+	// NoStream appends events only when the stream has no events yet
+	NoStream int64 = 0
+)
+
+// Maximum number of events appended by concurrent writers an AnyVersion append skips over
+const maxAnyVersionConflicts = 100
+
+/*
+ElasticSearchEventStore is an append-only store of event streams kept in an ElasticSearch index.
+Every event is a document identified by the aggregate id and the version, created with
+op_type=create, so two writers can never store different events under the same version.
+
+Append checks the expected version of the stream: it fails with ConflictError
+WRONG_EXPECTED_VERSION when the stream was changed since it was read. Appends with AnyVersion
+move past events stored by concurrent writers, so events of one append may interleave with them.
+Only appends of a single event are atomic. Events of one append are written one by one in version
+order, so a failure in the middle leaves the preceding events stored, but never leaves gaps in the stream.
+
+Configuration parameters:
+
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  host name or IP address
+    - port:                  port number
+    - uri:                   resource URI or connection string with all parameters in it
+- options:
+    - index:             index name (default: "events")
+    - max_page_size:     maximum number of events returned by ReadStream (default: 1000)
+    - timeout:           invocation timeout in milliseconds (default: 30 sec)
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to write own diagnostics
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+
+Example:
+
+    store := NewElasticSearchEventStore()
+    store.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.uri", "http://localhost:9200",
+        "options.index", "order_events",
+    ))
+
+    err := store.Open("123")
+    ...
+
+    event, _ := NewEvent("OrderPlaced", order)
+    version, err := store.Append("123", order.Id, NoStream, event)
+
+    events, err := store.ReadStream("123", order.Id, 1, 100)
+*/
+type ElasticSearchEventStore struct {
+	connectionResolver *crpccon.HttpConnectionResolver
+	logger             *clog.CompositeLogger
+	transport          http.RoundTripper
+
+	lock   sync.Mutex
+	client *esv8.Client
+
+	index       string
+	maxPageSize int
+	timeout     int
+}
+
+// NewElasticSearchEventStore method creates a new instance of the event store.
+// Returns *ElasticSearchEventStore
+// pointer on new ElasticSearchEventStore
+func NewElasticSearchEventStore() *ElasticSearchEventStore {
+	c := ElasticSearchEventStore{}
+	c.connectionResolver = crpccon.NewHttpConnectionResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.index = "events"
+	c.maxPageSize = 1000
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchEventStore) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.maxPageSize = config.GetAsIntegerWithDefault("options.max_page_size", c.maxPageSize)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *ElasticSearchEventStore) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+}
+
+// SetTransport method sets a custom HTTP transport used to send requests to ElasticSearch.
+// It must be called before the store is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to be used
+func (c *ElasticSearchEventStore) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *ElasticSearchEventStore) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.client != nil
+}
+
+// Open method are opens the component and creates the event index if it does not exist.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchEventStore) Open(correlationId string) (err error) {
+	if c.IsOpen() {
+		return nil
+	}
+
+	client, err := econnect.NewBasicClient(correlationId, c.connectionResolver, c.transport, c.timeout)
+	if err != nil {
+		return err
+	}
+	if err := c.createIndex(correlationId, client); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.client = client
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Close method are closes component.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchEventStore) Close(correlationId string) (err error) {
+	c.lock.Lock()
+	c.client = nil
+	c.lock.Unlock()
+
+	return nil
+}
+
+func (c *ElasticSearchEventStore) getClient(correlationId string) (*esv8.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.client == nil {
+		return nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Event store is not opened")
+	}
+	return c.client, nil
+}
+
+func (c *ElasticSearchEventStore) createIndex(correlationId string, client *esv8.Client) error {
+	resp, err := client.Indices.Exists([]string{c.index})
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT",
+			"Failed to check event index "+c.index).WithCause(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == 200 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"event_id":       map[string]interface{}{"type": "keyword"},
+				"aggregate_id":   map[string]interface{}{"type": "keyword"},
+				"version":        map[string]interface{}{"type": "long"},
+				"event_type":     map[string]interface{}{"type": "keyword"},
+				"correlation_id": map[string]interface{}{"type": "keyword"},
+				"time":           map[string]interface{}{"type": "date"},
+				// Events are read by streams, their content is not searched
+				"data": map[string]interface{}{"type": "object", "enabled": false},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err = client.Indices.Create(c.index, client.Indices.Create.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CREATE_INDEX",
+			"Failed to create event index "+c.index).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	appErr := econnect.NewErrorFromResponse(correlationId, resp)
+	// The index could be created by another instance of the store
+	if appErr != nil && appErr.Code == "RESOURCE_ALREADY_EXISTS_EXCEPTION" {
+		return nil
+	}
+	if appErr != nil {
+		return appErr
+	}
+	c.logger.Debug(correlationId, "Created event index %s", c.index)
+	return nil
+}
+
+// eventDocumentId returns id of the document that keeps the event with the version.
+func eventDocumentId(aggregateId string, version int64) string {
+	return aggregateId + ":" + strconv.FormatInt(version, 10)
+}
+
+func wrongVersion(correlationId string, aggregateId string, expectedVersion int64) *cerr.ApplicationError {
+	return cerr.NewConflictError(correlationId, "WRONG_EXPECTED_VERSION",
+		"Stream "+aggregateId+" is not at version "+strconv.FormatInt(expectedVersion, 10)).
+		WithDetails("aggregate_id", aggregateId).
+		WithDetails("expected_version", expectedVersion)
+}
+
+// Append method appends events to the end of the aggregate stream.
+// The events get consecutive versions following the expected version and share the same time.
+// With AnyVersion the events stored by concurrent writers in the meantime are skipped over
+// and the events get the next free versions.
+// Only a single event is appended atomically: when appending of several events fails in the middle,
+// the events before the failed one stay stored and the returned version is the version of the last of them.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - aggregateId string  id of the aggregate stream
+//   - expectedVersion int64  version of the last event in the stream, NoStream for a new stream
+//     or AnyVersion to skip the check
+//   - events ...*Event  events to be appended
+// Returns the new version of the stream or ConflictError if the stream is not at the expected version.
+func (c *ElasticSearchEventStore) Append(correlationId string, aggregateId string, expectedVersion int64,
+	events ...*Event) (int64, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return 0, err
+	}
+	if aggregateId == "" {
+		return 0, cerr.NewBadRequestError(correlationId, "NO_AGGREGATE_ID", "Aggregate id is not set")
+	}
+
+	version := expectedVersion
+	if expectedVersion == AnyVersion {
+		if version, err = c.GetStreamVersion(correlationId, aggregateId); err != nil {
+			return 0, err
+		}
+	} else if expectedVersion > NoStream {
+		// Reading by id is realtime, unlike searches it does not wait for refreshes
+		exists, err := c.versionExists(correlationId, client, aggregateId, expectedVersion)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			return 0, wrongVersion(correlationId, aggregateId, expectedVersion)
+		}
+	} else if expectedVersion < AnyVersion {
+		return 0, cerr.NewBadRequestError(correlationId, "WRONG_VERSION", "Expected version is not valid").
+			WithDetails("expected_version", expectedVersion)
+	}
+
+	// All events of the append are stamped with the same time
+	now := time.Now().UTC()
+	conflicts := 0
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+
+		doc := *event
+		doc.AggregateId = aggregateId
+		doc.Version = version + 1
+		doc.Time = now
+		if doc.EventId == "" {
+			doc.EventId = cdata.IdGenerator.NextLong()
+		}
+		if doc.CorrelationId == "" {
+			doc.CorrelationId = correlationId
+		}
+
+		for {
+			err := c.create(correlationId, client, &doc)
+			if err == nil {
+				break
+			}
+			appErr, ok := err.(*cerr.ApplicationError)
+			if !ok || appErr.Category != cerr.Conflict {
+				return version, err
+			}
+			if expectedVersion != AnyVersion || conflicts >= maxAnyVersionConflicts {
+				return version, wrongVersion(correlationId, aggregateId, version)
+			}
+
+			// Searches may not see events just stored by other writers, so free version is probed by ids
+			if version, conflicts, err = c.skipStoredVersions(correlationId, client, aggregateId,
+				doc.Version, conflicts+1); err != nil {
+				return version, err
+			}
+			doc.Version = version + 1
+		}
+
+		*event = doc
+		version = doc.Version
+	}
+
+	c.logger.Trace(correlationId, "Appended %d events to stream %s at version %d", len(events), aggregateId, version)
+	return version, nil
+}
+
+// skipStoredVersions finds the last version of the stream starting from the version known to be stored.
+// Returns the version, the number of stored versions counted so far and error if the request failed.
+func (c *ElasticSearchEventStore) skipStoredVersions(correlationId string, client *esv8.Client,
+	aggregateId string, version int64, conflicts int) (int64, int, error) {
+	for conflicts < maxAnyVersionConflicts {
+		exists, err := c.versionExists(correlationId, client, aggregateId, version+1)
+		if err != nil || !exists {
+			return version, conflicts, err
+		}
+		conflicts++
+		version++
+	}
+	return version, conflicts, nil
+}
+
+// create stores the event unless the stream already has an event with the same version.
+func (c *ElasticSearchEventStore) create(correlationId string, client *esv8.Client, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Index(c.index, bytes.NewReader(body),
+		client.Index.WithDocumentID(eventDocumentId(event.AggregateId, event.Version)),
+		client.Index.WithOpType("create"),
+		client.Index.WithRefresh("wait_for"),
+	)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_APPEND",
+			"Failed to append event to stream "+event.AggregateId).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// versionExists checks if the stream has an event with the version.
+func (c *ElasticSearchEventStore) versionExists(correlationId string, client *esv8.Client,
+	aggregateId string, version int64) (bool, error) {
+	resp, err := client.Exists(c.index, eventDocumentId(aggregateId, version))
+	if err != nil {
+		return false, cerr.NewConnectionError(correlationId, "CANNOT_READ",
+			"Failed to read stream "+aggregateId).WithCause(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return false, nil
+	}
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return false, appErr
+	}
+	return true, nil
+}
+
+// search reads events of the stream ordered by version.
+func (c *ElasticSearchEventStore) search(correlationId string, aggregateId string,
+	fromVersion int64, size int, ascending bool) ([]*Event, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := equery.NewBoolQuery().Filter(equery.NewTermQuery("aggregate_id", aggregateId))
+	if fromVersion > 1 {
+		query.Filter(equery.NewRangeQuery("version").Gte(fromVersion))
+	}
+	request := equery.NewSearchRequest().Query(query).Sort("version", ascending).Size(size)
+	body, err := json.Marshal(request.Source())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Search(
+		client.Search.WithIndex(c.index),
+		client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_READ",
+			"Failed to read stream "+aggregateId).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, appErr
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source Event `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	events := make([]*Event, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		event := hit.Source
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+// ReadStream method reads events of the aggregate stream ordered by version.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - aggregateId string  id of the aggregate stream
+//   - fromVersion int64  version of the first event to read, 1 reads the stream from the beginning
+//   - maxCount int  maximum number of events to read, it is limited by max_page_size
+// Returns events or error if the request failed.
+func (c *ElasticSearchEventStore) ReadStream(correlationId string, aggregateId string,
+	fromVersion int64, maxCount int) ([]*Event, error) {
+	if maxCount <= 0 || maxCount > c.maxPageSize {
+		maxCount = c.maxPageSize
+	}
+	return c.search(correlationId, aggregateId, fromVersion, maxCount, true)
+}
+
+// GetStreamVersion method returns the version of the last event in the aggregate stream.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - aggregateId string  id of the aggregate stream
+// Returns the version, NoStream if the stream has no events or error if the request failed.
+func (c *ElasticSearchEventStore) GetStreamVersion(correlationId string, aggregateId string) (int64, error) {
+	events, err := c.search(correlationId, aggregateId, 1, 1, false)
+	if err != nil || len(events) == 0 {
+		return NoStream, err
+	}
+	return events[0].Version, nil
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// Event is an event appended to a stream of ElasticSearchEventStore.
+type Event struct {
+	// Unique event id, it is generated when the event is appended without it
+	EventId string `json:"event_id"`
+	// Id of the aggregate the event belongs to, it is set by the store
+	AggregateId string `json:"aggregate_id"`
+	// Position of the event in the aggregate stream starting from 1, it is set by the store
+	Version int64 `json:"version"`
+	// Type of the event used by readers to parse it
+	EventType string `json:"event_type"`
+	// Transaction id to trace execution through call chain
+	CorrelationId string `json:"correlation_id"`
+	// Time when the event was appended
+	Time time.Time `json:"time"`
+	// Event content in JSON
+	Data json.RawMessage `json:"data"`
+}
+
+// NewEvent method creates a new event with the value serialized into JSON.
+// Parameters:
+//   - eventType string  a type of the event
+//   - value interface{}  a value to be serialized
+// Returns the event or error if the value cannot be serialized.
+func NewEvent(eventType string, value interface{}) (*Event, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Event{
+		EventId:   cdata.IdGenerator.NextLong(),
+		EventType: eventType,
+		Data:      data,
+	}
+	return &c, nil
+}
+
+// GetDataAsObject method deserializes the JSON event content into the value.
+// Parameters:
+//   - value interface{}  a pointer to the value to be set
+// Returns error if the content is not valid JSON.
+func (c *Event) GetDataAsObject(value interface{}) error {
+	return json.Unmarshal(c.Data, value)
+}
@@ -0,0 +1,485 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+// ChangedDocument is a document delivered by ElasticSearchChangeFeed.
+type ChangedDocument struct {
+	// Index the document is stored in
+	Index string
+	// Id of the document
+	Id string
+	// Value of the checkpoint field
+	Checkpoint interface{}
+	// Source of the document
+	Source map[string]interface{}
+}
+
+// IChangeHandler is an interface for components that process documents delivered by the change feed.
+type IChangeHandler interface {
+	// HandleChanges is called with a batch of new or updated documents ordered by the checkpoint field.
+	// The batch is delivered again when it returns error.
+	HandleChanges(correlationId string, documents []*ChangedDocument) error
+}
+
+// feedCheckpoint is the last delivered value of the checkpoint field
+// and ids of delivered documents with that value.
+type feedCheckpoint struct {
+	Value interface{} `json:"value"`
+	Ids   []string    `json:"ids"`
+}
+
+/*
+ElasticSearchChangeFeed is a component that polls an index for documents with the checkpoint field
+newer than the last delivered one and passes them to referenced IChangeHandler components.
+It enables simple change-driven integrations without a message broker.
+
+Documents are delivered at least once: the checkpoint is saved into checkpoint_index after
+handlers accept a batch, and the batch is delivered again after errors or restarts.
+The checkpoint field must increase with every write, like a sequence number or an update time.
+Documents written with a checkpoint older than the delivered one are not delivered.
+
+Configuration parameters:
+
+- name:                  name of the feed, the checkpoint is stored under it (default: the index name)
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  host name or IP address
+    - port:                  port number
+    - uri:                   resource URI or connection string with all parameters in it
+- options:
+    - index:             index name or pattern to poll
+    - checkpoint_field:  date or numeric field that increases with every write (default: "update_time")
+    - checkpoint_index:  index to store checkpoints in (default: "change_feed_checkpoints")
+    - interval:          interval in milliseconds between polls, it must be positive (default: 1 sec)
+    - batch_size:        maximum number of documents delivered in one batch, it must be positive (default: 100)
+    - timeout:           invocation timeout in milliseconds (default: 30 sec)
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to write own diagnostics
+- *:change-handler:*:*:1.0    (optional)  IChangeHandler components to process documents
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+
+Example:
+
+    feed := NewElasticSearchChangeFeed()
+    feed.Configure(cconf.NewConfigParamsFromTuples(
+        "name", "orders-to-billing",
+        "connection.uri", "http://localhost:9200",
+        "options.index", "orders",
+        "options.checkpoint_field", "update_time",
+    ))
+    feed.SetHandler(handler)
+
+    err := feed.Open("123")
+*/
+type ElasticSearchChangeFeed struct {
+	connectionResolver *crpccon.HttpConnectionResolver
+	logger             *clog.CompositeLogger
+	handlers           []IChangeHandler
+	transport          http.RoundTripper
+
+	lock       sync.Mutex
+	pollLock   sync.Mutex
+	client     *esv8.Client
+	timer      chan bool
+	checkpoint *feedCheckpoint
+
+	name            string
+	index           string
+	checkpointField string
+	checkpointIndex string
+	interval        int
+	batchSize       int
+	timeout         int
+
+	configError error
+}
+
+// NewElasticSearchChangeFeed method creates a new instance of the change feed.
+// Retruns *ElasticSearchChangeFeed
+// pointer on new ElasticSearchChangeFeed
+func NewElasticSearchChangeFeed() *ElasticSearchChangeFeed {
+	c := ElasticSearchChangeFeed{}
+	c.connectionResolver = crpccon.NewHttpConnectionResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.handlers = make([]IChangeHandler, 0)
+	c.checkpointField = "update_time"
+	c.checkpointIndex = "change_feed_checkpoints"
+	c.interval = 1000
+	c.batchSize = 100
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchChangeFeed) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+
+	c.name = config.GetAsStringWithDefault("name", c.name)
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.checkpointField = config.GetAsStringWithDefault("options.checkpoint_field", c.checkpointField)
+	c.checkpointIndex = config.GetAsStringWithDefault("options.checkpoint_index", c.checkpointIndex)
+	c.interval = config.GetAsIntegerWithDefault("options.interval", c.interval)
+	c.batchSize = config.GetAsIntegerWithDefault("options.batch_size", c.batchSize)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+
+	c.configError = nil
+	if c.interval <= 0 {
+		c.configError = cerr.NewConfigError("", "WRONG_INTERVAL",
+			"Configuration option options.interval must be positive").
+			WithDetails("interval", c.interval)
+	} else if c.batchSize <= 0 {
+		c.configError = cerr.NewConfigError("", "WRONG_BATCH_SIZE",
+			"Configuration option options.batch_size must be positive").
+			WithDetails("batch_size", c.batchSize)
+	}
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *ElasticSearchChangeFeed) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+
+	handlers := references.GetOptional(cref.NewDescriptor("*", "change-handler", "*", "*", "1.0"))
+	for _, handler := range handlers {
+		if h, ok := handler.(IChangeHandler); ok {
+			c.handlers = append(c.handlers, h)
+		}
+	}
+}
+
+// SetHandler method adds a handler called with delivered documents in addition to referenced ones.
+// Parameters:
+//   - handler IChangeHandler  a handler to be added
+func (c *ElasticSearchChangeFeed) SetHandler(handler IChangeHandler) {
+	c.handlers = append(c.handlers, handler)
+}
+
+// SetTransport method sets a custom HTTP transport used to send requests to ElasticSearch.
+// It must be called before the feed is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to be used
+func (c *ElasticSearchChangeFeed) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// getName returns the name the checkpoint is stored under.
+func (c *ElasticSearchChangeFeed) getName() string {
+	if c.name != "" {
+		return c.name
+	}
+	return c.index
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *ElasticSearchChangeFeed) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.client != nil
+}
+
+// Open method are opens the component, loads the saved checkpoint and starts polling.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchChangeFeed) Open(correlationId string) (err error) {
+	if c.IsOpen() {
+		return nil
+	}
+	if c.configError != nil {
+		return c.configError
+	}
+	if c.index == "" {
+		return cerr.NewConfigError(correlationId, "NO_INDEX", "options.index is not set").
+			WithDetails("option", "index")
+	}
+
+	client, err := econnect.NewBasicClient(correlationId, c.connectionResolver, c.transport, c.timeout)
+	if err != nil {
+		return err
+	}
+	checkpoint, err := c.loadCheckpoint(correlationId, client)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.client = client
+	c.checkpoint = checkpoint
+	c.timer = startInterval(func() {
+		c.Poll("elasticsearch_change_feed." + cdata.IdGenerator.NextShort())
+	}, c.interval)
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Close method are closes component and stops polling.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchChangeFeed) Close(correlationId string) (err error) {
+	c.lock.Lock()
+	timer := c.timer
+	c.timer = nil
+	c.client = nil
+	c.lock.Unlock()
+
+	if timer != nil {
+		timer <- true
+		close(timer)
+	}
+	return nil
+}
+
+// startInterval calls the function every interval until a value is sent to the returned channel.
+func startInterval(someFunc func(), milliseconds int) chan bool {
+	ticker := time.NewTicker(time.Duration(milliseconds) * time.Millisecond)
+	clear := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				someFunc()
+			case <-clear:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return clear
+}
+
+func (c *ElasticSearchChangeFeed) loadCheckpoint(correlationId string, client *esv8.Client) (*feedCheckpoint, error) {
+	resp, err := client.Get(c.checkpointIndex, c.getName())
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_READ_CHECKPOINT",
+			"Failed to read checkpoint of change feed "+c.getName()).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return &feedCheckpoint{Ids: []string{}}, nil
+	}
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, appErr
+	}
+
+	var result struct {
+		Source feedCheckpoint `json:"_source"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	// Keep sequence numbers and epoch millis exact
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Source.Ids == nil {
+		result.Source.Ids = []string{}
+	}
+	return &result.Source, nil
+}
+
+func (c *ElasticSearchChangeFeed) saveCheckpoint(correlationId string, client *esv8.Client,
+	checkpoint *feedCheckpoint) error {
+	body, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Index(c.checkpointIndex, bytes.NewReader(body),
+		client.Index.WithDocumentID(c.getName()))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_SAVE_CHECKPOINT",
+			"Failed to save checkpoint of change feed "+c.getName()).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// Poll method delivers documents written since the checkpoint to the handlers.
+// It is called periodically after the feed is opened.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns number of delivered documents or error if reading or handling failed.
+func (c *ElasticSearchChangeFeed) Poll(correlationId string) (int, error) {
+	c.pollLock.Lock()
+	defer c.pollLock.Unlock()
+
+	c.lock.Lock()
+	client := c.client
+	checkpoint := c.checkpoint
+	c.lock.Unlock()
+	if client == nil {
+		return 0, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Change feed is not opened")
+	}
+
+	delivered := 0
+	for {
+		documents, full, err := c.readChanges(correlationId, client, checkpoint)
+		if err != nil {
+			c.logger.Error(correlationId, err, "Failed to read changes of index %s", c.index)
+			return delivered, err
+		}
+		if len(documents) == 0 {
+			return delivered, nil
+		}
+
+		for _, handler := range c.handlers {
+			if err := handler.HandleChanges(correlationId, documents); err != nil {
+				c.logger.Error(correlationId, err, "Failed to handle %d changes of index %s", len(documents), c.index)
+				return delivered, err
+			}
+		}
+
+		next := advanceCheckpoint(checkpoint, documents)
+		if err := c.saveCheckpoint(correlationId, client, next); err != nil {
+			c.logger.Error(correlationId, err, "Failed to save checkpoint of change feed %s", c.getName())
+			return delivered, err
+		}
+
+		c.lock.Lock()
+		c.checkpoint = next
+		c.lock.Unlock()
+
+		checkpoint = next
+		delivered += len(documents)
+		if !full {
+			return delivered, nil
+		}
+	}
+}
+
+// readChanges reads documents with the checkpoint field not older than the checkpoint
+// and skips the ones delivered before. Returns the documents and true if more can be available.
+func (c *ElasticSearchChangeFeed) readChanges(correlationId string, client *esv8.Client,
+	checkpoint *feedCheckpoint) ([]*ChangedDocument, bool, error) {
+	query := equery.NewBoolQuery().Filter(equery.NewExistsQuery(c.checkpointField))
+	if checkpoint.Value != nil {
+		query.Filter(equery.NewRangeQuery(c.checkpointField).Gte(checkpoint.Value))
+	}
+
+	// Read past documents delivered at the checkpoint value, so the feed cannot get stuck
+	// when more than batch_size documents share the same value
+	size := c.batchSize + len(checkpoint.Ids)
+	request := equery.NewSearchRequest().Query(query).Sort(c.checkpointField, true).Size(size)
+	body, err := json.Marshal(request.Source())
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := client.Search(
+		client.Search.WithIndex(c.index),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithIgnoreUnavailable(true),
+		client.Search.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		return nil, false, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failed to read changes of index "+c.index).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, false, appErr
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Index  string                 `json:"_index"`
+				Id     string                 `json:"_id"`
+				Source map[string]interface{} `json:"_source"`
+				Sort   []interface{}          `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, false, err
+	}
+
+	seen := map[string]bool{}
+	for _, id := range checkpoint.Ids {
+		seen[id] = true
+	}
+
+	documents := make([]*ChangedDocument, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		if len(hit.Sort) == 0 {
+			continue
+		}
+		value := hit.Sort[0]
+		if seen[hit.Id] && sameValue(value, checkpoint.Value) {
+			continue
+		}
+		documents = append(documents, &ChangedDocument{
+			Index:      hit.Index,
+			Id:         hit.Id,
+			Checkpoint: value,
+			Source:     hit.Source,
+		})
+		if len(documents) >= c.batchSize {
+			break
+		}
+	}
+	full := len(result.Hits.Hits) >= size || len(documents) >= c.batchSize
+	return documents, full, nil
+}
+
+// sameValue compares checkpoint values decoded as json.Number or strings.
+func sameValue(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return valueKey(a) == valueKey(b)
+}
+
+// valueKey serializes a checkpoint value into a comparable string.
+func valueKey(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// advanceCheckpoint moves the checkpoint to the value of the last delivered document
+// and remembers ids of documents delivered with that value.
+func advanceCheckpoint(checkpoint *feedCheckpoint, documents []*ChangedDocument) *feedCheckpoint {
+	next := &feedCheckpoint{Value: checkpoint.Value, Ids: append([]string{}, checkpoint.Ids...)}
+	for _, document := range documents {
+		if !sameValue(document.Checkpoint, next.Value) {
+			next.Value = document.Checkpoint
+			next.Ids = []string{}
+		}
+		next.Ids = append(next.Ids, document.Id)
+	}
+	return next
+}
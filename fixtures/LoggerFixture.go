@@ -0,0 +1,156 @@
+package fixtures
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// ILogMessagesReader is an interface to read back messages written by the tested logger.
+// It is implemented by ElasticSearchLogReader and ElasticSearchMockLogger.
+type ILogMessagesReader interface {
+	// GetMessagesByCorrelationId retrieves messages written within the transaction sorted by time.
+	GetMessagesByCorrelationId(correlationId string, id string, limit int) ([]*clog.LogMessage, error)
+}
+
+/*
+LoggerFixture contains standard checks of ElasticSearchLogger, so components that embed
+or configure the logger can verify their setup in integration tests.
+The logger shall be configured and opened before the checks are run.
+
+Example:
+
+    logger := elog.NewElasticSearchLogger()
+    logger.Configure(config)
+    logger.Open("")
+    defer logger.Close("")
+
+    reader := elog.NewElasticSearchLogReader()
+    reader.Configure(config)
+    reader.Open("")
+    defer reader.Close("")
+
+    fixture := fixtures.NewLoggerFixture(logger, reader)
+    t.Run("Log Level", fixture.TestLogLevel)
+    t.Run("Read Back", fixture.TestReadBack)
+*/
+type LoggerFixture struct {
+	logger *elog.ElasticSearchLogger
+	reader ILogMessagesReader
+	// Number of messages written by TestReadBack
+	MessageCount int
+	// Time in milliseconds to wait until written messages become searchable
+	ReadTimeout int
+}
+
+// NewLoggerFixture method creates a new instance of the fixture.
+// Parameters:
+//   - logger *elog.ElasticSearchLogger  the tested logger
+//   - reader ILogMessagesReader  (optional) a reader to read back written messages
+// Retruns *LoggerFixture
+// pointer on new LoggerFixture
+func NewLoggerFixture(logger *elog.ElasticSearchLogger, reader ILogMessagesReader) *LoggerFixture {
+	c := LoggerFixture{}
+	c.logger = logger
+	c.reader = reader
+	c.MessageCount = 10
+	c.ReadTimeout = 5000
+	return &c
+}
+
+// TestLogLevel method checks that the logger level is valid.
+func (c *LoggerFixture) TestLogLevel(t *testing.T) {
+	assert.True(t, c.logger.Level() >= clog.None)
+
+	assert.True(t, c.logger.Level() <= clog.Trace)
+}
+
+// TestSimpleLogging method writes messages of all levels.
+func (c *LoggerFixture) TestSimpleLogging(t *testing.T) {
+	c.logger.SetLevel(clog.Trace)
+
+	c.logger.Fatal("", nil, "Fatal error message")
+	c.logger.Error("", nil, "Error message")
+	c.logger.Warn("", "Warning message")
+	c.logger.Info("", "Information message")
+	c.logger.Debug("", "Debug message")
+	c.logger.Trace("", "Trace message")
+	c.logger.Dump()
+
+	select {
+	case <-time.After(time.Duration(1000) * time.Millisecond):
+	}
+}
+
+// TestErrorLogging method writes messages with errors.
+func (c *LoggerFixture) TestErrorLogging(t *testing.T) {
+
+	var ex error = errors.New("Testing error throw")
+
+	c.logger.Fatal("123", ex, "Fatal error")
+	c.logger.Error("123", ex, "Recoverable error")
+	assert.NotNil(t, ex)
+	c.logger.Dump()
+	select {
+	case <-time.After(time.Duration(1000) * time.Millisecond):
+	}
+}
+
+// TestReadBack method writes MessageCount messages within a new transaction
+// and checks that all of them are read back. The order is not checked, since
+// messages written within the same millisecond share the time in the index.
+// It is skipped when the fixture has no reader.
+func (c *LoggerFixture) TestReadBack(t *testing.T) {
+	if c.reader == nil {
+		t.Skip("Reader is not set")
+	}
+
+	c.logger.SetLevel(clog.Trace)
+	correlationId := "fixture." + cdata.IdGenerator.NextLong()
+
+	for i := 0; i < c.MessageCount; i++ {
+		c.logger.Info(correlationId, "Message %d", i)
+	}
+	c.logger.Error(correlationId, errors.New("Testing error"), "Error message")
+	err := c.logger.Dump()
+	assert.Nil(t, err)
+
+	// Written documents become searchable after the index is refreshed
+	var messages []*clog.LogMessage
+	deadline := time.Now().Add(time.Duration(c.ReadTimeout) * time.Millisecond)
+	for {
+		messages, err = c.reader.GetMessagesByCorrelationId(correlationId, correlationId, c.MessageCount+1)
+		if err != nil || len(messages) > c.MessageCount || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	assert.Nil(t, err)
+	if !assert.Len(t, messages, c.MessageCount+1) {
+		return
+	}
+	read := map[string]*clog.LogMessage{}
+	for _, message := range messages {
+		assert.Equal(t, correlationId, message.CorrelationId)
+		read[message.Message] = message
+	}
+	for i := 0; i < c.MessageCount; i++ {
+		message, ok := read["Message "+strconv.Itoa(i)]
+		if assert.True(t, ok, "Message %d is not read back", i) {
+			assert.Equal(t, clog.Info, message.Level)
+		}
+	}
+
+	message, ok := read["Error message"]
+	if assert.True(t, ok, "Error message is not read back") {
+		assert.Equal(t, clog.Error, message.Level)
+		assert.Equal(t, "Testing error", message.Error.Message)
+	}
+}
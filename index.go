@@ -2,5 +2,11 @@ package elasticsearch
 
 import (
 	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/build"
+	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/events"
+	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/feed"
+	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/kibana"
 	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	_ "github.com/pip-services3-go/pip-services3-elasticsearch-go/queue"
 )
@@ -0,0 +1,230 @@
+package kibana
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Ids of the saved objects bundled for ElasticSearchLogger indices.
+const (
+	LogsIndexPatternId   = "pip-services-logs"
+	LogsAllSearchId      = "pip-services-logs-all"
+	LogsErrorsSearchId   = "pip-services-logs-errors"
+	LogsControlsId       = "pip-services-logs-controls"
+	LogsDashboardId      = "pip-services-logs-dashboard"
+	logsDefaultTimeField = "time"
+	logsEcsTimeField     = "@timestamp"
+)
+
+// logObjectFields holds names of log document fields shown and filtered in saved objects,
+// which depend on the schema the logger writes with.
+type logObjectFields struct {
+	level         string
+	source        string
+	correlationId string
+	// Kuery selecting error and fatal messages
+	errorsQuery string
+}
+
+var defaultLogObjectFields = logObjectFields{
+	level:         "level",
+	source:        "source",
+	correlationId: "correlation_id",
+	// Fatal = 1, Error = 2, level is a keyword, so values are listed instead of compared
+	errorsQuery: "level:(1 or 2)",
+}
+
+var ecsLogObjectFields = logObjectFields{
+	level:         "log.level",
+	source:        "service.name",
+	correlationId: "labels.correlation_id",
+	errorsQuery:   "log.level:(error or fatal)",
+}
+
+// NewLogSavedObjects method creates the bundled set of Kibana saved objects
+// for log indices written by ElasticSearchLogger: an index pattern, saved searches
+// for all messages and for errors, a filter controls panel and a logs dashboard.
+// Parameters:
+//   - indexPattern string  index pattern title, for instance "log,log-*" to match only daily indices of the logger
+//   - timeField    string  name of the time field in log documents
+//
+// Returns []map[string]interface{}
+// saved objects in the format accepted by Kibana import API
+func NewLogSavedObjects(indexPattern string, timeField string) []map[string]interface{} {
+	if timeField == "" {
+		timeField = logsDefaultTimeField
+	}
+	return newLogSavedObjects(indexPattern, timeField, defaultLogObjectFields)
+}
+
+// NewEcsLogSavedObjects method creates the same set of Kibana saved objects as NewLogSavedObjects
+// for log indices written by ElasticSearchLogger with "ecs" schema. Columns, filters and the errors
+// search use Elastic Common Schema fields, so the objects also show documents shipped by Filebeat.
+// Parameters:
+//   - indexPattern string  index pattern title, for instance "log,log-*"
+//   - timeField    string  name of the time field in log documents, "@timestamp" when it is empty
+//
+// Returns []map[string]interface{}
+// saved objects in the format accepted by Kibana import API
+func NewEcsLogSavedObjects(indexPattern string, timeField string) []map[string]interface{} {
+	if timeField == "" {
+		timeField = logsEcsTimeField
+	}
+	return newLogSavedObjects(indexPattern, timeField, ecsLogObjectFields)
+}
+
+func newLogSavedObjects(indexPattern string, timeField string,
+	fields logObjectFields) []map[string]interface{} {
+	columns := []string{fields.level, fields.source, fields.correlationId, "message"}
+	sort := [][]string{{timeField, "desc"}}
+
+	allSearch := map[string]interface{}{
+		"title":   "Logs: All messages",
+		"columns": columns,
+		"sort":    sort,
+		"kibanaSavedObjectMeta": map[string]interface{}{
+			"searchSourceJSON": toJson(map[string]interface{}{
+				"indexRefName": "kibanaSavedObjectMeta.searchSourceJSON.index",
+				"query":        map[string]interface{}{"language": "kuery", "query": ""},
+				"filter":       []interface{}{},
+			}),
+		},
+	}
+
+	errorsSearch := map[string]interface{}{
+		"title":   "Logs: Errors",
+		"columns": columns,
+		"sort":    sort,
+		"kibanaSavedObjectMeta": map[string]interface{}{
+			"searchSourceJSON": toJson(map[string]interface{}{
+				"indexRefName": "kibanaSavedObjectMeta.searchSourceJSON.index",
+				"query":        map[string]interface{}{"language": "kuery", "query": fields.errorsQuery},
+				"filter":       []interface{}{},
+			}),
+		},
+	}
+
+	controls := make([]interface{}, 0)
+	for i, field := range []string{fields.level, fields.source, fields.correlationId} {
+		controls = append(controls, map[string]interface{}{
+			"id":                  field,
+			"fieldName":           field,
+			"label":               field,
+			"type":                "list",
+			"indexPatternRefName": "control_" + strconv.Itoa(i) + "_index_pattern",
+			"options": map[string]interface{}{
+				"type":        "terms",
+				"multiselect": true,
+				// correlation_id keyword has many values, they are suggested as the user types
+				"dynamicOptions": field == fields.correlationId,
+				"size":           20,
+				"order":          "desc",
+			},
+			"parent": "",
+		})
+	}
+
+	controlsVis := map[string]interface{}{
+		"title": "Logs: Filters",
+		"visState": toJson(map[string]interface{}{
+			"title": "Logs: Filters",
+			"type":  "input_control_vis",
+			"params": map[string]interface{}{
+				"controls":              controls,
+				"updateFiltersOnChange": false,
+				"useTimeFilter":         true,
+				"pinFilters":            false,
+			},
+			"aggs": []interface{}{},
+		}),
+		"uiStateJSON": "{}",
+		"kibanaSavedObjectMeta": map[string]interface{}{
+			"searchSourceJSON": toJson(map[string]interface{}{}),
+		},
+	}
+
+	panels := []interface{}{
+		newDashboardPanel("1", "panel_0", 0, 0, 48, 8),
+		newDashboardPanel("2", "panel_1", 0, 8, 48, 15),
+		newDashboardPanel("3", "panel_2", 0, 23, 48, 20),
+	}
+
+	dashboard := map[string]interface{}{
+		"title":       "Logs: Overview",
+		"description": "Log messages filtered by level, source and correlation id",
+		"panelsJSON":  toJson(panels),
+		"optionsJSON": toJson(map[string]interface{}{"useMargins": true, "hidePanelTitles": false}),
+		"timeRestore": false,
+		"kibanaSavedObjectMeta": map[string]interface{}{
+			"searchSourceJSON": toJson(map[string]interface{}{
+				"query":  map[string]interface{}{"language": "kuery", "query": ""},
+				"filter": []interface{}{},
+			}),
+		},
+	}
+
+	indexRef := []interface{}{
+		newReference("kibanaSavedObjectMeta.searchSourceJSON.index", "index-pattern", LogsIndexPatternId),
+	}
+	controlsRefs := make([]interface{}, 0)
+	for i := range controls {
+		controlsRefs = append(controlsRefs,
+			newReference("control_"+strconv.Itoa(i)+"_index_pattern", "index-pattern", LogsIndexPatternId))
+	}
+
+	return []map[string]interface{}{
+		newSavedObject("index-pattern", LogsIndexPatternId, map[string]interface{}{
+			"title":         indexPattern,
+			"timeFieldName": timeField,
+		}, nil),
+		newSavedObject("search", LogsAllSearchId, allSearch, indexRef),
+		newSavedObject("search", LogsErrorsSearchId, errorsSearch, indexRef),
+		newSavedObject("visualization", LogsControlsId, controlsVis, controlsRefs),
+		newSavedObject("dashboard", LogsDashboardId, dashboard, []interface{}{
+			newReference("panel_0", "visualization", LogsControlsId),
+			newReference("panel_1", "search", LogsErrorsSearchId),
+			newReference("panel_2", "search", LogsAllSearchId),
+		}),
+	}
+}
+
+func newSavedObject(objType string, id string, attributes map[string]interface{},
+	references []interface{}) map[string]interface{} {
+	if references == nil {
+		references = []interface{}{}
+	}
+	return map[string]interface{}{
+		"type":       objType,
+		"id":         id,
+		"attributes": attributes,
+		"references": references,
+	}
+}
+
+func newReference(name string, refType string, id string) map[string]interface{} {
+	return map[string]interface{}{
+		"name": name,
+		"type": refType,
+		"id":   id,
+	}
+}
+
+func newDashboardPanel(index string, refName string, x int, y int, w int, h int) map[string]interface{} {
+	return map[string]interface{}{
+		"panelIndex":       index,
+		"panelRefName":     refName,
+		"embeddableConfig": map[string]interface{}{},
+		"gridData": map[string]interface{}{
+			"i": index,
+			"x": x,
+			"y": y,
+			"w": w,
+			"h": h,
+		},
+	}
+}
+
+func toJson(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
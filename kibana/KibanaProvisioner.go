@@ -0,0 +1,267 @@
+package kibana
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	ccon "github.com/pip-services3-go/pip-services3-components-go/connect"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+/*
+KibanaProvisioner is a component that imports a bundled set of saved searches
+and a basic logs dashboard into Kibana when it is opened.
+The dashboard shows messages written by ElasticSearchLogger and allows to filter
+them by level, source and correlation id. With "ecs" schema the objects use Elastic
+Common Schema fields, like the logger does with the same schema.
+
+Objects are imported via Kibana saved objects API and overwrite previous versions
+with the same ids, so the provisioning can safely be repeated on every start.
+
+Configuration parameters:
+
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  Kibana host name or IP address
+    - port:                  port int (default: 5601)
+    - uri:                   resource URI or connection string with all parameters in it
+- credential(s):
+    - store_key:             (optional) a key to retrieve the credentials from ICredentialStore
+    - username:              (optional) user name for basic authentication
+    - password:              (optional) user password
+- options:
+    - index:           ElasticSearch log index name, its daily and per-source indices are included (default: "log")
+    - schema:          document schema used by the logger: "default" or "ecs" (default: "default")
+    - time_field:      name of the time field in log documents (default: "time" or "@timestamp" with ecs schema)
+    - space:           (optional) Kibana space to import objects into
+    - timeout:         invocation timeout in milliseconds (default: 30 sec)
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to pass log messages
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+- *:credential-store:*:*:1.0  (optional)  Credential stores to resolve credentials
+
+Example:
+
+    provisioner := NewKibanaProvisioner()
+    provisioner.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.protocol", "http",
+        "connection.host", "localhost",
+        "connection.port", "5601",
+        "options.index", "log",
+    ))
+
+    err := provisioner.Open("123")
+*/
+type KibanaProvisioner struct {
+	connectionResolver *ccon.ConnectionResolver
+	credentialResolver *cauth.CredentialResolver
+	logger             *clog.CompositeLogger
+
+	index     string
+	schema    string
+	timeField string
+	space     string
+	timeout   int
+	opened    bool
+}
+
+// NewKibanaProvisioner method creates a new instance of the provisioner.
+// Retruns *KibanaProvisioner
+// pointer on new KibanaProvisioner
+func NewKibanaProvisioner() *KibanaProvisioner {
+	c := KibanaProvisioner{}
+	c.connectionResolver = ccon.NewEmptyConnectionResolver()
+	c.credentialResolver = cauth.NewEmptyCredentialResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.index = "log"
+	c.schema = "default"
+	c.timeField = "time"
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *KibanaProvisioner) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+	c.credentialResolver.Configure(config)
+
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.schema = config.GetAsStringWithDefault("options.schema", c.schema)
+	if c.schema == "ecs" {
+		c.timeField = "@timestamp"
+	}
+	c.timeField = config.GetAsStringWithDefault("options.time_field", c.timeField)
+	c.space = config.GetAsStringWithDefault("options.space", c.space)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *KibanaProvisioner) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.credentialResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *KibanaProvisioner) IsOpen() bool {
+	return c.opened
+}
+
+// Open method are opens the component and imports saved objects into Kibana.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *KibanaProvisioner) Open(correlationId string) (err error) {
+	if c.opened {
+		return nil
+	}
+
+	indexPattern := c.index + "," + c.index + "-*"
+	objects := NewLogSavedObjects(indexPattern, c.timeField)
+	if c.schema == "ecs" {
+		objects = NewEcsLogSavedObjects(indexPattern, c.timeField)
+	}
+
+	err = c.Provision(correlationId, objects)
+	if err != nil {
+		return err
+	}
+
+	c.opened = true
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *KibanaProvisioner) Close(correlationId string) (err error) {
+	c.opened = false
+	return nil
+}
+
+// Provision method imports saved objects into Kibana overwriting existing objects with the same ids.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - objects []map[string]interface{}  saved objects to import
+// Returns error or nil, if no errors occured.
+func (c *KibanaProvisioner) Provision(correlationId string, objects []map[string]interface{}) (err error) {
+	uri, err := c.resolveUri(correlationId)
+	if err != nil {
+		return err
+	}
+
+	credential, err := c.credentialResolver.Lookup(correlationId)
+	if err != nil {
+		return err
+	}
+
+	var ndjson bytes.Buffer
+	for _, object := range objects {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		ndjson.Write(data)
+		ndjson.WriteString("\n")
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "export.ndjson")
+	if err != nil {
+		return err
+	}
+	part.Write(ndjson.Bytes())
+	writer.Close()
+
+	route := uri
+	if c.space != "" {
+		route += "/s/" + c.space
+	}
+	route += "/api/saved_objects/_import?overwrite=true"
+
+	req, err := http.NewRequest(http.MethodPost, route, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("kbn-xsrf", "true")
+	if credential != nil && credential.Username() != "" {
+		req.SetBasicAuth(credential.Username(), credential.Password())
+	}
+
+	client := http.Client{Timeout: time.Duration(c.timeout) * time.Millisecond}
+	resp, err := client.Do(req)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT",
+			"Failed to connect to Kibana at "+uri).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return cerr.NewInvocationError(correlationId, "IMPORT_FAILED",
+			"Failed to import Kibana saved objects").
+			WithStatus(resp.StatusCode).
+			WithCauseString(string(data))
+	}
+
+	var result struct {
+		Success      bool          `json:"success"`
+		SuccessCount int           `json:"successCount"`
+		Errors       []interface{} `json:"errors"`
+	}
+	if err = json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return cerr.NewInvocationError(correlationId, "IMPORT_FAILED",
+			"Failed to import Kibana saved objects").
+			WithDetails("errors", result.Errors)
+	}
+
+	c.logger.Debug(correlationId, "Imported %d saved objects into Kibana at %s", result.SuccessCount, uri)
+	return nil
+}
+
+func (c *KibanaProvisioner) resolveUri(correlationId string) (string, error) {
+	connection, err := c.connectionResolver.Resolve(correlationId)
+	if err != nil {
+		return "", err
+	}
+	if connection == nil {
+		return "", cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection is not configured")
+	}
+
+	uri := connection.Uri()
+	if uri != "" {
+		return strings.TrimRight(uri, "/"), nil
+	}
+
+	host := connection.Host()
+	if host == "" {
+		return "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+	protocol := connection.ProtocolWithDefault("http")
+	port := connection.PortWithDefault(5601)
+	return protocol + "://" + host + ":" + strconv.Itoa(port), nil
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"strconv"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// checkAcks cross-checks items of the bulk response against the sent documents.
+// Every document must be acknowledged in the same position with a successful status
+// and, when ElasticSearch returns it, the same id.
+// Returns ACK_MISMATCH error with counts of sent, acknowledged, rejected, missing
+// and unexpected items or nil when every document was acknowledged.
+func (c *ElasticSearchLogger) checkAcks(correlationId string, sent []bulkEntry,
+	bulk *econnect.ElasticSearchBulkResponse) error {
+	acknowledged, rejected, unexpected := 0, 0, 0
+	for i, item := range bulk.Items {
+		if i >= len(sent) || (item.Id != "" && item.Id != sent[i].id) {
+			unexpected++
+		} else if (item.Failed() && !c.isDelivered(item.Status)) || item.Status < 200 {
+			rejected++
+		} else {
+			acknowledged++
+		}
+	}
+	missing := len(sent) - len(bulk.Items)
+	if missing < 0 {
+		missing = 0
+	}
+	if acknowledged == len(sent) && unexpected == 0 {
+		return nil
+	}
+
+	c.counters.Increment(metricsPrefix+".ack_mismatches", 1)
+	c.counters.Increment(metricsPrefix+".unacknowledged", len(sent)-acknowledged)
+
+	return cerr.NewInternalError(correlationId, "ACK_MISMATCH",
+		"ElasticSearch acknowledged "+strconv.Itoa(acknowledged)+" of "+strconv.Itoa(len(sent))+" sent documents").
+		WithDetails("sent", len(sent)).
+		WithDetails("acknowledged", acknowledged).
+		WithDetails("rejected", rejected).
+		WithDetails("missing", missing).
+		WithDetails("unexpected", unexpected)
+}
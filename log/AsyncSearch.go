@@ -0,0 +1,147 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+)
+
+// AsyncLogSearch is a state of a search submitted with SubmitAsyncSearch.
+type AsyncLogSearch struct {
+	// Id to poll or delete the search, it is empty when the search completed before it was stored
+	Id string
+	// True while the search is still running
+	Running bool
+	// True if the result does not include all shards yet
+	Partial bool
+	// Hits and aggregations found so far
+	Result *LogSearchResult
+}
+
+// asyncSearchResponse is a decoded response of ElasticSearch async search API.
+type asyncSearchResponse struct {
+	Id       string       `json:"id"`
+	Running  bool         `json:"is_running"`
+	Partial  bool         `json:"is_partial"`
+	Response searchResult `json:"response"`
+}
+
+// SubmitAsyncSearch method starts a long running search, for instance an aggregation over months
+// of logs, without holding the connection until it completes. The search runs on the cluster
+// and its result is polled with GetAsyncSearch.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - request *equery.SearchRequest  a search request, max_page_size hits are returned when its size is not set
+//   - waitFor time.Duration  time to wait for the search to complete before it is returned as running
+//   - keepAlive time.Duration  time the result is kept in the cluster, 0 keeps it for 5 days
+// Returns state of the search or error if it failed to start.
+func (c *ElasticSearchLogReader) SubmitAsyncSearch(correlationId string, request *equery.SearchRequest,
+	waitFor time.Duration, keepAlive time.Duration) (*AsyncLogSearch, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.composeSearchRequest(correlationId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	index := c.searchIndex()
+	options := []func(*esapi.AsyncSearchSubmitRequest){
+		client.AsyncSearch.Submit.WithIndex(index),
+		client.AsyncSearch.Submit.WithBody(bytes.NewReader(body)),
+		client.AsyncSearch.Submit.WithIgnoreUnavailable(true),
+		client.AsyncSearch.Submit.WithAllowNoIndices(true),
+		client.AsyncSearch.Submit.WithWaitForCompletionTimeout(waitFor),
+	}
+	if keepAlive > 0 {
+		options = append(options, client.AsyncSearch.Submit.WithKeepAlive(keepAlive))
+	}
+
+	resp, err := client.AsyncSearch.Submit(options...)
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to submit async search in ElasticSearch index %s", index)
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure submitting async search").WithCause(err)
+	}
+	return c.readAsyncSearch(correlationId, resp)
+}
+
+// GetAsyncSearch method polls the state and the result of a submitted search.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  an id returned by SubmitAsyncSearch
+//   - waitFor time.Duration  time to wait for the search to complete, 0 returns the current state
+// Returns state of the search or error if it is not found.
+func (c *ElasticSearchLogReader) GetAsyncSearch(correlationId string, id string,
+	waitFor time.Duration) (*AsyncLogSearch, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []func(*esapi.AsyncSearchGetRequest){}
+	if waitFor > 0 {
+		options = append(options, client.AsyncSearch.Get.WithWaitForCompletionTimeout(waitFor))
+	}
+
+	resp, err := client.AsyncSearch.Get(id, options...)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure getting async search "+id).WithCause(err)
+	}
+	return c.readAsyncSearch(correlationId, resp)
+}
+
+// DeleteAsyncSearch method cancels a running search or deletes a stored result.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  an id returned by SubmitAsyncSearch
+// Returns error or nil if the search was deleted or did not exist.
+func (c *ElasticSearchLogReader) DeleteAsyncSearch(correlationId string, id string) error {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.AsyncSearch.Delete(id)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_DELETE_SEARCH",
+			"Failure deleting async search "+id).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil
+	}
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func (c *ElasticSearchLogReader) readAsyncSearch(correlationId string, resp *esapi.Response) (*AsyncLogSearch, error) {
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to run async search in ElasticSearch")
+		return nil, appErr
+	}
+
+	var result asyncSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &AsyncLogSearch{
+		Id:      result.Id,
+		Running: result.Running,
+		Partial: result.Partial,
+		Result:  c.parseSearchResult(&result.Response),
+	}, nil
+}
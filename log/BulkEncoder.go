@@ -0,0 +1,75 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// bulkEntry references a log message sent in a bulk request.
+type bulkEntry struct {
+	message *clog.LogMessage
+	id      string
+	index   string
+	// Pre-serialized action metadata for the index
+	action  string
+	context *messageContext
+}
+
+// Buffers grown above this size are not returned to the pool
+// to avoid holding memory after occasional large flushes.
+const maxPooledBulkBufferSize = 4 * 1024 * 1024
+
+// bulkEncoder holds a reusable buffer and JSON encoder used to compose bulk request bodies.
+type bulkEncoder struct {
+	buf     bytes.Buffer
+	encoder *json.Encoder
+}
+
+var bulkEncoderPool = sync.Pool{
+	New: func() interface{} {
+		e := &bulkEncoder{}
+		e.encoder = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+func getBulkEncoder() *bulkEncoder {
+	e := bulkEncoderPool.Get().(*bulkEncoder)
+	e.buf.Reset()
+	return e
+}
+
+func putBulkEncoder(e *bulkEncoder) {
+	if e.buf.Cap() > maxPooledBulkBufferSize {
+		return
+	}
+	bulkEncoderPool.Put(e)
+}
+
+// bulkActionPrefix pre-serializes the constant part of the bulk action for the index.
+func bulkActionPrefix(opType string, index string, documentType string) string {
+	return fmt.Sprintf(`{ "%s": { "_index":"%s", "_type":"%s", "_id":"`, opType, index, documentType)
+}
+
+const bulkActionSuffix = "\"}}\n"
+
+// WriteDocument appends the index action and the document to the bulk body.
+// When the document cannot be encoded the body is left unchanged.
+func (c *bulkEncoder) WriteDocument(actionPrefix string, id string, doc interface{}) error {
+	length := c.buf.Len()
+
+	c.buf.WriteString(actionPrefix)
+	c.buf.WriteString(id)
+	c.buf.WriteString(bulkActionSuffix)
+
+	// Encoder terminates every document with a new line as required by bulk API
+	if err := c.encoder.Encode(doc); err != nil {
+		c.buf.Truncate(length)
+		return err
+	}
+	return nil
+}
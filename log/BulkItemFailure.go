@@ -0,0 +1,72 @@
+package log
+
+import (
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// BulkItemFailure describes a log message rejected by ElasticSearch in a bulk request.
+type BulkItemFailure struct {
+	// Original log message
+	Message *clog.LogMessage
+	// Document sent to ElasticSearch
+	Document map[string]interface{}
+	// Index the document was written to
+	Index string
+	// Id of the document
+	Id string
+	// HTTP status of the bulk item
+	Status int
+	// Rejection reason returned by ElasticSearch
+	Error *cerr.ApplicationError
+}
+
+/*
+IBulkFailureListener is an interface for components that shall be notified when
+ElasticSearch rejects log messages in bulk requests, for instance to move them into
+a dead letter queue or to raise alerts.
+*/
+type IBulkFailureListener interface {
+	// OnBulkFailure is called with all messages rejected in a single bulk request.
+	OnBulkFailure(correlationId string, failures []*BulkItemFailure)
+}
+
+// AddBulkFailureListener method adds a listener notified about rejected bulk items.
+// Parameters:
+//   - listener IBulkFailureListener  a listener to be added.
+func (c *ElasticSearchLogger) AddBulkFailureListener(listener IBulkFailureListener) {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	c.bulkFailureListeners = append(c.bulkFailureListeners, listener)
+}
+
+// RemoveBulkFailureListener method removes a previously added listener.
+// Parameters:
+//   - listener IBulkFailureListener  a listener to be removed.
+func (c *ElasticSearchLogger) RemoveBulkFailureListener(listener IBulkFailureListener) {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	for i, l := range c.bulkFailureListeners {
+		if l == listener {
+			c.bulkFailureListeners = append(c.bulkFailureListeners[:i], c.bulkFailureListeners[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *ElasticSearchLogger) notifyBulkFailures(correlationId string, failures []*BulkItemFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	c.listenersLock.Lock()
+	listeners := make([]IBulkFailureListener, len(c.bulkFailureListeners))
+	copy(listeners, c.bulkFailureListeners)
+	c.listenersLock.Unlock()
+
+	for _, listener := range listeners {
+		listener.OnBulkFailure(correlationId, failures)
+	}
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"io"
+)
+
+// bulkStream is a bulk request body that encodes documents while
+// the request is being sent instead of materializing the whole batch in memory.
+type bulkStream struct {
+	reader *io.PipeReader
+	done   chan struct{}
+	sent   []bulkEntry
+	size   int
+}
+
+// newBulkStream starts encoding the entries into a pipe read by the bulk request.
+// Entries that cannot be encoded are reported to the error handler and skipped.
+// The first entry is encoded before the stream is returned.
+// Returns nil when none of the entries can be encoded and there is nothing to send.
+func newBulkStream(entries []bulkEntry,
+	compose func(entry bulkEntry) map[string]interface{}, onError func(err error)) *bulkStream {
+	encoder := getBulkEncoder()
+
+	first := 0
+	for ; first < len(entries); first++ {
+		entry := entries[first]
+		err := encoder.WriteDocument(entry.action, entry.id, compose(entry))
+		if err == nil {
+			break
+		}
+		onError(err)
+	}
+	if first == len(entries) {
+		putBulkEncoder(encoder)
+		return nil
+	}
+
+	reader, writer := io.Pipe()
+	c := &bulkStream{
+		reader: reader,
+		done:   make(chan struct{}),
+		sent:   make([]bulkEntry, 0, len(entries)-first),
+	}
+
+	go func() {
+		defer close(c.done)
+		defer putBulkEncoder(encoder)
+
+		for i := first; i < len(entries); i++ {
+			entry := entries[i]
+			if i > first {
+				encoder.buf.Reset()
+				if err := encoder.WriteDocument(entry.action, entry.id, compose(entry)); err != nil {
+					onError(err)
+					continue
+				}
+			}
+			if _, err := writer.Write(encoder.buf.Bytes()); err != nil {
+				// The request was aborted and the reader closed
+				writer.CloseWithError(err)
+				return
+			}
+			c.sent = append(c.sent, entry)
+			c.size += encoder.buf.Len()
+		}
+		writer.Close()
+	}()
+
+	return c
+}
+
+// Read reads the next chunk of the encoded bulk body.
+func (c *bulkStream) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// Close stops encoding and waits until the encoding goroutine exits.
+func (c *bulkStream) Close() error {
+	err := c.reader.Close()
+	<-c.done
+	return err
+}
+
+// Sent returns the entries written to the body in the order they were sent.
+// It shall be called only after the stream is closed.
+func (c *bulkStream) Sent() []bulkEntry {
+	return c.sent
+}
+
+// Size returns the number of bytes written to the body.
+// It shall be called only after the stream is closed.
+func (c *bulkStream) Size() int {
+	return c.size
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"net/http"
+
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cinfo "github.com/pip-services3-go/pip-services3-components-go/info"
+)
+
+// identityTransport sets User-Agent and X-Opaque-ID headers on requests to ElasticSearch,
+// so cluster slow logs and audit logs can attribute traffic to the microservice.
+type identityTransport struct {
+	transport http.RoundTripper
+	userAgent string
+	opaqueId  string
+}
+
+// RoundTrip sends the request with identity headers.
+// X-Opaque-ID already set for the request is preserved.
+func (c *identityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.opaqueId != "" && req.Header.Get("X-Opaque-Id") == "" {
+		req.Header.Set("X-Opaque-Id", c.opaqueId)
+	}
+	return c.transport.RoundTrip(req)
+}
+
+// configureIdentity derives default User-Agent and X-Opaque-ID from ContextInfo
+// when they are not set explicitly in options.
+func (c *ElasticSearchLogger) configureIdentity(references cref.IReferences) {
+	var contextInfo *cinfo.ContextInfo
+	switch value := references.GetOneOptional(
+		cref.NewDescriptor("pip-services", "context-info", "*", "*", "1.0")).(type) {
+	case *cinfo.ContextInfo:
+		contextInfo = value
+	case cinfo.ContextInfo:
+		contextInfo = &value
+	}
+	if contextInfo == nil || contextInfo.Name == "" {
+		return
+	}
+
+	identity := contextInfo.Name
+	if version, ok := contextInfo.Properties["version"]; ok && version != "" {
+		identity += "/" + version
+	}
+	if c.userAgent == "" {
+		c.userAgent = identity
+	}
+	if c.opaqueId == "" {
+		c.opaqueId = identity
+	}
+}
+
+// identify wraps the transport to set identity headers when they are configured.
+func (c *ElasticSearchLogger) identify(transport http.RoundTripper) http.RoundTripper {
+	if c.userAgent == "" && c.opaqueId == "" {
+		return transport
+	}
+	return &identityTransport{
+		transport: transport,
+		userAgent: c.userAgent,
+		opaqueId:  c.opaqueId,
+	}
+}
+
+// requestOpaqueId composes X-Opaque-ID for a single request, so it can be traced
+// back to the flush while still identifying the microservice.
+func (c *ElasticSearchLogger) requestOpaqueId(correlationId string) string {
+	if c.opaqueId == "" {
+		return correlationId
+	}
+	return c.opaqueId + ":" + correlationId
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// Maximum number of body bytes included into traced requests and responses
+const maxLoggedBodySize = 1024
+
+// clientLogger bridges request traces of ElasticSearch client into referenced loggers.
+// Requests are logged at debug level, at trace level request and response bodies are added.
+type clientLogger struct {
+	logger *clog.CompositeLogger
+	level  int
+}
+
+// LogRoundTrip logs a request sent by ElasticSearch client.
+func (c *clientLogger) LogRoundTrip(req *http.Request, res *http.Response, err error,
+	start time.Time, dur time.Duration) error {
+	if req == nil {
+		return nil
+	}
+	correlationId := req.Header.Get("X-Opaque-Id")
+
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+
+	if err != nil {
+		c.logger.Debug(correlationId, "ElasticSearch %s %s failed in %d ms: %v",
+			req.Method, req.URL.String(), dur.Milliseconds(), err)
+	} else {
+		c.logger.Debug(correlationId, "ElasticSearch %s %s responded %d in %d ms",
+			req.Method, req.URL.String(), status, dur.Milliseconds())
+	}
+
+	if c.level >= clog.Trace {
+		if req.Body != nil && req.Body != http.NoBody {
+			c.logger.Trace(correlationId, "ElasticSearch request body: %s", readLoggedBody(req.Body))
+		}
+		if res != nil && res.Body != nil && res.Body != http.NoBody {
+			c.logger.Trace(correlationId, "ElasticSearch response body: %s", readLoggedBody(res.Body))
+		}
+	}
+	return nil
+}
+
+// RequestBodyEnabled makes the client pass a copy of request body at trace level.
+func (c *clientLogger) RequestBodyEnabled() bool {
+	return c.level >= clog.Trace
+}
+
+// ResponseBodyEnabled makes the client pass a copy of response body at trace level.
+func (c *clientLogger) ResponseBodyEnabled() bool {
+	return c.level >= clog.Trace
+}
+
+func readLoggedBody(body io.ReadCloser) string {
+	defer body.Close()
+
+	data, _ := ioutil.ReadAll(io.LimitReader(body, maxLoggedBodySize+1))
+	if len(data) > maxLoggedBodySize {
+		return string(data[:maxLoggedBodySize]) + "..."
+	}
+	return string(data)
+}
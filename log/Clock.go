@@ -0,0 +1,141 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// IClock is a source of time used by ElasticSearchLogger to timestamp messages,
+// compute daily index names and schedule flushes and connection checks.
+type IClock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a channel that delivers ticks with the interval and a function to stop them.
+	NewTicker(interval time.Duration) (<-chan time.Time, func())
+}
+
+// systemClock implements IClock with the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// sleepWithClock waits until the clock moves by the duration.
+func sleepWithClock(clock IClock, duration time.Duration) {
+	ticks, stop := clock.NewTicker(duration)
+	defer stop()
+
+	<-ticks
+}
+
+/*
+ManualClock is IClock that moves only when it is advanced. It is used in tests
+to verify index rollover at midnight and flush intervals without waiting.
+
+Example:
+
+    clock := NewManualClock(time.Date(2024, 1, 1, 23, 59, 59, 0, time.UTC))
+    logger.SetClock(clock)
+    logger.Open("123")
+
+    logger.Info("123", "Written into log-20240101")
+    clock.Advance(time.Second)
+    logger.Info("123", "Written into log-20240102")
+*/
+type ManualClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	tickers []*manualTicker
+}
+
+type manualTicker struct {
+	interval time.Duration
+	next     time.Time
+	c        chan time.Time
+	stopped  bool
+}
+
+// NewManualClock method creates a new instance of the clock.
+// Parameters:
+//   - now time.Time  initial time of the clock
+// Retruns *ManualClock
+// pointer on new ManualClock
+func NewManualClock(now time.Time) *ManualClock {
+	c := ManualClock{}
+	c.now = now
+	return &c
+}
+
+// Now method returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// NewTicker method creates a ticker that fires when the clock is advanced past its interval.
+// Like time.Ticker it drops ticks for slow receivers.
+// Parameters:
+//   - interval time.Duration  interval between ticks
+// Returns the channel with ticks and a function to stop the ticker.
+func (c *ManualClock) NewTicker(interval time.Duration) (<-chan time.Time, func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ticker := &manualTicker{
+		interval: interval,
+		next:     c.now.Add(interval),
+		c:        make(chan time.Time, 1),
+	}
+	c.tickers = append(c.tickers, ticker)
+
+	stop := func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		ticker.stopped = true
+	}
+	return ticker.c, stop
+}
+
+// Advance method moves the clock forward and fires tickers whose intervals elapsed.
+// Parameters:
+//   - duration time.Duration  time to move the clock by
+func (c *ManualClock) Advance(duration time.Duration) {
+	c.Set(c.Now().Add(duration))
+}
+
+// Set method moves the clock to the specified time and fires tickers whose intervals elapsed.
+// Parameters:
+//   - now time.Time  new time of the clock
+func (c *ManualClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = now
+	active := c.tickers[:0]
+	for _, ticker := range c.tickers {
+		if ticker.stopped {
+			continue
+		}
+		active = append(active, ticker)
+		if ticker.interval <= 0 || now.Before(ticker.next) {
+			continue
+		}
+		for !ticker.next.After(now) {
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+		select {
+		case ticker.c <- now:
+		default:
+		}
+	}
+	c.tickers = active
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"sync/atomic"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+// configureFailover configures connections to the secondary cluster set in the "secondary" section.
+func (c *ElasticSearchLogger) configureFailover(config *cconf.ConfigParams) {
+	c.secondaryResolver = nil
+
+	secondary := config.GetSection("secondary")
+	if len(secondary.GetSection("connection").Keys()) == 0 && len(secondary.GetSection("connections").Keys()) == 0 {
+		return
+	}
+	c.secondaryResolver = crpccon.NewHttpConnectionResolver()
+	c.secondaryResolver.Configure(configureSrvConnections(secondary))
+}
+
+// activeResolver returns the resolver of the cluster the logger currently writes to.
+func (c *ElasticSearchLogger) activeResolver() *crpccon.HttpConnectionResolver {
+	if c.IsFailedOver() {
+		return c.secondaryResolver
+	}
+	return c.connectionResolver
+}
+
+// IsFailedOver method checks if the logger writes to the secondary cluster
+// after the primary one became unreachable.
+// Returns true if messages are written to the secondary cluster.
+func (c *ElasticSearchLogger) IsFailedOver() bool {
+	c.failoverLock.Lock()
+	defer c.failoverLock.Unlock()
+
+	return c.failedOver
+}
+
+// checkFailover switches to the secondary cluster after failover_after consecutive failed flushes.
+func (c *ElasticSearchLogger) checkFailover(correlationId string) {
+	if c.secondaryResolver == nil || c.customClient != nil || c.failoverAfter <= 0 {
+		return
+	}
+	if int(atomic.LoadInt32(&c.failedFlushes)) < c.failoverAfter {
+		return
+	}
+
+	c.failoverLock.Lock()
+	if c.failedOver {
+		c.failoverLock.Unlock()
+		return
+	}
+	c.failedOver = true
+	// Probes run asynchronously, so a successful probe can stop the timer
+	c.failbackTimer = setIntervalWithClock(c.clock, func() {
+		c.probePrimary("elasticsearch_logger." + cdata.IdGenerator.NextShort())
+	}, c.failbackInterval, true)
+	c.failoverLock.Unlock()
+
+	c.logger.Warn(correlationId, "Failing over to secondary ElasticSearch cluster after %d failed flushes",
+		c.failoverAfter)
+	c.resetCluster()
+}
+
+// probePrimary pings the primary cluster and switches back to it when it responds.
+func (c *ElasticSearchLogger) probePrimary(correlationId string) {
+	if !c.IsFailedOver() {
+		return
+	}
+
+	uris, err := c.resolveUrisWith(correlationId, c.connectionResolver)
+	if err != nil {
+		return
+	}
+	client, err := c.createClient(uris)
+	if err != nil {
+		return
+	}
+	resp, err := client.Info()
+	if err != nil {
+		return
+	}
+	appErr := econnect.NewErrorFromResponse(correlationId, resp)
+	resp.Body.Close()
+	if appErr != nil {
+		return
+	}
+
+	c.failoverLock.Lock()
+	if !c.failedOver {
+		c.failoverLock.Unlock()
+		return
+	}
+	c.failedOver = false
+	c.failoverLock.Unlock()
+
+	c.logger.Info(correlationId, "Primary ElasticSearch cluster at %s recovered, failing back", redactUris(uris))
+	c.stopFailback()
+	c.resetCluster()
+}
+
+// resetCluster tears down the client, so the next flush connects to the active cluster
+// and creates indices, policies and pipelines there.
+func (c *ElasticSearchLogger) resetCluster() {
+	c.indexLock.Lock()
+	c.indices = nil
+	c.indexLock.Unlock()
+
+	c.disconnect()
+}
+
+func (c *ElasticSearchLogger) stopFailback() {
+	c.failoverLock.Lock()
+	timer := c.failbackTimer
+	c.failbackTimer = nil
+	c.failoverLock.Unlock()
+
+	if timer != nil {
+		timer <- true
+		close(timer)
+	}
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// Protocol of connections resolved from DNS SRV records
+const SrvProtocol = "srv"
+
+// SrvLookup resolves DNS SRV records same way as net.LookupSRV does.
+type SrvLookup func(service string, proto string, name string) (string, []*net.SRV, error)
+
+// redactUri removes credentials from the uri to be shown in logs and errors.
+func redactUri(uri string) string {
+	address, _, _ := econnect.SplitCredentials(uri)
+	return address
+}
+
+// redactUris removes credentials from the uris and joins them to be shown in logs and errors.
+func redactUris(uris []string) string {
+	addresses := make([]string, len(uris))
+	for i, uri := range uris {
+		addresses[i] = redactUri(uri)
+	}
+	return strings.Join(addresses, ", ")
+}
+
+// configureSrvConnections turns connections with srv protocol into srv:// uris,
+// since HTTP connection resolver accepts only http and https protocols.
+func configureSrvConnections(config *cconf.ConfigParams) *cconf.ConfigParams {
+	result := cconf.NewConfigParamsFromValue(config.Value())
+	for _, key := range config.Keys() {
+		if !strings.HasSuffix(key, ".protocol") || config.GetAsString(key) != SrvProtocol {
+			continue
+		}
+		if !strings.HasPrefix(key, "connection.") && !strings.HasPrefix(key, "connections.") {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(key, "protocol")
+		if config.GetAsString(prefix+"uri") == "" {
+			result.Put(prefix+"uri", SrvProtocol+"://"+config.GetAsString(prefix+"host"))
+		}
+	}
+	return result
+}
+
+// resolveSrvUris resolves srv:// uri into addresses of the nodes registered in the DNS SRV record.
+// The nodes are addressed using srv_protocol and ports from the records, credentials of the srv uri are kept.
+// The nodes are ordered by priority ascending and by weight descending within the same priority.
+func (c *ElasticSearchLogger) resolveSrvUris(correlationId string, uri string) ([]string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, cerr.NewConfigError(correlationId, "WRONG_SRV_URI",
+			"Invalid DNS SRV uri "+redactUri(uri)).WithCause(err)
+	}
+
+	_, records, err := c.lookupSrv("", "", parsed.Hostname())
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_RESOLVE_SRV",
+			"Failed to resolve DNS SRV record "+parsed.Hostname()).WithCause(err)
+	}
+	if len(records) == 0 {
+		return nil, cerr.NewConnectionError(correlationId, "NO_SRV_RECORDS",
+			"DNS SRV record "+parsed.Hostname()+" has no nodes").WithDetails("name", parsed.Hostname())
+	}
+
+	records = append([]*net.SRV{}, records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		}
+		return records[i].Weight > records[j].Weight
+	})
+
+	uris := make([]string, 0, len(records))
+	for _, record := range records {
+		node := url.URL{
+			Scheme: c.srvProtocol,
+			User:   parsed.User,
+			Host:   net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))),
+		}
+		uris = append(uris, node.String())
+	}
+	return uris, nil
+}
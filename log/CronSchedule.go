@@ -0,0 +1,117 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// cronSchedule is a parsed cron expression with minute, hour, day of month,
+// month and day of week fields. Fields accept "*", numbers, lists, ranges and steps like "*/15".
+// Unlike classic cron, restricted day of month and day of week must both match.
+type cronSchedule struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+}
+
+func parseCronSchedule(expression string) (*cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, cerr.NewConfigError("", "WRONG_SCHEDULE",
+			"Schedule "+expression+" must have 5 fields: minute hour day month weekday").
+			WithDetails("schedule", expression)
+	}
+
+	limits := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	sets := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, ok := parseCronField(field, limits[i][0], limits[i][1])
+		if !ok {
+			return nil, cerr.NewConfigError("", "WRONG_SCHEDULE",
+				"Invalid field "+field+" in schedule "+expression).
+				WithDetails("schedule", expression)
+		}
+		sets[i] = set
+	}
+
+	return &cronSchedule{
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+	}, nil
+}
+
+func parseCronField(field string, min int, max int) (map[int]bool, bool) {
+	set := map[int]bool{}
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			value, err := strconv.Atoi(item[i+1:])
+			if err != nil || value <= 0 {
+				return nil, false
+			}
+			step = value
+			item = item[:i]
+		}
+
+		from, to := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			value, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, false
+			}
+			from, to = value, value
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, false
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, false
+		}
+
+		for value := from; value <= to; value += step {
+			set[value] = true
+		}
+	}
+	return set, true
+}
+
+// Next returns the first time after the given time that matches the schedule.
+// Schedules are evaluated in UTC.
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	next := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Any valid schedule matches within 4 years, including February 29
+	limit := next.AddDate(4, 0, 0)
+	for next.Before(limit) {
+		if !c.months[int(next.Month())] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.days[next.Day()] || !c.weekdays[int(next.Weekday())] {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.hours[next.Hour()] {
+			next = next.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if !c.minutes[next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return limit
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"strconv"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+const (
+	// IndexOpType overwrites documents with the same id
+	IndexOpType = "index"
+	// CreateOpType rejects documents whose id already exists in the index
+	CreateOpType = "create"
+)
+
+// documentId derives the id of the document from the content of the log message,
+// so a batch retried after a timeout writes the same ids again.
+// Identical messages logged at the same nanosecond receive the same id.
+func documentId(message *clog.LogMessage) string {
+	hash := sha1.New()
+	for _, part := range []string{
+		strconv.FormatInt(message.Time.UnixNano(), 10),
+		message.Source,
+		strconv.Itoa(message.Level),
+		message.CorrelationId,
+		message.Message,
+		message.Error.Type,
+		message.Error.Code,
+		message.Error.Message,
+		message.Error.StackTrace,
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+}
+
+// isDelivered checks if the bulk item was rejected only because the document had been
+// written by an earlier attempt of the same batch.
+func (c *ElasticSearchLogger) isDelivered(status int) bool {
+	return c.opType == CreateOpType && status == 409
+}
@@ -0,0 +1,185 @@
+package log
+
+import (
+	"strings"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// ClusterWriteStats describes messages written to one of the clusters in the dual-write mode.
+type ClusterWriteStats struct {
+	// Number of messages written to the cluster
+	Written int64
+	// Number of messages dropped after they failed to be written
+	Dropped int64
+	// Number of failed flushes
+	FailedFlushes int64
+	// Error of the last failed flush or nil
+	LastError error
+}
+
+// DualWriteStats compares completeness of the clusters written in the dual-write mode.
+type DualWriteStats struct {
+	// Cluster set in connection(s) that messages are retried in
+	Primary ClusterWriteStats
+	// Cluster set in the migration section
+	Migration ClusterWriteStats
+	// Number of messages waiting to be written to the migration cluster
+	Pending int
+}
+
+// Sections of the logger configuration that are not copied to the migration cluster
+var migrationSkippedSections = []string{"connection.", "connections.", "secondary.", "migration."}
+
+// configureMigration creates a shadow logger writing to the cluster set in the "migration" section.
+// It inherits all other parameters, which can be overridden in migration.options.
+func (c *ElasticSearchLogger) configureMigration(config *cconf.ConfigParams) error {
+	c.migration = nil
+
+	section := config.GetSection("migration")
+	if len(section.GetSection("connection").Keys()) == 0 && len(section.GetSection("connections").Keys()) == 0 {
+		return nil
+	}
+
+	params := cconf.NewEmptyConfigParams()
+	for _, key := range config.Keys() {
+		skip := false
+		for _, prefix := range migrationSkippedSections {
+			skip = skip || strings.HasPrefix(key, prefix)
+		}
+		if !skip {
+			params.Put(key, config.Get(key))
+		}
+	}
+	for _, name := range []string{"connection", "connections", "options"} {
+		params.AddSection(name, section.GetSection(name))
+	}
+
+	c.migration = NewElasticSearchLogger()
+	c.migration.parent = c
+	c.migration.Configure(params)
+	return c.migration.configError
+}
+
+// prepareMigration passes settings set after configuration to the migration logger.
+func (c *ElasticSearchLogger) prepareMigration() {
+	if c.migration == nil {
+		return
+	}
+	c.migration.transport = c.transport
+	c.migration.clock = c.clock
+	c.migration.customTemplates = c.customTemplates
+}
+
+// GetDualWriteStats method returns numbers of messages written to the primary and the migration
+// clusters, to compare their completeness before a cutover.
+// Returns statistics or nil when the migration section is not configured.
+func (c *ElasticSearchLogger) GetDualWriteStats() *DualWriteStats {
+	if c.migration == nil {
+		return nil
+	}
+
+	c.dualWriteLock.Lock()
+	defer c.dualWriteLock.Unlock()
+
+	stats := c.dualWriteStats
+	stats.Pending = len(c.migrationPending)
+	return &stats
+}
+
+// countPrimaryDropped records messages dropped from the primary cache after failed flushes.
+func (c *ElasticSearchLogger) countPrimaryDropped(count int) {
+	if c.migration == nil {
+		return
+	}
+
+	c.dualWriteLock.Lock()
+	defer c.dualWriteLock.Unlock()
+
+	c.dualWriteStats.Primary.Dropped += int64(count)
+}
+
+// dualWrite records the result of the primary write and queues the messages accepted
+// by the primary cluster for the migration cluster. Batches that failed in the primary
+// cluster are mirrored after their retry succeeds, so they are not duplicated there.
+// The migration cluster is written by a separate goroutine, so its failures and delays
+// do not hold up primary flushes. Queued messages that failed there are retried after the next flush.
+func (c *ElasticSearchLogger) dualWrite(correlationId string, messages []*clog.LogMessage, primaryErr error) {
+	if c.migration == nil {
+		return
+	}
+
+	c.dualWriteLock.Lock()
+	defer c.dualWriteLock.Unlock()
+
+	if primaryErr != nil {
+		c.dualWriteStats.Primary.FailedFlushes++
+		c.dualWriteStats.Primary.LastError = primaryErr
+	} else {
+		c.dualWriteStats.Primary.Written += int64(len(messages))
+		// The primary logger releases contexts after the flush,
+		// the migration logger keeps them until the messages are written there
+		for _, message := range messages {
+			c.migration.setMessageContext(message, c.getMessageContext(message))
+		}
+		c.migrationPending = append(c.migrationPending, messages...)
+		c.trimMigrationPending()
+	}
+
+	if len(c.migrationPending) > 0 && !c.migrationWriting {
+		c.migrationWriting = true
+		c.migrationDone.Add(1)
+		go c.writeMigration(correlationId)
+	}
+}
+
+// writeMigration writes queued messages to the migration cluster until the queue is empty
+// or a write fails. Failed messages are kept in the queue for the next attempt.
+func (c *ElasticSearchLogger) writeMigration(correlationId string) {
+	defer c.migrationDone.Done()
+
+	for {
+		c.dualWriteLock.Lock()
+		batch := c.migrationPending
+		c.migrationPending = nil
+		if len(batch) == 0 {
+			c.migrationWriting = false
+			c.dualWriteLock.Unlock()
+			return
+		}
+		c.dualWriteLock.Unlock()
+
+		err := c.migration.saveMessages(correlationId, batch)
+
+		c.dualWriteLock.Lock()
+		if err == nil {
+			c.dualWriteStats.Migration.Written += int64(len(batch))
+			c.dualWriteLock.Unlock()
+			c.migration.releaseMessageContexts(batch)
+			continue
+		}
+
+		c.logger.Error(correlationId, err, "Failed to write %d messages to migration ElasticSearch cluster", len(batch))
+		c.dualWriteStats.Migration.FailedFlushes++
+		c.dualWriteStats.Migration.LastError = err
+		c.migrationPending = append(batch, c.migrationPending...)
+		c.trimMigrationPending()
+		c.migrationWriting = false
+		c.dualWriteLock.Unlock()
+		return
+	}
+}
+
+// trimMigrationPending keeps the migration backlog within the cache size by dropping the oldest messages.
+// It must be called with dualWriteLock held.
+func (c *ElasticSearchLogger) trimMigrationPending() {
+	if len(c.migrationPending) <= c.MaxCacheSize {
+		return
+	}
+
+	dropped := len(c.migrationPending) - c.MaxCacheSize
+	c.dualWriteStats.Migration.Dropped += int64(dropped)
+	c.migration.releaseMessageContexts(c.migrationPending[:dropped])
+	c.migrationPending = c.migrationPending[dropped:]
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Dynamic templates presets included into the log index mapping
+var dynamicTemplatePresets = map[string][]map[string]interface{}{
+	// Fields named *_at or *_time are dates, malformed values are kept in _source
+	"dates": {
+		{"dates_at": map[string]interface{}{
+			"match":              "*_at",
+			"match_mapping_type": "string",
+			"mapping":            map[string]interface{}{"type": "date", "ignore_malformed": true},
+		}},
+		{"dates_time": map[string]interface{}{
+			"match":              "*_time",
+			"match_mapping_type": "string",
+			"mapping":            map[string]interface{}{"type": "date", "ignore_malformed": true},
+		}},
+	},
+	// Strings are keywords instead of default text with keyword multi-field
+	"strings_as_keywords": {
+		{"strings_as_keywords": map[string]interface{}{
+			"match_mapping_type": "string",
+			"mapping":            map[string]interface{}{"type": "keyword", "ignore_above": 1024},
+		}},
+	},
+}
+
+// AddDynamicTemplate method adds a custom dynamic template to the mapping of created indices.
+// Custom templates are matched before the presets configured in options.dynamic_templates.
+// It must be called before the logger is opened.
+// Parameters:
+//   - name string  a name of the template.
+//   - template map[string]interface{}  the template definition with match conditions and mapping.
+func (c *ElasticSearchLogger) AddDynamicTemplate(name string, template map[string]interface{}) {
+	c.customTemplates = append(c.customTemplates, map[string]interface{}{name: template})
+}
+
+func (c *ElasticSearchLogger) configureDynamicTemplates(config *cconf.ConfigParams) error {
+	// Presets change mapping of custom fields, so they are applied only when listed explicitly
+	value := config.GetAsStringWithDefault("options.dynamic_templates", "none")
+
+	c.dynamicTemplates = make([]map[string]interface{}, 0)
+	for _, preset := range splitList(value) {
+		if preset == "none" {
+			continue
+		}
+		templates, ok := dynamicTemplatePresets[preset]
+		if !ok {
+			return cerr.NewConfigError("", "WRONG_DYNAMIC_TEMPLATE",
+				"Unknown dynamic templates preset "+preset+" in options.dynamic_templates").
+				WithDetails("preset", preset)
+		}
+		c.dynamicTemplates = append(c.dynamicTemplates, templates...)
+	}
+	return nil
+}
+
+func (c *ElasticSearchLogger) composeDynamicTemplates() []interface{} {
+	templates := make([]interface{}, 0, len(c.customTemplates)+len(c.dynamicTemplates))
+	for _, template := range c.customTemplates {
+		templates = append(templates, template)
+	}
+	for _, template := range c.dynamicTemplates {
+		templates = append(templates, template)
+	}
+	return templates
+}
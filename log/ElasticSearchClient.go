@@ -0,0 +1,61 @@
+package log
+
+import (
+	"io"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// IElasticSearchClient is an interface of ElasticSearch operations used by ElasticSearchLogger
+// to write log messages. It allows to emulate ElasticSearch behavior like timeouts
+// and partial bulk failures in tests or to replace the client implementation.
+// Request options are created the same way as for esv8.Client, for instance esapi.Bulk(nil).WithIndex("log").
+type IElasticSearchClient interface {
+	// Info returns basic information about the cluster, it is used to check the connection.
+	Info(o ...func(*esapi.InfoRequest)) (*esapi.Response, error)
+	// Bulk sends log documents in a bulk request.
+	Bulk(body io.Reader, o ...func(*esapi.BulkRequest)) (*esapi.Response, error)
+	// IndicesExists checks if the indices exist, it shall respond with 404 status if they don't.
+	IndicesExists(index []string, o ...func(*esapi.IndicesExistsRequest)) (*esapi.Response, error)
+	// IndicesCreate creates the index with settings and mappings in the request body.
+	IndicesCreate(index string, o ...func(*esapi.IndicesCreateRequest)) (*esapi.Response, error)
+}
+
+// Zero values of esapi functions used to compose request options
+var (
+	bulkOptions          esapi.Bulk
+	indicesCreateOptions esapi.IndicesCreate
+)
+
+// elasticSearchClient implements IElasticSearchClient with esv8.Client.
+type elasticSearchClient struct {
+	client *esv8.Client
+}
+
+// NewElasticSearchClient method wraps esv8.Client into IElasticSearchClient,
+// for instance to decorate requests sent by the logger.
+// Parameters:
+//   - client *esv8.Client  a client to be wrapped
+// Retruns IElasticSearchClient
+func NewElasticSearchClient(client *esv8.Client) IElasticSearchClient {
+	return &elasticSearchClient{client: client}
+}
+
+func (c *elasticSearchClient) Info(o ...func(*esapi.InfoRequest)) (*esapi.Response, error) {
+	return c.client.Info(o...)
+}
+
+func (c *elasticSearchClient) Bulk(body io.Reader, o ...func(*esapi.BulkRequest)) (*esapi.Response, error) {
+	return c.client.Bulk(body, o...)
+}
+
+func (c *elasticSearchClient) IndicesExists(index []string,
+	o ...func(*esapi.IndicesExistsRequest)) (*esapi.Response, error) {
+	return c.client.Indices.Exists(index, o...)
+}
+
+func (c *elasticSearchClient) IndicesCreate(index string,
+	o ...func(*esapi.IndicesCreateRequest)) (*esapi.Response, error) {
+	return c.client.Indices.Create(index, o...)
+}
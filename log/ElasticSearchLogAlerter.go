@@ -0,0 +1,355 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+// LogAlert describes a source that logged more errors than allowed within the window.
+type LogAlert struct {
+	// Source (component) that logged the errors
+	Source string
+	// Number of error and fatal messages within the window
+	Count int
+	// Threshold exceeded by the source
+	Threshold int
+	// Window in milliseconds the errors were counted in
+	Window int
+	// Time of the check
+	Time time.Time
+}
+
+// ILogAlertNotifier is an interface for components notified about exceeded error thresholds,
+// for instance to send emails or chat messages.
+type ILogAlertNotifier interface {
+	// NotifyAlert is called for every source that exceeded its threshold.
+	NotifyAlert(correlationId string, alert *LogAlert) error
+}
+
+/*
+ElasticSearchLogAlerter is a component that periodically counts error and fatal messages
+written by ElasticSearchLogger per source and raises alerts when the counts exceed thresholds.
+It provides simple alerting on top of the log index without ElasticSearch Watcher.
+
+Alerts are passed to referenced ILogAlertNotifier components and written as errors
+into referenced loggers. A source is not alerted again until the cooldown expires.
+
+Configuration parameters:
+
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  host name or IP address
+    - port:                  port number
+    - uri:                   resource URI or connection string with all parameters in it
+- options:
+    - index:           log index name or pattern, daily indices are matched automatically (default: "log")
+    - alerts_index:    (optional) alerts index of the logger excluded from counts, so mirrored errors are not counted twice
+    - naming:          index naming used by the logger: "default" or "logstash" (default: "default")
+    - schema:          document schema used by the logger: "default" or "ecs" (default: "default")
+    - interval:        interval in milliseconds between checks, it must be positive (default: 60 sec)
+    - window:          period in milliseconds the errors are counted in (default: 5 min)
+    - threshold:       number of errors per source that raises an alert (default: 10)
+    - cooldown:        minimal time in milliseconds between alerts for the same source (default: window)
+    - timeout:         invocation timeout in milliseconds (default: 30 sec)
+- thresholds:          (optional) thresholds for individual sources, for instance "thresholds.orders-service=50"
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to write alerts and own diagnostics
+- *:alert-notifier:*:*:1.0    (optional)  ILogAlertNotifier components to notify about alerts
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+
+Example:
+
+    alerter := NewElasticSearchLogAlerter()
+    alerter.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.uri", "http://localhost:9200",
+        "options.index", "log",
+        "options.threshold", 20,
+        "thresholds.billing", 5,
+    ))
+
+    err := alerter.Open("123")
+*/
+type ElasticSearchLogAlerter struct {
+	connectionResolver *crpccon.HttpConnectionResolver
+	logger             *clog.CompositeLogger
+	notifiers          []ILogAlertNotifier
+	transport          http.RoundTripper
+
+	lock    sync.Mutex
+	client  *esv8.Client
+	timer   chan bool
+	alerted map[string]time.Time
+
+	index       string
+	alertsIndex string
+	naming      string
+	schema      string
+	interval    int
+	window      int
+	threshold   int
+	thresholds  map[string]int
+	cooldown    int
+	timeout     int
+
+	configError error
+}
+
+// NewElasticSearchLogAlerter method creates a new instance of the alerter.
+// Retruns *ElasticSearchLogAlerter
+// pointer on new ElasticSearchLogAlerter
+func NewElasticSearchLogAlerter() *ElasticSearchLogAlerter {
+	c := ElasticSearchLogAlerter{}
+	c.connectionResolver = crpccon.NewHttpConnectionResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.notifiers = make([]ILogAlertNotifier, 0)
+	c.alerted = map[string]time.Time{}
+	c.index = "log"
+	c.naming = DefaultNaming
+	c.schema = DefaultSchema
+	c.interval = 60000
+	c.window = 300000
+	c.threshold = 10
+	c.thresholds = map[string]int{}
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchLogAlerter) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.alertsIndex = config.GetAsStringWithDefault("options.alerts_index", c.alertsIndex)
+	c.naming = config.GetAsStringWithDefault("options.naming", c.naming)
+	c.schema = config.GetAsStringWithDefault("options.schema", c.schema)
+	c.interval = config.GetAsIntegerWithDefault("options.interval", c.interval)
+	c.configError = nil
+	if c.interval <= 0 {
+		c.configError = cerr.NewConfigError("", "WRONG_INTERVAL",
+			"Configuration option options.interval must be positive").
+			WithDetails("interval", c.interval)
+	}
+	c.window = config.GetAsIntegerWithDefault("options.window", c.window)
+	c.threshold = config.GetAsIntegerWithDefault("options.threshold", c.threshold)
+	c.cooldown = config.GetAsIntegerWithDefault("options.cooldown", c.window)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+
+	thresholds := config.GetSection("thresholds")
+	for _, source := range thresholds.Keys() {
+		c.thresholds[source] = thresholds.GetAsIntegerWithDefault(source, c.threshold)
+	}
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *ElasticSearchLogAlerter) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+
+	c.notifiers = make([]ILogAlertNotifier, 0)
+	notifiers := references.GetOptional(cref.NewDescriptor("*", "alert-notifier", "*", "*", "1.0"))
+	for _, notifier := range notifiers {
+		if n, ok := notifier.(ILogAlertNotifier); ok {
+			c.notifiers = append(c.notifiers, n)
+		}
+	}
+}
+
+// SetTransport method sets a custom HTTP transport used to send requests to ElasticSearch.
+// It must be called before the alerter is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to be used
+func (c *ElasticSearchLogAlerter) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *ElasticSearchLogAlerter) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.client != nil
+}
+
+// Open method are opens the component and starts periodic checks.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogAlerter) Open(correlationId string) (err error) {
+	if c.IsOpen() {
+		return nil
+	}
+	if c.configError != nil {
+		return c.configError
+	}
+
+	client, err := econnect.NewBasicClient(correlationId, c.connectionResolver, c.transport, c.timeout)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.client = client
+	c.timer = setInterval(func() {
+		c.Check("elasticsearch_log_alerter." + cdata.IdGenerator.NextShort())
+	}, c.interval, false)
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Close method are closes component and stops periodic checks.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogAlerter) Close(correlationId string) (err error) {
+	c.lock.Lock()
+	timer := c.timer
+	c.timer = nil
+	c.client = nil
+	c.lock.Unlock()
+
+	if timer != nil {
+		timer <- true
+		close(timer)
+	}
+	return nil
+}
+
+func (c *ElasticSearchLogAlerter) getThreshold(source string) int {
+	if threshold, ok := c.thresholds[source]; ok {
+		return threshold
+	}
+	return c.threshold
+}
+
+func (c *ElasticSearchLogAlerter) composeQuery() map[string]interface{} {
+	fields := newLogDocumentFields(c.naming, c.schema)
+
+	return map[string]interface{}{
+		"size": 0,
+		"query": equery.NewBoolQuery().Filter(
+			equery.NewRangeQuery(fields.time).Gte("now-"+strconv.Itoa(c.window)+"ms"),
+			equery.NewTermsQuery(fields.level, fields.errorLevels...),
+		).Source(),
+		"aggs": map[string]interface{}{
+			"sources": map[string]interface{}{
+				"terms": map[string]interface{}{"field": fields.source, "size": 1000},
+			},
+		},
+	}
+}
+
+// Check method counts recent errors per source and raises alerts for sources above thresholds.
+// It is called periodically after the alerter is opened.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns raised alerts or error if the check failed.
+func (c *ElasticSearchLogAlerter) Check(correlationId string) (alerts []*LogAlert, err error) {
+	c.lock.Lock()
+	client := c.client
+	c.lock.Unlock()
+	if client == nil {
+		return nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Alerter is not opened")
+	}
+
+	body, err := json.Marshal(c.composeQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	index := strings.Join(logIndices(c.index, c.alertsIndex), ",")
+	resp, err := client.Search(
+		client.Search.WithIndex(index),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithIgnoreUnavailable(true),
+		client.Search.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to count errors in ElasticSearch index %s", index)
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure counting errors").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to count errors in ElasticSearch index %s", index)
+		return nil, appErr
+	}
+
+	var result struct {
+		Aggregations struct {
+			Sources struct {
+				Buckets []struct {
+					Key   string `json:"key"`
+					Count int    `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"sources"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cooldown := time.Duration(c.cooldown) * time.Millisecond
+	alerts = make([]*LogAlert, 0)
+
+	c.lock.Lock()
+	for _, bucket := range result.Aggregations.Sources.Buckets {
+		threshold := c.getThreshold(bucket.Key)
+		if threshold <= 0 || bucket.Count < threshold {
+			continue
+		}
+		if last, ok := c.alerted[bucket.Key]; ok && now.Sub(last) < cooldown {
+			continue
+		}
+		c.alerted[bucket.Key] = now
+		alerts = append(alerts, &LogAlert{
+			Source:    bucket.Key,
+			Count:     bucket.Count,
+			Threshold: threshold,
+			Window:    c.window,
+			Time:      now,
+		})
+	}
+	c.lock.Unlock()
+
+	for _, alert := range alerts {
+		c.notify(correlationId, alert)
+	}
+	return alerts, nil
+}
+
+func (c *ElasticSearchLogAlerter) notify(correlationId string, alert *LogAlert) {
+	c.logger.Error(correlationId, nil, "%s logged %d errors within %d ms exceeding the threshold %d",
+		alert.Source, alert.Count, alert.Window, alert.Threshold)
+
+	for _, notifier := range c.notifiers {
+		if err := notifier.NotifyAlert(correlationId, alert); err != nil {
+			c.logger.Error(correlationId, err, "Failed to notify about errors in %s", alert.Source)
+		}
+	}
+}
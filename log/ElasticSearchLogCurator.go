@@ -0,0 +1,359 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+/*
+ElasticSearchLogCurator is a component that runs maintenance actions on daily log indices
+written by ElasticSearchLogger by a cron-like schedule: rolls over the write alias,
+force-merges, closes and deletes indices older than the configured number of days.
+Age of the indices is taken from their date suffix.
+
+Configuration parameters:
+
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  host name or IP address
+    - port:                  port number
+    - uri:                   resource URI or connection string with all parameters in it
+- options:
+    - index:           log index name, indices <index>-<date> and <index>-<source>-<date> are curated (default: "log")
+    - naming:          index naming used by the logger: "default" or "logstash" (default: "default")
+    - schedule:        cron expression "minute hour day month weekday" in UTC (default: "0 1 * * *")
+    - rollover_alias:  (optional) write alias to roll over on every run
+    - rollover_max_age: (optional) maximum age of the index to roll over, for instance "1d"
+    - rollover_max_size: (optional) maximum size of the index to roll over, for instance "50gb"
+    - forcemerge_after: days after which indices are force-merged (default: 0, never)
+    - max_num_segments: number of segments in force-merged indices (default: 1)
+    - close_after:     days after which indices are closed (default: 0, never)
+    - delete_after:    days after which indices are deleted (default: 0, never)
+    - timeout:         invocation timeout in milliseconds (default: 30 sec)
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to pass log messages
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+
+Example:
+
+    curator := NewElasticSearchLogCurator()
+    curator.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.uri", "http://localhost:9200",
+        "options.index", "log",
+        "options.forcemerge_after", 1,
+        "options.close_after", 7,
+        "options.delete_after", 30,
+    ))
+
+    err := curator.Open("123")
+*/
+type ElasticSearchLogCurator struct {
+	connectionResolver *crpccon.HttpConnectionResolver
+	logger             *clog.CompositeLogger
+	transport          http.RoundTripper
+
+	lock     sync.Mutex
+	runLock  sync.Mutex
+	client   *esv8.Client
+	stop     chan bool
+	schedule *cronSchedule
+
+	index           string
+	naming          string
+	scheduleError   error
+	rolloverAlias   string
+	rolloverMaxAge  string
+	rolloverMaxSize string
+	forceMergeAfter int
+	maxNumSegments  int
+	closeAfter      int
+	deleteAfter     int
+	timeout         int
+}
+
+// NewElasticSearchLogCurator method creates a new instance of the curator.
+// Retruns *ElasticSearchLogCurator
+// pointer on new ElasticSearchLogCurator
+func NewElasticSearchLogCurator() *ElasticSearchLogCurator {
+	c := ElasticSearchLogCurator{}
+	c.connectionResolver = crpccon.NewHttpConnectionResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.index = "log"
+	c.naming = DefaultNaming
+	c.schedule, _ = parseCronSchedule("0 1 * * *")
+	c.maxNumSegments = 1
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchLogCurator) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.naming = config.GetAsStringWithDefault("options.naming", c.naming)
+	c.rolloverAlias = config.GetAsStringWithDefault("options.rollover_alias", c.rolloverAlias)
+	c.rolloverMaxAge = config.GetAsStringWithDefault("options.rollover_max_age", c.rolloverMaxAge)
+	c.rolloverMaxSize = config.GetAsStringWithDefault("options.rollover_max_size", c.rolloverMaxSize)
+	c.forceMergeAfter = config.GetAsIntegerWithDefault("options.forcemerge_after", c.forceMergeAfter)
+	c.maxNumSegments = config.GetAsIntegerWithDefault("options.max_num_segments", c.maxNumSegments)
+	c.closeAfter = config.GetAsIntegerWithDefault("options.close_after", c.closeAfter)
+	c.deleteAfter = config.GetAsIntegerWithDefault("options.delete_after", c.deleteAfter)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+
+	if expression := config.GetAsString("options.schedule"); expression != "" {
+		var schedule *cronSchedule
+		schedule, c.scheduleError = parseCronSchedule(expression)
+		if c.scheduleError == nil {
+			c.schedule = schedule
+		}
+	}
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *ElasticSearchLogCurator) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+}
+
+// SetTransport method sets a custom HTTP transport used to send requests to ElasticSearch.
+// It must be called before the curator is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to be used
+func (c *ElasticSearchLogCurator) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *ElasticSearchLogCurator) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.client != nil
+}
+
+// Open method are opens the component and starts scheduled runs.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogCurator) Open(correlationId string) (err error) {
+	if c.IsOpen() {
+		return nil
+	}
+	if c.scheduleError != nil {
+		return c.scheduleError
+	}
+
+	client, err := econnect.NewBasicClient(correlationId, c.connectionResolver, c.transport, c.timeout)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan bool)
+	c.lock.Lock()
+	c.client = client
+	c.stop = stop
+	c.lock.Unlock()
+
+	go c.runScheduled(stop)
+	return nil
+}
+
+// Close method are closes component and stops scheduled runs.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogCurator) Close(correlationId string) (err error) {
+	c.lock.Lock()
+	stop := c.stop
+	c.stop = nil
+	c.client = nil
+	c.lock.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+	return nil
+}
+
+func (c *ElasticSearchLogCurator) runScheduled(stop chan bool) {
+	for {
+		timer := time.NewTimer(time.Until(c.schedule.Next(time.Now())))
+		select {
+		case <-timer.C:
+			correlationId := "elasticsearch_log_curator." + cdata.IdGenerator.NextShort()
+			if err := c.Run(correlationId); err != nil {
+				c.logger.Error(correlationId, err, "Failed to curate ElasticSearch indices %s", c.index)
+			}
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Run method executes all configured actions once.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogCurator) Run(correlationId string) error {
+	c.lock.Lock()
+	client := c.client
+	c.lock.Unlock()
+	if client == nil {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Curator is not opened")
+	}
+
+	c.runLock.Lock()
+	defer c.runLock.Unlock()
+
+	if c.rolloverAlias != "" {
+		if err := c.rollover(correlationId, client); err != nil {
+			return err
+		}
+	}
+
+	ages, err := c.getIndexAges(correlationId, client, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Indices are sorted to run actions in a stable order
+	indices := make([]string, 0, len(ages))
+	for index := range ages {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+
+	for _, index := range indices {
+		age := ages[index]
+		var resp *esapi.Response
+		var action string
+		switch {
+		case c.deleteAfter > 0 && age >= c.deleteAfter:
+			action = "delete"
+			resp, err = client.Indices.Delete([]string{index})
+		case c.closeAfter > 0 && age >= c.closeAfter:
+			action = "close"
+			resp, err = client.Indices.Close([]string{index})
+		case c.forceMergeAfter > 0 && age >= c.forceMergeAfter:
+			action = "forcemerge"
+			resp, err = client.Indices.Forcemerge(
+				client.Indices.Forcemerge.WithIndex(index),
+				client.Indices.Forcemerge.WithMaxNumSegments(c.maxNumSegments),
+			)
+		default:
+			continue
+		}
+
+		if err = c.checkResponse(correlationId, resp, err); err != nil {
+			return err
+		}
+		c.logger.Debug(correlationId, "Executed %s of ElasticSearch index %s", action, index)
+	}
+	return nil
+}
+
+func (c *ElasticSearchLogCurator) rollover(correlationId string, client *esv8.Client) error {
+	conditions := map[string]interface{}{}
+	if c.rolloverMaxAge != "" {
+		conditions["max_age"] = c.rolloverMaxAge
+	}
+	if c.rolloverMaxSize != "" {
+		conditions["max_size"] = c.rolloverMaxSize
+	}
+
+	options := []func(*esapi.IndicesRolloverRequest){}
+	if len(conditions) > 0 {
+		body, err := json.Marshal(map[string]interface{}{"conditions": conditions})
+		if err != nil {
+			return err
+		}
+		options = append(options, client.Indices.Rollover.WithBody(bytes.NewReader(body)))
+	}
+
+	resp, err := client.Indices.Rollover(c.rolloverAlias, options...)
+	return c.checkResponse(correlationId, resp, err)
+}
+
+// getIndexAges returns age in days of the daily indices parsed from their date suffix.
+func (c *ElasticSearchLogCurator) getIndexAges(correlationId string, client *esv8.Client,
+	now time.Time) (map[string]int, error) {
+	resp, err := client.Cat.Indices(
+		client.Cat.Indices.WithIndex(c.index+"-*"),
+		client.Cat.Indices.WithFormat("json"),
+		client.Cat.Indices.WithH("index"),
+	)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_CURATE",
+			"Failure listing ElasticSearch indices").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, appErr
+	}
+
+	var items []struct {
+		Index string `json:"index"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, err
+	}
+
+	layout := "20060102"
+	if c.naming == LogstashNaming {
+		layout = "2006.01.02"
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	ages := map[string]int{}
+	for _, item := range items {
+		name := strings.TrimSuffix(item.Index, shrunkIndexSuffix)
+		if len(name) < len(layout) {
+			continue
+		}
+		date, err := time.Parse(layout, name[len(name)-len(layout):])
+		if err != nil {
+			continue
+		}
+		ages[item.Index] = int(today.Sub(date).Hours() / 24)
+	}
+	return ages, nil
+}
+
+func (c *ElasticSearchLogCurator) checkResponse(correlationId string, resp *esapi.Response, err error) error {
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CURATE",
+			"Failure curating ElasticSearch indices").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
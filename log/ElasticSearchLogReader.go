@@ -0,0 +1,893 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+/*
+ElasticSearchLogReader is a component that reads log messages written by ElasticSearchLogger.
+It wraps the queries used most often by support tools and end-to-end tests, like retrieving
+all messages of a transaction or counting recent errors, and runs other searches against the logger indices.
+
+Configuration parameters:
+
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  host name or IP address
+    - port:                  port number
+    - uri:                   resource URI or connection string with all parameters in it
+- options:
+    - index:           log index name or pattern, daily and per-source indices are matched automatically
+                       (default: "log" or "logstash" with logstash naming)
+    - naming:          index naming used by the logger: "default" or "logstash" (default: "default")
+    - alerts_index:    (optional) alerts index of the logger excluded from searches, so mirrored errors are not read twice
+    - schema:          document schema used by the logger: "default" or "ecs" (default: "default")
+    - max_page_size:   maximum number of messages returned by a single request (default: 1000)
+    - tail_interval:   interval in milliseconds between polls for new messages in TailMessages (default: 1 sec)
+    - tail_lookback:   time in milliseconds TailMessages searches back before the last delivered message to pick up
+                       messages that became searchable late, like bulks of other workers or retries (default: 0)
+    - tail_tiebreaker: unique field to order messages with the same time in TailMessages, set it to a keyword field
+                       copied from the id when sorting by _id is disabled in the cluster (default: "_id")
+    - timeout:         invocation timeout in milliseconds (default: 30 sec)
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to write own diagnostics
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+
+Example:
+
+    reader := NewElasticSearchLogReader()
+    reader.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.uri", "http://localhost:9200",
+        "options.index", "log",
+    ))
+
+    err := reader.Open("123")
+    ...
+
+    messages, err := reader.GetMessagesByCorrelationId("123", "order-456", 100)
+    count, err := reader.CountErrorsSince("123", time.Now().Add(-time.Hour))
+    total, err := reader.CountMessages("123", nil)
+    found, err := reader.GetMessagesByIds("123", "log-20210304", []string{"id1", "id2"})
+    sample, err := reader.GetOneRandom("123", nil)
+
+    query := equery.NewMatchQuery("message", "payment failed")
+    highlighted, err := reader.SearchHighlighted("123", query.Source(), equery.NewHighlight("message"), 20)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    go reader.TailMessages(ctx, nil, time.Now(), func(message *clog.LogMessage) error {
+        fmt.Println(message.Message)
+        return nil
+    })
+*/
+type ElasticSearchLogReader struct {
+	connectionResolver *crpccon.HttpConnectionResolver
+	logger             *clog.CompositeLogger
+	transport          http.RoundTripper
+
+	lock   sync.Mutex
+	client *esv8.Client
+
+	index          string
+	naming         string
+	alertsIndex    string
+	schema         string
+	maxPageSize    int
+	tailInterval   int
+	tailLookback   int
+	tailTiebreaker string
+	timeout        int
+}
+
+// NewElasticSearchLogReader method creates a new instance of the reader.
+// Retruns *ElasticSearchLogReader
+// pointer on new ElasticSearchLogReader
+func NewElasticSearchLogReader() *ElasticSearchLogReader {
+	c := ElasticSearchLogReader{}
+	c.connectionResolver = crpccon.NewHttpConnectionResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.index = "log"
+	c.naming = DefaultNaming
+	c.schema = DefaultSchema
+	c.maxPageSize = 1000
+	c.tailInterval = 1000
+	c.tailTiebreaker = "_id"
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchLogReader) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+
+	c.naming = config.GetAsStringWithDefault("options.naming", c.naming)
+	if c.naming == LogstashNaming {
+		c.index = "logstash"
+	}
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.alertsIndex = config.GetAsStringWithDefault("options.alerts_index", c.alertsIndex)
+	c.schema = config.GetAsStringWithDefault("options.schema", c.schema)
+	c.maxPageSize = config.GetAsIntegerWithDefault("options.max_page_size", c.maxPageSize)
+	c.tailInterval = config.GetAsIntegerWithDefault("options.tail_interval", c.tailInterval)
+	c.tailLookback = config.GetAsIntegerWithDefault("options.tail_lookback", c.tailLookback)
+	c.tailTiebreaker = config.GetAsStringWithDefault("options.tail_tiebreaker", c.tailTiebreaker)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *ElasticSearchLogReader) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+}
+
+// SetTransport method sets a custom HTTP transport used to send requests to ElasticSearch.
+// It must be called before the reader is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to be used
+func (c *ElasticSearchLogReader) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *ElasticSearchLogReader) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.client != nil
+}
+
+// Open method are opens the component.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogReader) Open(correlationId string) (err error) {
+	if c.IsOpen() {
+		return nil
+	}
+
+	client, err := econnect.NewBasicClient(correlationId, c.connectionResolver, c.transport, c.timeout)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.client = client
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Close method are closes component.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogReader) Close(correlationId string) (err error) {
+	c.lock.Lock()
+	c.client = nil
+	c.lock.Unlock()
+
+	return nil
+}
+
+func (c *ElasticSearchLogReader) getClient(correlationId string) (*esv8.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.client == nil {
+		return nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Reader is not opened")
+	}
+	return c.client, nil
+}
+
+// searchIndex returns the indices written by the logger as a multi-target expression.
+func (c *ElasticSearchLogReader) searchIndex() string {
+	return strings.Join(logIndices(c.index, c.alertsIndex), ",")
+}
+
+func (c *ElasticSearchLogReader) composeErrorsQuery(since time.Time) map[string]interface{} {
+	fields := newLogDocumentFields(c.naming, c.schema)
+	return equery.NewBoolQuery().Filter(
+		equery.NewRangeQuery(fields.time).Gte(since.UTC().Format(time.RFC3339Nano)),
+		equery.NewTermsQuery(fields.level, fields.errorLevels...),
+	).Source()
+}
+
+// SearchMessages method retrieves log messages that match the query sorted by time.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - query map[string]interface{}  an ElasticSearch query, all messages are returned when it is nil
+//   - limit int  maximum number of returned messages, max_page_size is used when it is not positive
+// Returns found messages or error if the search failed.
+func (c *ElasticSearchLogReader) SearchMessages(correlationId string, query map[string]interface{},
+	limit int) ([]*clog.LogMessage, error) {
+	hits, err := c.searchDocuments(correlationId, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	messages := make([]*clog.LogMessage, 0, len(hits))
+	for _, hit := range hits {
+		messages = append(messages, fields.parseMessage(hit.Source))
+	}
+	return messages, nil
+}
+
+// HighlightedMessage is a log message found by SearchHighlighted.
+type HighlightedMessage struct {
+	// Found log message
+	Message *clog.LogMessage
+	// Highlighted fragments per field
+	Highlights map[string][]string
+}
+
+// LogSearchHit is a log message found by Search.
+type LogSearchHit struct {
+	// Id of the document
+	Id string
+	// Found log message
+	Message *clog.LogMessage
+	// Values of requested fields, including runtime and script fields
+	Fields map[string][]interface{}
+	// Messages collapsed into the hit per inner hits name
+	InnerHits map[string][]*clog.LogMessage
+}
+
+// LogSearchResult contains hits and aggregations returned by Search.
+type LogSearchResult struct {
+	// Found log messages
+	Hits []*LogSearchHit
+	// Decoded aggregations
+	Aggregations map[string]interface{}
+}
+
+// searchHit is a document found in the log index.
+type searchHit struct {
+	Id        string                   `json:"_id"`
+	Source    map[string]interface{}   `json:"_source"`
+	Highlight map[string][]string      `json:"highlight"`
+	Fields    map[string][]interface{} `json:"fields"`
+	Sort      []interface{}            `json:"sort"`
+	InnerHits map[string]struct {
+		Hits struct {
+			Hits []searchHit `json:"hits"`
+		} `json:"hits"`
+	} `json:"inner_hits"`
+}
+
+func (c *ElasticSearchLogReader) searchDocuments(correlationId string, query map[string]interface{},
+	limit int) ([]searchHit, error) {
+	return c.searchHighlightedDocuments(correlationId, query, nil, limit)
+}
+
+func (c *ElasticSearchLogReader) searchHighlightedDocuments(correlationId string, query map[string]interface{},
+	highlight *equery.Highlight, limit int) ([]searchHit, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > c.maxPageSize {
+		limit = c.maxPageSize
+	}
+	if query == nil {
+		query = equery.NewMatchAllQuery().Source()
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	request := map[string]interface{}{
+		"size":  limit,
+		"query": query,
+		"sort": []interface{}{
+			map[string]interface{}{
+				fields.time: map[string]interface{}{"order": "asc", "unmapped_type": "date"},
+			},
+		},
+	}
+	if highlight != nil {
+		request["highlight"] = highlight.Source()
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, body, &result); err != nil {
+		return nil, err
+	}
+	return result.Hits.Hits, nil
+}
+
+// SearchHighlighted method retrieves log messages that match the query sorted by time
+// together with fragments of fields with matched terms highlighted.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - query map[string]interface{}  an ElasticSearch query, all messages are returned when it is nil
+//   - highlight *equery.Highlight  highlighted fields and fragment parameters, the message field is highlighted when it is nil
+//   - limit int  maximum number of returned messages, max_page_size is used when it is not positive
+// Returns found messages with highlights or error if the search failed.
+func (c *ElasticSearchLogReader) SearchHighlighted(correlationId string, query map[string]interface{},
+	highlight *equery.Highlight, limit int) ([]*HighlightedMessage, error) {
+	if highlight == nil {
+		highlight = equery.NewHighlight("message")
+	}
+
+	hits, err := c.searchHighlightedDocuments(correlationId, query, highlight, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	messages := make([]*HighlightedMessage, 0, len(hits))
+	for _, hit := range hits {
+		highlights := hit.Highlight
+		if highlights == nil {
+			highlights = map[string][]string{}
+		}
+		messages = append(messages, &HighlightedMessage{
+			Message:    fields.parseMessage(hit.Source),
+			Highlights: highlights,
+		})
+	}
+	return messages, nil
+}
+
+// Suggestion is an option returned by a suggester.
+type Suggestion struct {
+	// Suggested text
+	Text string `json:"text"`
+	// Score of the suggestion, higher scores are better
+	Score float64 `json:"score"`
+	// Number of documents with the suggested term (term suggesters only)
+	Frequency int64 `json:"freq"`
+}
+
+// SuggestionEntry contains suggestions for a term of the suggested text.
+type SuggestionEntry struct {
+	// Term of the text or the whole prefix for completion suggesters
+	Text string `json:"text"`
+	// Offset of the term in the text
+	Offset int `json:"offset"`
+	// Length of the term
+	Length int `json:"length"`
+	// Suggested options
+	Options []Suggestion `json:"options"`
+}
+
+// searchResult is a decoded response of ElasticSearch search API.
+type searchResult struct {
+	Hits struct {
+		Hits []searchHit `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]interface{}       `json:"aggregations"`
+	Suggest      map[string][]SuggestionEntry `json:"suggest"`
+}
+
+// Suggest method runs term or completion suggesters against the log indices,
+// for instance to hint sources or correct misspelled search terms.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - suggesters ...equery.ISuggester  suggesters to run
+// Returns suggestions per suggester name or error if the request failed.
+func (c *ElasticSearchLogReader) Suggest(correlationId string,
+	suggesters ...equery.ISuggester) (map[string][]SuggestionEntry, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	if len(suggesters) == 0 {
+		return nil, cerr.NewBadRequestError(correlationId, "NO_SUGGESTERS", "Suggesters are not set")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"size":    0,
+		"suggest": equery.SuggestSource(suggesters...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, body, &result); err != nil {
+		return nil, err
+	}
+	if result.Suggest == nil {
+		result.Suggest = map[string][]SuggestionEntry{}
+	}
+	return result.Suggest, nil
+}
+
+// executeSearch sends the search request to the log indices and decodes the response into the result.
+func (c *ElasticSearchLogReader) executeSearch(correlationId string, client *esv8.Client,
+	body []byte, result interface{}) error {
+	index := c.searchIndex()
+	resp, err := client.Search(
+		client.Search.WithIndex(index),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithIgnoreUnavailable(true),
+		client.Search.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to search messages in ElasticSearch index %s", index)
+		return cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure searching log messages").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to search messages in ElasticSearch index %s", index)
+		return appErr
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// rawSearchBody converts a search request given as a JSON string, bytes or a map into bytes.
+func rawSearchBody(correlationId string, body interface{}) ([]byte, error) {
+	switch value := body.(type) {
+	case string:
+		return []byte(value), nil
+	case []byte:
+		return value, nil
+	case nil:
+		return nil, cerr.NewBadRequestError(correlationId, "NO_QUERY", "Search request body is not set")
+	default:
+		return json.Marshal(value)
+	}
+}
+
+// SearchRaw method retrieves log messages with a search request written in ElasticSearch Query DSL,
+// for queries that cannot be expressed by other methods of the reader.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - body interface{}  a complete search request as a JSON string, bytes or a map
+// Returns found messages in the order of hits or error if the search failed.
+func (c *ElasticSearchLogReader) SearchRaw(correlationId string, body interface{}) ([]*clog.LogMessage, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rawSearchBody(correlationId, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, data, &result); err != nil {
+		return nil, err
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	messages := make([]*clog.LogMessage, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		messages = append(messages, fields.parseMessage(hit.Source))
+	}
+	return messages, nil
+}
+
+// Search method runs a search request composed with the query builders. Fields computed
+// by runtime fields of the request can be filtered, aggregated and returned with hits.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - request *equery.SearchRequest  a search request, max_page_size hits are returned when its size is not set
+// Returns found messages and aggregations or error if the search failed.
+func (c *ElasticSearchLogReader) Search(correlationId string, request *equery.SearchRequest) (*LogSearchResult, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.composeSearchRequest(correlationId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, body, &result); err != nil {
+		return nil, err
+	}
+	return c.parseSearchResult(&result), nil
+}
+
+// composeSearchRequest validates the request and limits its size by max_page_size.
+func (c *ElasticSearchLogReader) composeSearchRequest(correlationId string,
+	request *equery.SearchRequest) ([]byte, error) {
+	if request == nil {
+		request = equery.NewSearchRequest()
+	}
+	if err := request.Validate(correlationId); err != nil {
+		return nil, err
+	}
+
+	source := request.Source()
+	if size, ok := request.GetSize(); !ok || size > c.maxPageSize {
+		source["size"] = c.maxPageSize
+	}
+	return json.Marshal(source)
+}
+
+// parseSearchResult converts hits and aggregations of the search response.
+func (c *ElasticSearchLogReader) parseSearchResult(result *searchResult) *LogSearchResult {
+	fields := newLogDocumentFields(c.naming, c.schema)
+	found := &LogSearchResult{
+		Hits:         make([]*LogSearchHit, 0, len(result.Hits.Hits)),
+		Aggregations: result.Aggregations,
+	}
+	if found.Aggregations == nil {
+		found.Aggregations = map[string]interface{}{}
+	}
+	for _, hit := range result.Hits.Hits {
+		hitFields := hit.Fields
+		if hitFields == nil {
+			hitFields = map[string][]interface{}{}
+		}
+		innerHits := map[string][]*clog.LogMessage{}
+		for name, inner := range hit.InnerHits {
+			messages := make([]*clog.LogMessage, 0, len(inner.Hits.Hits))
+			for _, innerHit := range inner.Hits.Hits {
+				messages = append(messages, fields.parseMessage(innerHit.Source))
+			}
+			innerHits[name] = messages
+		}
+		found.Hits = append(found.Hits, &LogSearchHit{
+			Id:        hit.Id,
+			Message:   fields.parseMessage(hit.Source),
+			Fields:    hitFields,
+			InnerHits: innerHits,
+		})
+	}
+	return found
+}
+
+// AggregateRaw method runs a search request written in ElasticSearch Query DSL
+// and returns its aggregations, for instance error counts per source and hour.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - body interface{}  a complete search request with aggs as a JSON string, bytes or a map
+// Returns decoded aggregations or error if the search failed.
+func (c *ElasticSearchLogReader) AggregateRaw(correlationId string, body interface{}) (map[string]interface{}, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rawSearchBody(correlationId, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, data, &result); err != nil {
+		return nil, err
+	}
+	if result.Aggregations == nil {
+		result.Aggregations = map[string]interface{}{}
+	}
+	return result.Aggregations, nil
+}
+
+// GetMessagesByCorrelationId method retrieves log messages written within a transaction.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  the correlation id of the transaction to retrieve messages for
+//   - limit int  maximum number of returned messages, max_page_size is used when it is not positive
+// Returns found messages sorted by time or error if the search failed.
+func (c *ElasticSearchLogReader) GetMessagesByCorrelationId(correlationId string, id string,
+	limit int) ([]*clog.LogMessage, error) {
+	fields := newLogDocumentFields(c.naming, c.schema)
+	query := equery.NewBoolQuery().Filter(equery.NewTermQuery(fields.correlationId, id))
+	return c.SearchMessages(correlationId, query.Source(), limit)
+}
+
+// GetMessagesByTraceId method retrieves log messages written within a distributed trace,
+// to pivot from a trace or a span found in APM or tracer indices to its logs.
+// The trace.id field is populated from W3C traceparent values and ITraceContextProvider tracers.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - traceId string  the W3C trace id of 32 hex characters
+//   - limit int  maximum number of returned messages, max_page_size is used when it is not positive
+// Returns found messages sorted by time or error if the search failed.
+func (c *ElasticSearchLogReader) GetMessagesByTraceId(correlationId string, traceId string,
+	limit int) ([]*clog.LogMessage, error) {
+	fields := newLogDocumentFields(c.naming, c.schema)
+	query := equery.NewBoolQuery().Filter(equery.NewTermQuery(fields.traceId, strings.ToLower(traceId)))
+	return c.SearchMessages(correlationId, query.Source(), limit)
+}
+
+// CountErrorsSince method counts error and fatal messages written since the specified time.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - since time.Time  the time to count errors from
+// Returns number of errors or error if the count failed.
+func (c *ElasticSearchLogReader) CountErrorsSince(correlationId string, since time.Time) (int64, error) {
+	return c.CountMessages(correlationId, c.composeErrorsQuery(since))
+}
+
+// CountMessages method counts log messages that match the query using the Count API,
+// which is cheaper than a search with track_total_hits when only the total is needed.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - query map[string]interface{}  an ElasticSearch query, all messages are counted when it is nil
+// Returns number of messages or error if the count failed.
+func (c *ElasticSearchLogReader) CountMessages(correlationId string, query map[string]interface{}) (int64, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return 0, err
+	}
+
+	if query == nil {
+		query = equery.NewMatchAllQuery().Source()
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query": query,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	index := c.searchIndex()
+	resp, err := client.Count(
+		client.Count.WithIndex(index),
+		client.Count.WithBody(bytes.NewReader(body)),
+		client.Count.WithIgnoreUnavailable(true),
+		client.Count.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to count messages in ElasticSearch index %s", index)
+		return 0, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure counting log messages").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to count messages in ElasticSearch index %s", index)
+		return 0, appErr
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// GetMessagesByIds method retrieves log messages by ids of their documents using the Multi-Get API.
+// Unlike a search, it reads documents that are not refreshed yet. Ids are requested in chunks
+// of max_page_size, one round trip per chunk.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - index string  the concrete index of the documents, like a daily index the logger wrote them to
+//   - ids []string  ids of the documents to retrieve
+// Returns found messages in the order of ids, missing ids are skipped, or error if the request failed.
+func (c *ElasticSearchLogReader) GetMessagesByIds(correlationId string, index string,
+	ids []string) ([]*clog.LogMessage, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	messages := make([]*clog.LogMessage, 0, len(ids))
+	for start := 0; start < len(ids); start += c.maxPageSize {
+		end := start + c.maxPageSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"ids": ids[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Mget(
+			bytes.NewReader(body),
+			client.Mget.WithIndex(index),
+		)
+		if err != nil {
+			c.logger.Error(correlationId, err, "Failed to get messages from ElasticSearch index %s", index)
+			return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+				"Failure getting log messages").WithCause(err)
+		}
+
+		var result struct {
+			Docs []struct {
+				Found  bool                   `json:"found"`
+				Source map[string]interface{} `json:"_source"`
+			} `json:"docs"`
+		}
+		appErr := econnect.NewErrorFromResponse(correlationId, resp)
+		if appErr == nil {
+			err = json.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if appErr != nil {
+			c.logger.Error(correlationId, appErr, "Failed to get messages from ElasticSearch index %s", index)
+			return nil, appErr
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range result.Docs {
+			if doc.Found {
+				messages = append(messages, fields.parseMessage(doc.Source))
+			}
+		}
+	}
+	return messages, nil
+}
+
+// GetOneRandom method retrieves a random log message that matches the query.
+// Hits are scored by random_score function, so the message is picked in a single request
+// without counting matching messages first.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - query map[string]interface{}  an ElasticSearch query, a random message of all is returned when it is nil
+// Returns a random message, nil if no messages match or error if the search failed.
+func (c *ElasticSearchLogReader) GetOneRandom(correlationId string, query map[string]interface{}) (*clog.LogMessage, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if query == nil {
+		query = equery.NewMatchAllQuery().Source()
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"size": 1,
+		"query": map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query":        query,
+				"random_score": map[string]interface{}{},
+				"boost_mode":   "replace",
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, body, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Hits.Hits) == 0 {
+		return nil, nil
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	return fields.parseMessage(result.Hits.Hits[0].Source), nil
+}
+
+// TailMessages method polls the log index for new messages and passes them to the callback
+// in the order they were written, similar to "tail -f". It blocks until the context is canceled,
+// the callback returns an error or a search fails.
+// Every poll searches from the time of the last delivered message minus tail_lookback and pages
+// with search_after, messages already passed to the callback are skipped.
+// Parameters:
+//   - ctx context.Context  a context to stop tailing
+//   - filter map[string]interface{}  (optional) an ElasticSearch query clause to filter messages
+//   - fromTime time.Time  the time to start tailing from
+//   - callback func(message *clog.LogMessage) error  a function called for every new message
+// Returns nil when the context is canceled or error otherwise.
+func (c *ElasticSearchLogReader) TailMessages(ctx context.Context, filter map[string]interface{},
+	fromTime time.Time, callback func(message *clog.LogMessage) error) error {
+	correlationId := "elasticsearch_log_reader.tail"
+	fields := newLogDocumentFields(c.naming, c.schema)
+	lookback := time.Duration(c.tailLookback) * time.Millisecond
+	lastTime := fromTime
+	// Times of messages within the lookback window that were already passed to the callback
+	seen := map[string]time.Time{}
+
+	for {
+		query := equery.NewBoolQuery().
+			Filter(equery.NewRangeQuery(fields.time).Gte(lastTime.Add(-lookback).UTC().Format(time.RFC3339Nano)))
+		if filter != nil {
+			query.Filter(equery.Raw(filter))
+		}
+
+		var searchAfter []interface{}
+		for {
+			hits, err := c.searchTailPage(correlationId, query.Source(), searchAfter)
+			if err != nil {
+				return err
+			}
+
+			for _, hit := range hits {
+				searchAfter = hit.Sort
+				if _, ok := seen[hit.Id]; ok {
+					continue
+				}
+				message := fields.parseMessage(hit.Source)
+				seen[hit.Id] = message.Time
+				if message.Time.After(lastTime) {
+					lastTime = message.Time
+				}
+
+				if err := callback(message); err != nil {
+					return err
+				}
+			}
+
+			// Read the next page right away when the current one was full
+			if len(hits) < c.maxPageSize || len(searchAfter) == 0 {
+				break
+			}
+		}
+
+		// Messages older than the window are not returned by the next polls
+		cutoff := lastTime.Add(-lookback)
+		for id, messageTime := range seen {
+			if messageTime.Before(cutoff) {
+				delete(seen, id)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(c.tailInterval) * time.Millisecond):
+		}
+	}
+}
+
+// searchTailPage reads a page of messages ordered by time and tail_tiebreaker after the sort values of the previous page.
+func (c *ElasticSearchLogReader) searchTailPage(correlationId string, query map[string]interface{},
+	searchAfter []interface{}) ([]searchHit, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	request := map[string]interface{}{
+		"size":  c.maxPageSize,
+		"query": query,
+		"sort": []interface{}{
+			map[string]interface{}{
+				fields.time: map[string]interface{}{"order": "asc", "unmapped_type": "date"},
+			},
+			map[string]interface{}{
+				c.tailTiebreaker: map[string]interface{}{"order": "asc"},
+			},
+		},
+	}
+	if len(searchAfter) > 0 {
+		request["search_after"] = searchAfter
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	if err := c.executeSearch(correlationId, client, body, &result); err != nil {
+		return nil, err
+	}
+	return result.Hits.Hits, nil
+}
@@ -0,0 +1,465 @@
+package log
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// Index naming presets supported by ElasticSearchLogger
+const (
+	// DefaultNaming uses the configured index name with optional yyyyMMdd daily suffix
+	DefaultNaming = "default"
+	// LogstashNaming produces logstash-yyyy.MM.dd indices with @timestamp and @version fields
+	LogstashNaming = "logstash"
+)
+
+// Document schemas supported by ElasticSearchLogger
+const (
+	// DefaultSchema writes log messages as they are serialized by pip-services
+	DefaultSchema = "default"
+	// EcsSchema writes documents with Elastic Common Schema fields used by Filebeat
+	EcsSchema = "ecs"
+)
+
+// Version of Elastic Common Schema written in ecs.version field
+const EcsVersion = "1.12.0"
+
+func (c *ElasticSearchLogger) timeField() string {
+	if c.naming == LogstashNaming || c.schema == EcsSchema {
+		return "@timestamp"
+	}
+	return "time"
+}
+
+func (c *ElasticSearchLogger) documentType() string {
+	if c.schema == EcsSchema {
+		return "_doc"
+	}
+	return "log_message"
+}
+
+// syslogSeverity converts the log level into syslog severity code from 0 (emergency) to 7 (debug),
+// so records can be selected by range queries like "warning and above" (severity <= 4).
+func syslogSeverity(level int) int {
+	switch level {
+	case clog.Fatal:
+		return 2
+	case clog.Error:
+		return 3
+	case clog.Warn:
+		return 4
+	case clog.Info:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// composeEntry converts a bulk entry into the document written into the index.
+func (c *ElasticSearchLogger) composeEntry(entry bulkEntry) map[string]interface{} {
+	doc := c.composeDocument(entry.message)
+	c.addProcessInfo(doc, entry.context)
+	c.addCaller(doc, entry.context)
+	if entry.context != nil && len(entry.context.fields) > 0 {
+		doc["fields"] = entry.context.fields
+	}
+	if c.parseJsonMessages {
+		if payload := parseJsonMessage(entry.message.Message); payload != nil {
+			doc[c.jsonField] = payload
+		}
+	}
+	return doc
+}
+
+// parseJsonMessage decodes the message text when it is a JSON object.
+// Returns nil if the message is a regular text.
+func parseJsonMessage(message string) map[string]interface{} {
+	text := strings.TrimSpace(message)
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil
+	}
+
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	// Keep large integers like ids intact
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil || decoder.More() {
+		return nil
+	}
+	return payload
+}
+
+// composeDocument converts a log message into the document written into the index.
+func (c *ElasticSearchLogger) composeDocument(message *clog.LogMessage) map[string]interface{} {
+	if c.schema == EcsSchema {
+		return c.composeEcsDocument(message)
+	}
+
+	doc := map[string]interface{}{
+		c.timeField():    message.Time,
+		"source":         message.Source,
+		"level":          message.Level,
+		"severity":       syslogSeverity(message.Level),
+		"correlation_id": message.CorrelationId,
+		"error":          message.Error,
+		"message":        message.Message,
+	}
+
+	if c.naming == LogstashNaming {
+		doc["@version"] = "1"
+	}
+
+	c.addTraceContext(doc, message.CorrelationId)
+	return doc
+}
+
+func (c *ElasticSearchLogger) composeEcsDocument(message *clog.LogMessage) map[string]interface{} {
+	doc := map[string]interface{}{
+		"@timestamp": message.Time,
+		"message":    message.Message,
+		"ecs":        map[string]interface{}{"version": EcsVersion},
+		"log": map[string]interface{}{
+			"level":  strings.ToLower(clog.LogLevelConverter.ToString(message.Level)),
+			"logger": message.Source,
+			"syslog": map[string]interface{}{
+				"severity": map[string]interface{}{"code": syslogSeverity(message.Level)},
+			},
+		},
+	}
+
+	if message.Source != "" {
+		doc["service"] = map[string]interface{}{"name": message.Source}
+	}
+	if message.CorrelationId != "" {
+		doc["labels"] = map[string]interface{}{"correlation_id": message.CorrelationId}
+	}
+	if message.Error.Message != "" || message.Error.Type != "" {
+		doc["error"] = map[string]interface{}{
+			"type":        message.Error.Type,
+			"code":        message.Error.Code,
+			"message":     message.Error.Message,
+			"stack_trace": message.Error.StackTrace,
+		}
+	}
+
+	c.addTraceContext(doc, message.CorrelationId)
+	return doc
+}
+
+// addProcessInfo adds host and process fields when enrich_process option is set.
+func (c *ElasticSearchLogger) addProcessInfo(doc map[string]interface{}, context *messageContext) {
+	if !c.enrichProcess {
+		return
+	}
+
+	process := map[string]interface{}{"pid": c.process.pid}
+	if c.schema == EcsSchema {
+		doc["host"] = map[string]interface{}{"hostname": c.process.hostName}
+		if context != nil && context.goroutineId > 0 {
+			process["thread"] = map[string]interface{}{"id": context.goroutineId}
+		}
+		labels, ok := doc["labels"].(map[string]interface{})
+		if !ok {
+			labels = map[string]interface{}{}
+			doc["labels"] = labels
+		}
+		labels["go_version"] = c.process.goVersion
+	} else {
+		doc["host"] = c.process.hostName
+		process["go_version"] = c.process.goVersion
+		if context != nil && context.goroutineId > 0 {
+			process["goroutine_id"] = context.goroutineId
+		}
+	}
+	doc["process"] = process
+}
+
+// addCaller adds location of the code that logged the message when capture_caller option is set.
+func (c *ElasticSearchLogger) addCaller(doc map[string]interface{}, context *messageContext) {
+	if context == nil || context.caller == nil {
+		return
+	}
+
+	caller := context.caller
+	if c.schema == EcsSchema {
+		log := doc["log"].(map[string]interface{})
+		log["origin"] = map[string]interface{}{
+			"file":     map[string]interface{}{"name": caller.File, "line": caller.Line},
+			"function": caller.Function,
+		}
+	} else {
+		doc["caller"] = map[string]interface{}{
+			"file":     caller.File,
+			"line":     caller.Line,
+			"function": caller.Function,
+		}
+	}
+}
+
+func (c *ElasticSearchLogger) composeCallerProperties(properties map[string]interface{}) {
+	if !c.captureCaller {
+		return
+	}
+
+	keyword := map[string]interface{}{"type": "keyword", "ignore_above": 1024}
+	integer := map[string]interface{}{"type": "integer"}
+	if c.schema == EcsSchema {
+		log := properties["log"].(map[string]interface{})["properties"].(map[string]interface{})
+		log["origin"] = map[string]interface{}{
+			"properties": map[string]interface{}{
+				"file": map[string]interface{}{
+					"properties": map[string]interface{}{"name": keyword, "line": integer},
+				},
+				"function": keyword,
+			},
+		}
+	} else {
+		properties["caller"] = map[string]interface{}{
+			"properties": map[string]interface{}{
+				"file":     keyword,
+				"line":     integer,
+				"function": keyword,
+			},
+		}
+	}
+}
+
+func (c *ElasticSearchLogger) composeProcessProperties(properties map[string]interface{}) {
+	if !c.enrichProcess {
+		return
+	}
+
+	keyword := map[string]interface{}{"type": "keyword", "ignore_above": 1024}
+	long := map[string]interface{}{"type": "long"}
+	if c.schema == EcsSchema {
+		properties["host"] = map[string]interface{}{
+			"properties": map[string]interface{}{"hostname": keyword},
+		}
+		properties["process"] = map[string]interface{}{
+			"properties": map[string]interface{}{
+				"pid": long,
+				"thread": map[string]interface{}{
+					"properties": map[string]interface{}{"id": long},
+				},
+			},
+		}
+		labels := properties["labels"].(map[string]interface{})["properties"].(map[string]interface{})
+		labels["go_version"] = keyword
+	} else {
+		properties["host"] = keyword
+		properties["process"] = map[string]interface{}{
+			"properties": map[string]interface{}{
+				"pid":          long,
+				"go_version":   keyword,
+				"goroutine_id": long,
+			},
+		}
+	}
+}
+
+// addTraceContext adds trace.id and span.id fields when trace context is available.
+func (c *ElasticSearchLogger) addTraceContext(doc map[string]interface{}, correlationId string) {
+	traceId, spanId, ok := c.resolveTraceContext(correlationId)
+	if !ok {
+		return
+	}
+
+	doc["trace"] = map[string]interface{}{"id": traceId}
+	if spanId != "" {
+		doc["span"] = map[string]interface{}{"id": spanId}
+	}
+}
+
+func composeSyslogProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"properties": map[string]interface{}{
+			"severity": map[string]interface{}{
+				"properties": map[string]interface{}{"code": map[string]interface{}{"type": "long"}},
+			},
+		},
+	}
+}
+
+func composeTraceProperties(properties map[string]interface{}) {
+	keyword := map[string]interface{}{"type": "keyword", "ignore_above": 1024}
+	properties["trace"] = map[string]interface{}{
+		"properties": map[string]interface{}{"id": keyword},
+	}
+	properties["span"] = map[string]interface{}{
+		"properties": map[string]interface{}{"id": keyword},
+	}
+}
+
+// composeIndexBody creates the settings and mappings used to create a new log index.
+func (c *ElasticSearchLogger) composeIndexBody() map[string]interface{} {
+	var properties map[string]interface{}
+	if c.schema == EcsSchema {
+		properties = c.composeEcsProperties()
+	} else {
+		properties = c.composeProperties()
+	}
+	composeTraceProperties(properties)
+	c.composeProcessProperties(properties)
+	c.composeCallerProperties(properties)
+	properties["fields"] = map[string]interface{}{"type": "object", "dynamic": c.fieldsDynamic}
+	if c.parseJsonMessages {
+		properties[c.jsonField] = map[string]interface{}{"type": "object"}
+	}
+
+	return map[string]interface{}{
+		"settings": c.composeIndexSettings(),
+		"mappings": map[string]interface{}{
+			c.documentType(): map[string]interface{}{
+				"dynamic":           c.dynamicMapping,
+				"dynamic_templates": c.composeDynamicTemplates(),
+				"properties":        properties,
+			},
+		},
+	}
+}
+
+func (c *ElasticSearchLogger) composeIndexSettings() map[string]interface{} {
+	settings := map[string]interface{}{
+		"number_of_shards": strconv.Itoa(c.shards),
+	}
+	if c.indexSort {
+		// Segments are sorted by time, so queries for latest messages terminate early
+		settings["sort.field"] = c.timeField()
+		settings["sort.order"] = "desc"
+	}
+	if c.codec != "" {
+		settings["codec"] = c.codec
+	}
+	c.composeAllocationSettings(settings)
+	return settings
+}
+
+// composeAlertsIndexBody creates a minimal mapping for the alerts index.
+// Only fields used by alerting rules are indexed, the rest of the document is stored as is.
+func (c *ElasticSearchLogger) composeAlertsIndexBody() map[string]interface{} {
+	keyword := map[string]interface{}{"type": "keyword"}
+
+	var properties map[string]interface{}
+	if c.schema == EcsSchema {
+		properties = map[string]interface{}{
+			"@timestamp": map[string]interface{}{"type": "date"},
+			"message":    map[string]interface{}{"type": "text"},
+			"log": map[string]interface{}{
+				"properties": map[string]interface{}{"level": keyword, "syslog": composeSyslogProperties()},
+			},
+			"service": map[string]interface{}{
+				"properties": map[string]interface{}{"name": keyword},
+			},
+			"error": map[string]interface{}{
+				"properties": map[string]interface{}{"type": keyword, "code": keyword},
+			},
+		}
+	} else {
+		properties = map[string]interface{}{
+			c.timeField():    map[string]interface{}{"type": "date"},
+			"source":         keyword,
+			"level":          keyword,
+			"severity":       map[string]interface{}{"type": "byte"},
+			"correlation_id": keyword,
+			"message":        map[string]interface{}{"type": "text"},
+			"error": map[string]interface{}{
+				"properties": map[string]interface{}{"type": keyword, "code": keyword},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards": "1",
+		},
+		"mappings": map[string]interface{}{
+			c.documentType(): map[string]interface{}{
+				"dynamic":    false,
+				"properties": properties,
+			},
+		},
+	}
+}
+
+func (c *ElasticSearchLogger) composeProperties() map[string]interface{} {
+	properties := map[string]interface{}{
+		c.timeField():    map[string]interface{}{"type": "date", "index": true},
+		"source":         map[string]interface{}{"type": "keyword", "index": true},
+		"level":          map[string]interface{}{"type": "keyword", "index": true},
+		"severity":       map[string]interface{}{"type": "byte", "index": true},
+		"correlation_id": c.composeCorrelationIdProperty(),
+		"error": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"type":           map[string]interface{}{"type": "keyword", "index": true},
+				"category":       map[string]interface{}{"type": "keyword", "index": true},
+				"status":         map[string]interface{}{"type": "integer", "index": false},
+				"code":           map[string]interface{}{"type": "keyword", "index": true},
+				"message":        map[string]interface{}{"type": "text", "index": false},
+				"details":        map[string]interface{}{"type": "object"},
+				"correlation_id": map[string]interface{}{"type": "text", "index": false},
+				"cause":          map[string]interface{}{"type": "text", "index": false},
+				"stack_trace":    map[string]interface{}{"type": "text", "index": false},
+			},
+		},
+		"message": map[string]interface{}{"type": "text", "index": c.indexMessage},
+	}
+
+	if c.naming == LogstashNaming {
+		properties["@version"] = map[string]interface{}{"type": "keyword", "index": true}
+	}
+
+	return properties
+}
+
+// composeCorrelationIdProperty maps correlation id as keyword to allow terms aggregations and exact filters.
+// Text multi-field is added for full-text search on parts of composite ids.
+func (c *ElasticSearchLogger) composeCorrelationIdProperty() map[string]interface{} {
+	property := map[string]interface{}{"type": "keyword", "index": true, "ignore_above": 1024}
+	if c.correlationIdText {
+		property["fields"] = map[string]interface{}{
+			"text": map[string]interface{}{"type": "text"},
+		}
+	}
+	return property
+}
+
+// composeEcsProperties creates the subset of Filebeat ECS template fields written by the logger.
+// The message is always indexed as in the Filebeat template, so full-text search in shared
+// dashboards works on documents of both.
+func (c *ElasticSearchLogger) composeEcsProperties() map[string]interface{} {
+	keyword := map[string]interface{}{"type": "keyword", "ignore_above": 1024}
+
+	return map[string]interface{}{
+		"@timestamp": map[string]interface{}{"type": "date"},
+		"message":    map[string]interface{}{"type": "text", "norms": false},
+		"ecs": map[string]interface{}{
+			"properties": map[string]interface{}{"version": keyword},
+		},
+		"log": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"level":  keyword,
+				"logger": keyword,
+				"syslog": composeSyslogProperties(),
+			},
+		},
+		"service": map[string]interface{}{
+			"properties": map[string]interface{}{"name": keyword},
+		},
+		"labels": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"correlation_id": keyword},
+		},
+		"error": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"type":        keyword,
+				"code":        keyword,
+				"message":     map[string]interface{}{"type": "text", "norms": false},
+				"stack_trace": map[string]interface{}{"type": "text", "index": false},
+			},
+		},
+	}
+}
@@ -3,54 +3,238 @@ package log
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
+	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	esv8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/elastic/go-elasticsearch/v8/estransport"
 	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
 	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
 	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
 	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
 )
 
+// FlushErrorCallback is a callback invoked when log messages cannot be saved to ElasticSearch.
+// It receives the correlation id of the flush, the error and the number of messages in the failed batch.
+type FlushErrorCallback func(correlationId string, err error, batchSize int)
+
 /*
 ElasticSearchLogger is logger that dumps execution logs to ElasticSearch service.
 ElasticSearch is a popular search index. It is often used
 to store and index execution logs by itself or as a part of
 ELK (ElasticSearch - Logstash - Kibana) stack.
 
-Authentication is not supported in this version.
+Basic authentication is supported with credentials set in the connection URI.
 
 Configuration parameters:
 
 - level:             maximum log level to capture
 - source:            source (context) name
-- connection(s):
-    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
-    - protocol:              connection protocol: http or https
+- connection(s):           all configured or discovered connections are used as ElasticSearch nodes
+    - discovery_key:         (optional) a key to retrieve the connections from IDiscovery
+    - protocol:              connection protocol: http, https or srv to resolve nodes from DNS SRV record
+                             set in host, for instance "_elasticsearch._tcp.elasticsearch.default.svc.cluster.local"
     - host:                  host name or IP address
     - port:                  port int
-    - uri:                   resource URI or connection string with all parameters in it
+    - uri:                   resource URI or connection string with all parameters in it,
+                             user and password in the URI are used for basic authentication
+- secondary:               (optional) connection(s) to a secondary ElasticSearch cluster the logger fails over to
+    - connection(s):         the same parameters as of the primary connection(s)
+- migration:               (optional) connection(s) to a cluster every batch is additionally written to
+                           while logging is migrated between clusters, see GetDualWriteStats
+    - connection(s):         the same parameters as of the primary connection(s)
+    - options:               options overridden for the migration cluster, for instance an index name
 - options:
-    - interval:        interval in milliseconds to save log messages (default: 10 seconds)
+    - interval:        interval in milliseconds to save log messages, 0 to save them right after
+                       every log call like with immediate option (default: 10 seconds)
+    - interval_jitter: maximum random delay in milliseconds added to every flush, so identically configured
+                       replicas don't write to ElasticSearch at the same instant (default: 0)
+    - immediate:       true to start saving cached messages in background on every log call,
+                       so short-lived jobs don't lose messages at exit (default: false)
     - max_cache_size:  maximum int of messages stored in this cache (default: 100)
     - index:           ElasticSearch index name (default: "log")
     - daily:           true to create a new index every day by adding date suffix to the index
                        name (default: false)
-    - reconnect:       reconnect timeout in milliseconds (default: 60 sec)
+    - naming:          index naming preset: "default" or "logstash" to write logstash-yyyy.MM.dd
+                       indices with @timestamp and @version fields (default: "default")
+    - schema:          document schema: "default" or "ecs" to write Elastic Common Schema fields
+                       compatible with Filebeat index templates (default: "default")
+    - reconnect:       interval in milliseconds to re-resolve the connection, reconnect when the address
+                       changes and ping ElasticSearch, 0 to disable (default: 60 sec)
+    - failover_after:  number of consecutive failed flushes after which the logger switches to the secondary
+                       cluster, 0 to disable (default: 3)
+    - failback_interval: interval in milliseconds to probe the primary cluster after failover and switch
+                       back to it once it responds (default: 60 sec)
+    - reopen_after_failures: number of consecutive failed flushes after which the client is recreated
+                       on the next reconnect check, 0 to disable (default: 3)
+    - open_retries:    number of attempts to ping ElasticSearch during open after the first one failed,
+                       open fails when ElasticSearch is not reachable (default: 2)
+    - open_retry_timeout: timeout in milliseconds between ping attempts during open (default: 1 sec)
+    - srv_protocol:    protocol used to connect to nodes resolved from DNS SRV records (default: "http")
+    - connect_on_demand: true to open immediately and connect to ElasticSearch on the first flush,
+                       messages are kept in the cache while the connection fails (default: false)
     - timeout:         invocation timeout in milliseconds (default: 30 sec)
+    - connect_timeout: timeout in milliseconds to establish a connection (default: 30 sec)
+    - tls_handshake_timeout: timeout in milliseconds for TLS handshake (default: 10 sec)
+    - idle_conn_timeout: time in milliseconds an idle connection is kept in the pool (default: 60 sec)
+    - max_idle_conns_per_host: maximum number of idle connections kept per host (default: 2)
+    - max_conns_per_host: maximum number of connections per host, 0 for unlimited (default: 0)
     - max_retries:     maximum int of retries (default: 3)
-    - index_message:   true to enable indexing for message object (default: false)
+    - retry_on_status: comma-separated list of HTTP statuses to retry, for instance "429,503,504"
+                       (default: "502,503,504")
+    - retry_backoff:   initial delay in milliseconds between retries doubled on every attempt
+                       (default: 0, retry immediately)
+    - disable_retry:   true to disable client retries, failed batches are still retried on the next flush
+                       (default: false)
+    - retry_queue_size: maximum number of messages in failed batches kept in a retry queue and retried with
+                       backoff ahead of new messages, 0 to put failed messages back into the cache (default: 0)
+    - retry_spill:     what to do with the oldest batches when the retry queue is full: "drop" or "disk"
+                       to write them into retry_spill_path, spilled batches are retried after a restart (default: "drop")
+    - retry_spill_path: directory for spilled batches, it shall not be shared with other loggers
+    - retry_queue_backoff: initial delay in milliseconds before queued batches are retried, doubled
+                       after every failed attempt (default: 1 sec)
+    - retry_queue_max_backoff: maximum delay in milliseconds between retries of queued batches (default: 60 sec)
+    - refresh:         (optional) refresh of bulk requests: "true" to make written messages searchable
+                       right away, "wait_for" to wait until they are refreshed or "false" to leave it
+                       to the index refresh interval, which is cheapest for production
+    - index_message:   true to enable indexing for message object, it is always indexed with ecs schema (default: false)
+    - index_per_source: true to write messages into separate indices per source, for instance
+                       "log-orders-service-20240101", to apply retention and access control per service (default: false)
+    - alerts_index:    (optional) name of a small index where error and fatal messages are additionally written
+                       to be monitored by alerting rules, it follows the daily setting of the main index
+    - enrich_process:  true to add host name, process id, go version and goroutine id to every document
+                       to tell apart replicas sharing one source name (default: false)
+    - capture_stack:   true to capture the stack of the calling goroutine for errors logged without
+                       a stack trace (default: false)
+    - stack_depth:     maximum number of frames in captured stack traces (default: 32)
+    - capture_caller:  true to add file, line and function of the code that logged the message (default: false)
+    - caller_skip:     number of frames to skip above the logger, for instance in logging helpers (default: 0)
+    - fields_dynamic:  dynamic mapping of structured fields: "true" to index new fields, "false" to store
+                       them without indexing or "strict" to reject unknown fields (default: "true")
+    - parse_json_messages: true to index messages that are JSON objects as structured documents (default: false)
+    - json_field:      name of the field with parsed JSON messages (default: "payload")
+    - correlation_id_text: true to add correlation_id.text field for full-text search, correlation_id itself
+                       is mapped as keyword (default: false)
+    - dynamic_mapping: handling of unknown fields in created indices: "true" to add them to the mapping,
+                       "false" to store them without indexing or "strict" to reject documents with them,
+                       which protects from mapping explosions (default: "true")
+    - index_sort:      true to sort created indices by time descending to speed up queries
+                       for latest messages (default: false)
+    - codec:           (optional) compression codec of created indices: "default" or "best_compression"
+                       that saves storage for verbose logs at the cost of slower reads
+    - shards:          number of primary shards in created indices (default: 1)
+    - forcemerge:      true to force-merge yesterday's daily indices to reduce segments count (default: false)
+    - max_num_segments: number of segments in force-merged indices (default: 1)
+    - shrink:          true to shrink yesterday's daily indices to a single shard into <index>-shrunk indices,
+                       it requires a copy of every shard on one node set by allocation_require (default: false)
+    - maintenance_interval: interval in milliseconds between checks for indices to maintain (default: 1 hour)
+    - tier_preference: (optional) data tiers preference of created indices, for instance "data_hot"
+    - allocation_require: (optional) node attributes required for created indices, for instance "box_type=hot"
+    - ilm_policy:      (optional) name of ILM policy assigned to created indices
+    - ilm_warm_after:  (optional) age of indices to move them to the warm phase, for instance "7d".
+                       When set the logger creates or updates ilm_policy on open
+    - ilm_warm_require: (optional) node attributes required in the warm phase, for instance "box_type=warm",
+                       without them indices migrate to data_warm tier
+    - pipeline:        (optional) name of ingest pipeline that processes written messages
+    - pipeline_processors: (optional) JSON array of ingest processors or a pipeline object with description
+                       and processors. When set the logger creates or updates the pipeline on open
+    - pipeline_file:   (optional) path to a JSON file with ingest processors used instead of pipeline_processors
+    - slm_policy:      (optional) id of snapshot lifecycle policy created on open to back up the logger indices
+    - slm_repository:  snapshot repository registered in ElasticSearch, required with slm_policy
+    - slm_schedule:    cron schedule of snapshots (default: "0 30 1 * * ?", daily at 1:30 AM)
+    - slm_expire_after: (optional) age of snapshots to delete them, for instance "30d"
+    - slm_min_count:   (optional) minimum number of snapshots kept regardless of their age
+    - slm_max_count:   (optional) maximum number of kept snapshots
+    - error_watch:     (optional) id of ElasticSearch Watcher watch created on open that fires when
+                       error and fatal messages in the logger indices reach watch_threshold
+    - watch_threshold: number of errors within watch_window that fires the watch (default: 10)
+    - watch_window:    period the errors are counted in, for instance "15m" (default: "5m")
+    - watch_interval:  interval between watch executions (default: "1m")
+    - watch_webhook:   (optional) URL the watch posts alerts to
+    - watch_email:     (optional) comma-separated list of email addresses the watch sends alerts to,
+                       without webhook and email the watch writes alerts into ElasticSearch log
+    - dynamic_templates: comma-separated list of dynamic templates presets for custom fields: "dates" maps
+                       *_at and *_time fields to dates, "strings_as_keywords" maps other strings to keywords,
+                       "none" to keep ElasticSearch defaults. Presets are applied only to created indices,
+                       set for instance "dates,strings_as_keywords" to opt in (default: "none")
+    - exclude_sources: (optional) comma-separated list of sources to suppress, "*" wildcards are allowed
+    - exclude_messages: (optional) regular expression for message texts to suppress
+    - exclude_levels:  (optional) comma-separated list of levels to suppress, for instance "debug,trace"
+    - max_rate:        (optional) maximum number of cached messages per second, messages above the rate
+                       are replaced with a single "N messages suppressed" message (default: unlimited)
+    - max_burst:       maximum number of messages allowed in a burst above max_rate (default: max_rate)
+    - block_on_overflow: true to block log calls when the cache is full instead of dropping
+                       oldest messages (default: false)
+    - block_timeout:   maximum time in milliseconds to block a log call, after that the message
+                       is dropped (default: 5 sec)
+    - workers:         number of concurrent bulk requests the cached messages are partitioned
+                       between on every flush (default: 1)
+    - stream_bulk:     true to encode bulk request bodies while they are sent instead of building
+                       them in memory; client retries are disabled since the transport would have to
+                       buffer the body to replay it, failed batches are retried on the next flush (default: false)
+    - prioritize_errors: true to send error and fatal messages in a separate bulk request ahead of
+                       other cached messages on every flush (default: false)
+    - slow_request_threshold: (optional) duration in milliseconds after which bulk and index requests
+                       are logged as slow with their payload size (default: disabled)
+    - ensure_mapping:  true to compare the mapping of already existing indices with the logger mapping,
+                       add missing fields and fail with INCOMPATIBLE_MAPPING error listing fields mapped
+                       with other types instead of failing bulk requests with mapper exceptions (default: false)
+    - op_type:         bulk operation: "index" or "create" to write documents with ids derived from message
+                       content, so retried batches cannot create duplicates and already written documents
+                       are counted as delivered, giving effectively exactly-once delivery; daily indices are
+                       chosen by message time, so batches retried after midnight hit the same index (default: "index")
+    - verify_acks:     true to cross-check item count, ids and statuses of bulk responses against sent
+                       documents and fail the flush with ACK_MISMATCH error on any difference, the batch
+                       is kept in the cache and mismatches are reported to counters (default: false)
+    - user_agent:      User-Agent header sent to ElasticSearch (default: context name/version or client default)
+    - opaque_id:       X-Opaque-ID header to attribute requests in ElasticSearch task and slow logs,
+                       bulk requests add the batch id passed to error callbacks (default: context name/version)
+    - client_log_level: "debug" to log requests of ElasticSearch client to referenced loggers,
+                       "trace" to add request and response bodies (default: "none")
+    - metrics:         true to report client request, failure and response counts and request execution
+                       time to referenced counters (default: false)
 
 References:
 
-- *:context-info:*:*:1.0      (optional)  ContextInfo to detect the context id and specify counters source
+- *:context-info:*:*:1.0      (optional)  ContextInfo to detect the context id and specify counters source,
+                                          its name and "version" property identify requests to ElasticSearch
 - *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+- *:logger:*:*:1.0            (optional)  other ILogger components to report slow ElasticSearch requests
+                                          and client request traces
+- *:counters:*:*:1.0          (optional)  ICounters components to report client metrics, bulk request timing
+                                          and messages dropped by filters, interceptors, rate limit, cache overflow
+                                          or rejected by ElasticSearch, and messages of failed flushes
+- *:tracer:*:*:1.0            (optional)  ITraceContextProvider tracers to resolve trace.id and span.id fields
+
+Configuration is validated when it is set: unknown options, invalid values and incomplete
+connections are reported as ConfigError returned by Open.
+
+Messages rejected by ElasticSearch in bulk requests are reported to listeners
+added with AddBulkFailureListener. Failures to save whole batches are reported
+to the callback set with SetOnError.
+
+Log messages can be enriched, rewritten or dropped by interceptors added with
+AddInterceptor (applied before messages are cached) and AddFlushInterceptor
+(applied before messages are sent; they may run again when a failed batch is retried).
+
+HTTP requests to ElasticSearch can be routed through a custom http.RoundTripper
+set with SetTransport. Timeouts configured in options are not applied to custom transports.
+The client itself can be replaced with a custom IElasticSearchClient set with SetClient,
+for instance to emulate timeouts and partial bulk failures in tests.
+
+W3C traceparent values carried in correlation ids are indexed as trace.id and span.id fields,
+so Kibana can correlate log messages with APM traces.
 
 Example:
 
@@ -70,16 +254,184 @@ type ElasticSearchLogger struct {
 	*clog.CachedLogger
 	connectionResolver *crpccon.HttpConnectionResolver
 
-	timer        chan bool
-	index        string
-	dailyIndex   bool
-	currentIndex string
-	reconnect    int
-	timeout      int
+	// Failover to the secondary cluster
+	secondaryResolver *crpccon.HttpConnectionResolver
+	failoverAfter     int
+	failbackInterval  int
+	failoverLock      sync.Mutex
+	failedOver        bool
+	failbackTimer     chan bool
+
+	// Dual-write to the migration cluster
+	migration        *ElasticSearchLogger
+	parent           *ElasticSearchLogger
+	dualWriteLock    sync.Mutex
+	dualWriteStats   DualWriteStats
+	migrationPending []*clog.LogMessage
+	migrationWriting bool
+	migrationDone    sync.WaitGroup
+
+	timer      chan bool
+	indexLock  sync.Mutex
+	index      string
+	dailyIndex bool
+	naming     string
+	schema     string
+	indices    map[string]bool
+	reconnect  int
+	timeout    int
+
+	openRetries      int
+	openRetryTimeout int
+	connectOnDemand  bool
+	srvProtocol      string
+	lookupSrv        SrvLookup
+
+	reopenAfterFailures int
+	failedFlushes       int32
+	// Set while the reconnect timer recreates the client torn down by a failed check
+	recovering int32
+
+	connectTimeout      int
+	tlsHandshakeTimeout int
+	idleConnTimeout     int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+
 	maxRetries   int
 	indexMessage bool
 
-	client *esv8.Client
+	indexPerSource bool
+	alertsIndex    string
+
+	retryOnStatus []int
+	disableRetry  bool
+	retryBackoff  int
+
+	userAgent      string
+	opaqueId       string
+	clientLogLevel int
+
+	enableMetrics bool
+	counters      *ccount.CompositeCounters
+	metricsLock   sync.Mutex
+	metricsClient *esv8.Client
+	lastMetrics   estransport.Metrics
+
+	traceProviders []ITraceContextProvider
+
+	filter      *logFilter
+	configError error
+	maxRate     int
+	maxBurst    int
+	rateLimiter *logRateLimiter
+
+	blockOnOverflow bool
+	blockTimeout    int
+	cacheReleased   chan struct{}
+	dumpLock        sync.Mutex
+	workers         int
+	streamBulk      bool
+
+	prioritizeErrors bool
+	verifyAcks       bool
+	opType           string
+	ensureMappings   bool
+
+	retryQueueSize       int
+	retrySpill           string
+	retrySpillPath       string
+	retryQueueBackoff    int
+	retryQueueMaxBackoff int
+	retries              *retryQueue
+
+	slowRequestThreshold int
+	logger               *clog.CompositeLogger
+
+	enrichProcess bool
+	process       *processInfo
+	captureStack  bool
+	stackDepth    int
+	captureCaller bool
+	callerSkip    int
+	fieldsDynamic string
+
+	parseJsonMessages bool
+	jsonField         string
+	correlationIdText bool
+	dynamicMapping    string
+	indexSort         bool
+	codec             string
+	shards            int
+	tierPreference    string
+	allocationRequire map[string]string
+	ilmPolicy         string
+	ilmWarmAfter      string
+	ilmWarmRequire    map[string]string
+
+	forceMerge          bool
+	maxNumSegments      int
+	shrink              bool
+	maintenanceInterval int
+	maintenanceTimer    chan bool
+	maintenanceLock     sync.Mutex
+	maintained          map[string]bool
+	dynamicTemplates    []map[string]interface{}
+	customTemplates     []map[string]interface{}
+
+	contextsLock sync.Mutex
+	contexts     map[*clog.LogMessage]*messageContext
+
+	interceptorsLock  sync.Mutex
+	interceptors      []LogInterceptor
+	flushInterceptors []LogInterceptor
+
+	listenersLock        sync.Mutex
+	bulkFailureListeners []IBulkFailureListener
+	onError              FlushErrorCallback
+
+	reconnectTimer   chan bool
+	connectLock      sync.Mutex
+	transport        http.RoundTripper
+	defaultTransport *http.Transport
+	clientLock       sync.RWMutex
+	client           *esv8.Client
+	api              IElasticSearchClient
+	customClient     IElasticSearchClient
+	uris             []string
+
+	clock IClock
+
+	// Immediate flushes started on log calls
+	immediate      bool
+	immediateTimer chan bool
+	flushTrigger   chan bool
+
+	// Keeps the component open for the final save in Close
+	closing bool
+
+	refresh string
+
+	pipeline     string
+	pipelineBody map[string]interface{}
+
+	slmPolicy      string
+	slmRepository  string
+	slmSchedule    string
+	slmExpireAfter string
+	slmMinCount    int
+	slmMaxCount    int
+
+	errorWatch     string
+	watchThreshold int
+	watchWindow    string
+	watchInterval  string
+	watchWebhook   string
+	watchEmail     []string
+
+	intervalJitter int
+	jitterLock     sync.Mutex
+	jitterRand     *rand.Rand
 }
 
 // NewElasticSearchLogger method creates a new instance of the logger.
@@ -91,11 +443,58 @@ func NewElasticSearchLogger() *ElasticSearchLogger {
 	c.connectionResolver = crpccon.NewHttpConnectionResolver()
 	c.index = "log"
 	c.dailyIndex = false
+	c.naming = DefaultNaming
+	c.schema = DefaultSchema
 	c.reconnect = 60000
+	c.failoverAfter = 3
+	c.failbackInterval = 60000
+	c.openRetries = 2
+	c.srvProtocol = "http"
+	c.lookupSrv = net.LookupSRV
+	c.connectOnDemand = false
+	c.reopenAfterFailures = 3
+	c.openRetryTimeout = 1000
 	c.timeout = 30000
+	c.connectTimeout = 30000
+	c.tlsHandshakeTimeout = 10000
+	c.idleConnTimeout = 60000
+	c.maxIdleConnsPerHost = 2
+	c.maxConnsPerHost = 0
 	c.maxRetries = 3
+	c.disableRetry = false
+	c.retryBackoff = 0
+	c.clientLogLevel = clog.None
+	c.enableMetrics = false
+	c.counters = ccount.NewCompositeCounters()
 	c.Interval = 10000
 	c.indexMessage = false
+	c.indexPerSource = false
+	c.alertsIndex = ""
+	c.process = newProcessInfo()
+	c.stackDepth = 32
+	c.fieldsDynamic = "true"
+	c.jsonField = "payload"
+	c.dynamicMapping = "true"
+	c.shards = 1
+	c.maxNumSegments = 1
+	c.maintenanceInterval = 3600000
+	c.configureDynamicTemplates(cconf.NewEmptyConfigParams())
+	c.blockOnOverflow = false
+	c.blockTimeout = 5000
+	c.workers = 1
+	c.streamBulk = false
+	c.prioritizeErrors = false
+	c.opType = IndexOpType
+	c.retrySpill = DropSpill
+	c.retryQueueBackoff = 1000
+	c.retryQueueMaxBackoff = 60000
+	c.slowRequestThreshold = 0
+	c.logger = clog.NewCompositeLogger()
+	c.clock = systemClock{}
+	c.slmSchedule = "0 30 1 * * ?"
+	c.watchThreshold = 10
+	c.watchWindow = "5m"
+	c.watchInterval = "1m"
 	return &c
 }
 
@@ -105,14 +504,149 @@ func NewElasticSearchLogger() *ElasticSearchLogger {
 func (c *ElasticSearchLogger) Configure(config *cconf.ConfigParams) {
 	c.CachedLogger.Configure(config)
 
-	c.connectionResolver.Configure(config)
+	c.connectionResolver.Configure(configureSrvConnections(config))
+	c.configureFailover(config)
+
+	c.naming = config.GetAsStringWithDefault("options.naming", c.naming)
+	c.schema = config.GetAsStringWithDefault("options.schema", c.schema)
+	if c.naming == LogstashNaming {
+		c.index = "logstash"
+		c.dailyIndex = true
+	}
 
 	c.index = config.GetAsStringWithDefault("index", c.index)
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
 	c.dailyIndex = config.GetAsBooleanWithDefault("daily", c.dailyIndex)
+	c.dailyIndex = config.GetAsBooleanWithDefault("options.daily", c.dailyIndex)
+	c.immediate = config.GetAsBooleanWithDefault("options.immediate", c.immediate)
+	c.intervalJitter = config.GetAsIntegerWithDefault("options.interval_jitter", c.intervalJitter)
+	c.refresh = config.GetAsStringWithDefault("options.refresh", c.refresh)
+	c.pipeline = config.GetAsStringWithDefault("options.pipeline", c.pipeline)
+	c.slmPolicy = config.GetAsStringWithDefault("options.slm_policy", c.slmPolicy)
+	c.slmRepository = config.GetAsStringWithDefault("options.slm_repository", c.slmRepository)
+	c.slmSchedule = config.GetAsStringWithDefault("options.slm_schedule", c.slmSchedule)
+	c.slmExpireAfter = config.GetAsStringWithDefault("options.slm_expire_after", c.slmExpireAfter)
+	c.slmMinCount = config.GetAsIntegerWithDefault("options.slm_min_count", c.slmMinCount)
+	c.slmMaxCount = config.GetAsIntegerWithDefault("options.slm_max_count", c.slmMaxCount)
+	c.errorWatch = config.GetAsStringWithDefault("options.error_watch", c.errorWatch)
+	c.watchThreshold = config.GetAsIntegerWithDefault("options.watch_threshold", c.watchThreshold)
+	c.watchWindow = config.GetAsStringWithDefault("options.watch_window", c.watchWindow)
+	c.watchInterval = config.GetAsStringWithDefault("options.watch_interval", c.watchInterval)
+	c.watchWebhook = config.GetAsStringWithDefault("options.watch_webhook", c.watchWebhook)
+	if emails := config.GetAsString("options.watch_email"); emails != "" {
+		c.watchEmail = splitList(emails)
+	}
 	c.reconnect = config.GetAsIntegerWithDefault("options.reconnect", c.reconnect)
+	c.failoverAfter = config.GetAsIntegerWithDefault("options.failover_after", c.failoverAfter)
+	c.failbackInterval = config.GetAsIntegerWithDefault("options.failback_interval", c.failbackInterval)
+	c.openRetries = config.GetAsIntegerWithDefault("options.open_retries", c.openRetries)
+	c.openRetryTimeout = config.GetAsIntegerWithDefault("options.open_retry_timeout", c.openRetryTimeout)
+	c.connectOnDemand = config.GetAsBooleanWithDefault("options.connect_on_demand", c.connectOnDemand)
+	c.srvProtocol = config.GetAsStringWithDefault("options.srv_protocol", c.srvProtocol)
+	c.reopenAfterFailures = config.GetAsIntegerWithDefault("options.reopen_after_failures", c.reopenAfterFailures)
 	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+	c.connectTimeout = config.GetAsIntegerWithDefault("options.connect_timeout", c.connectTimeout)
+	c.tlsHandshakeTimeout = config.GetAsIntegerWithDefault("options.tls_handshake_timeout", c.tlsHandshakeTimeout)
+	c.idleConnTimeout = config.GetAsIntegerWithDefault("options.idle_conn_timeout", c.idleConnTimeout)
+	c.maxIdleConnsPerHost = config.GetAsIntegerWithDefault("options.max_idle_conns_per_host", c.maxIdleConnsPerHost)
+	c.maxConnsPerHost = config.GetAsIntegerWithDefault("options.max_conns_per_host", c.maxConnsPerHost)
 	c.maxRetries = config.GetAsIntegerWithDefault("options.max_retries", c.maxRetries)
 	c.indexMessage = config.GetAsBooleanWithDefault("options.index_message", c.indexMessage)
+	c.indexPerSource = config.GetAsBooleanWithDefault("options.index_per_source", c.indexPerSource)
+	c.alertsIndex = config.GetAsStringWithDefault("options.alerts_index", c.alertsIndex)
+	c.enrichProcess = config.GetAsBooleanWithDefault("options.enrich_process", c.enrichProcess)
+	c.captureStack = config.GetAsBooleanWithDefault("options.capture_stack", c.captureStack)
+	c.stackDepth = config.GetAsIntegerWithDefault("options.stack_depth", c.stackDepth)
+	c.captureCaller = config.GetAsBooleanWithDefault("options.capture_caller", c.captureCaller)
+	c.callerSkip = config.GetAsIntegerWithDefault("options.caller_skip", c.callerSkip)
+	c.fieldsDynamic = config.GetAsStringWithDefault("options.fields_dynamic", c.fieldsDynamic)
+	c.parseJsonMessages = config.GetAsBooleanWithDefault("options.parse_json_messages", c.parseJsonMessages)
+	c.jsonField = config.GetAsStringWithDefault("options.json_field", c.jsonField)
+	c.correlationIdText = config.GetAsBooleanWithDefault("options.correlation_id_text", c.correlationIdText)
+	c.dynamicMapping = config.GetAsStringWithDefault("options.dynamic_mapping", c.dynamicMapping)
+	c.indexSort = config.GetAsBooleanWithDefault("options.index_sort", c.indexSort)
+	c.codec = config.GetAsStringWithDefault("options.codec", c.codec)
+	c.shards = config.GetAsIntegerWithDefault("options.shards", c.shards)
+	c.forceMerge = config.GetAsBooleanWithDefault("options.forcemerge", c.forceMerge)
+	c.maxNumSegments = config.GetAsIntegerWithDefault("options.max_num_segments", c.maxNumSegments)
+	c.shrink = config.GetAsBooleanWithDefault("options.shrink", c.shrink)
+	c.maintenanceInterval = config.GetAsIntegerWithDefault("options.maintenance_interval", c.maintenanceInterval)
+	c.tierPreference = config.GetAsStringWithDefault("options.tier_preference", c.tierPreference)
+	c.ilmPolicy = config.GetAsStringWithDefault("options.ilm_policy", c.ilmPolicy)
+	c.ilmWarmAfter = config.GetAsStringWithDefault("options.ilm_warm_after", c.ilmWarmAfter)
+
+	c.disableRetry = config.GetAsBooleanWithDefault("options.disable_retry", c.disableRetry)
+	c.retryBackoff = config.GetAsIntegerWithDefault("options.retry_backoff", c.retryBackoff)
+	c.userAgent = config.GetAsStringWithDefault("options.user_agent", c.userAgent)
+	c.opaqueId = config.GetAsStringWithDefault("options.opaque_id", c.opaqueId)
+	c.enableMetrics = config.GetAsBooleanWithDefault("options.metrics", c.enableMetrics)
+	if level := config.GetAsString("options.client_log_level"); level != "" {
+		c.clientLogLevel = clog.LogLevelConverter.ToLogLevel(level)
+	}
+
+	c.configError = validateConfig(config)
+	if c.configError == nil {
+		c.filter, c.configError = newLogFilter(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configureRetryOnStatus(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configureDynamicTemplates(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configureAllocation(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configurePipeline(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configureSnapshotLifecycle(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configureRetryQueue(config)
+	}
+	if c.configError == nil {
+		c.configError = c.configureMigration(config)
+	}
+
+	c.maxRate = config.GetAsIntegerWithDefault("options.max_rate", c.maxRate)
+	c.maxBurst = config.GetAsIntegerWithDefault("options.max_burst", c.maxBurst)
+	c.rateLimiter = nil
+	if c.maxRate > 0 {
+		c.rateLimiter = newLogRateLimiter(c.maxRate, c.maxBurst)
+	}
+
+	c.blockOnOverflow = config.GetAsBooleanWithDefault("options.block_on_overflow", c.blockOnOverflow)
+	c.blockTimeout = config.GetAsIntegerWithDefault("options.block_timeout", c.blockTimeout)
+	c.workers = config.GetAsIntegerWithDefault("options.workers", c.workers)
+	c.streamBulk = config.GetAsBooleanWithDefault("options.stream_bulk", c.streamBulk)
+	c.prioritizeErrors = config.GetAsBooleanWithDefault("options.prioritize_errors", c.prioritizeErrors)
+	c.slowRequestThreshold = config.GetAsIntegerWithDefault("options.slow_request_threshold", c.slowRequestThreshold)
+	c.verifyAcks = config.GetAsBooleanWithDefault("options.verify_acks", c.verifyAcks)
+	c.opType = config.GetAsStringWithDefault("options.op_type", c.opType)
+	c.ensureMappings = config.GetAsBooleanWithDefault("options.ensure_mapping", c.ensureMappings)
+}
+
+// configureRetryOnStatus reads the list of HTTP statuses retried by the client.
+func (c *ElasticSearchLogger) configureRetryOnStatus(config *cconf.ConfigParams) error {
+	value := config.GetAsString("options.retry_on_status")
+	if value == "" {
+		return nil
+	}
+
+	statuses := make([]int, 0)
+	for _, item := range splitList(value) {
+		status, err := strconv.Atoi(item)
+		if err != nil {
+			return cerr.NewConfigError("", "WRONG_RETRY_ON_STATUS",
+				"Invalid HTTP status in options.retry_on_status").
+				WithDetails("status", item).WithCause(err)
+		}
+		statuses = append(statuses, status)
+	}
+	c.retryOnStatus = statuses
+	return nil
 }
 
 // SetReferences method are sets references to dependent components.
@@ -121,12 +655,279 @@ func (c *ElasticSearchLogger) Configure(config *cconf.ConfigParams) {
 func (c *ElasticSearchLogger) SetReferences(references cref.IReferences) {
 	c.CachedLogger.SetReferences(references)
 	c.connectionResolver.SetReferences(references)
+	if c.secondaryResolver != nil {
+		c.secondaryResolver.SetReferences(references)
+	}
+	c.logger.SetReferences(c.otherLoggers(references))
+	c.configureIdentity(references)
+	c.counters.SetReferences(references)
+
+	c.traceProviders = make([]ITraceContextProvider, 0)
+	tracers := references.GetOptional(cref.NewDescriptor("*", "tracer", "*", "*", "1.0"))
+	for _, tracer := range tracers {
+		if provider, ok := tracer.(ITraceContextProvider); ok {
+			c.traceProviders = append(c.traceProviders, provider)
+		}
+	}
+
+	if c.migration != nil {
+		c.migration.SetReferences(references)
+	}
+}
+
+// otherLoggers excludes this logger from the references, so own diagnostics
+// do not feed back into the logged messages.
+func (c *ElasticSearchLogger) otherLoggers(references cref.IReferences) cref.IReferences {
+	tuples := make([]interface{}, 0)
+	loggers := references.GetOptional(cref.NewDescriptor("*", "logger", "*", "*", "*"))
+	for i, logger := range loggers {
+		if logger == c || (c.parent != nil && logger == c.parent) {
+			continue
+		}
+		tuples = append(tuples, cref.NewDescriptor("pip-services", "logger", "default", strconv.Itoa(i), "1.0"), logger)
+	}
+	return cref.NewReferencesFromTuples(tuples...)
+}
+
+// createTransport creates the default HTTP transport with configured timeouts and connection pool limits.
+func (c *ElasticSearchLogger) createTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   (time.Duration)(c.connectTimeout) * time.Millisecond,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   (time.Duration)(c.tlsHandshakeTimeout) * time.Millisecond,
+		ResponseHeaderTimeout: (time.Duration)(c.timeout) * time.Millisecond,
+		IdleConnTimeout:       (time.Duration)(c.idleConnTimeout) * time.Millisecond,
+		MaxIdleConnsPerHost:   c.maxIdleConnsPerHost,
+		MaxConnsPerHost:       c.maxConnsPerHost,
+	}
+}
+
+// connect resolves the connections, creates the client, verifies that ElasticSearch
+// is reachable and creates the index. It does nothing when the client is already connected.
+func (c *ElasticSearchLogger) connect(correlationId string) error {
+	c.connectLock.Lock()
+	defer c.connectLock.Unlock()
+
+	if c.getApi() != nil {
+		return nil
+	}
+
+	var uris []string
+	if c.customClient != nil {
+		c.setApi(c.customClient)
+	} else {
+		var err error
+		if uris, err = c.resolveUris(correlationId); err != nil {
+			return err
+		}
+
+		elasticsearch, err := c.createClient(uris)
+		if err != nil {
+			return err
+		}
+		c.setClient(elasticsearch, uris)
+	}
+
+	err := c.ping(correlationId, uris)
+	if err == nil {
+		err = c.createLifecyclePolicy(correlationId)
+	}
+	if err == nil {
+		err = c.createPipeline(correlationId)
+	}
+	if err == nil {
+		err = c.createSnapshotPolicy(correlationId)
+	}
+	if err == nil {
+		err = c.createErrorWatch(correlationId)
+	}
+	if err == nil {
+		_, err = c.createIndexIfNeeded(correlationId, c.Source(), c.clock.Now(), true)
+	}
+	if err == nil {
+		_, err = c.createAlertsIndexIfNeeded(correlationId, c.clock.Now(), true)
+	}
+	if err != nil {
+		c.setClient(nil, nil)
+		return err
+	}
+	atomic.StoreInt32(&c.recovering, 0)
+	return nil
+}
+
+// resolveUris resolves addresses of all configured or discovered ElasticSearch nodes.
+func (c *ElasticSearchLogger) resolveUris(correlationId string) ([]string, error) {
+	return c.resolveUrisWith(correlationId, c.activeResolver())
+}
+
+func (c *ElasticSearchLogger) resolveUrisWith(correlationId string,
+	resolver *crpccon.HttpConnectionResolver) ([]string, error) {
+	connections, _, err := resolver.ResolveAll(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	uris := make([]string, 0, len(connections))
+	for _, connection := range connections {
+		uri := connection.Uri()
+		if connection.Protocol() == SrvProtocol {
+			nodes, err := c.resolveSrvUris(correlationId, uri)
+			if err != nil {
+				return nil, err
+			}
+			uris = append(uris, nodes...)
+		} else if uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+
+	if len(uris) == 0 {
+		return nil, cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection is not configured")
+	}
+	return uris, nil
+}
+
+// createClient creates ElasticSearch client connected to the nodes.
+// Credentials in the uri userinfo are sent using basic authentication.
+func (c *ElasticSearchLogger) createClient(uris []string) (*esv8.Client, error) {
+	addresses := make([]string, len(uris))
+	var username, password string
+	for i, uri := range uris {
+		address, user, pass := econnect.SplitCredentials(uri)
+		addresses[i] = address
+		if user != "" && username == "" {
+			username, password = user, pass
+		}
+	}
+
+	transport := c.transport
+	if transport == nil {
+		if c.defaultTransport == nil {
+			c.defaultTransport = c.createTransport()
+		}
+		transport = c.defaultTransport
+	}
+	transport = c.identify(transport)
+
+	options := esv8.Config{
+		Addresses:     addresses,
+		Username:      username,
+		Password:      password,
+		Transport:     transport,
+		MaxRetries:    c.maxRetries,
+		RetryOnStatus: c.retryOnStatus,
+		// Retries require buffering of the request body which defeats streaming
+		DisableRetry: c.disableRetry || c.streamBulk,
+	}
+	options.EnableMetrics = c.enableMetrics
+	if c.clientLogLevel > clog.None {
+		options.Logger = &clientLogger{logger: c.logger, level: c.clientLogLevel}
+	}
+	if c.retryBackoff > 0 {
+		backoff := time.Duration(c.retryBackoff) * time.Millisecond
+		options.RetryBackoff = func(attempt int) time.Duration {
+			return backoff * time.Duration(1<<uint(attempt-1))
+		}
+	}
+
+	return esv8.NewClient(options)
+}
+
+func (c *ElasticSearchLogger) getClient() *esv8.Client {
+	c.clientLock.RLock()
+	defer c.clientLock.RUnlock()
+
+	return c.client
+}
+
+func (c *ElasticSearchLogger) setClient(client *esv8.Client, uris []string) {
+	c.clientLock.Lock()
+	defer c.clientLock.Unlock()
+
+	c.client = client
+	c.uris = uris
+	c.api = nil
+	if client != nil {
+		c.api = NewElasticSearchClient(client)
+	}
+}
+
+// getApi returns the client used to write log messages or nil when the logger is not connected.
+func (c *ElasticSearchLogger) getApi() IElasticSearchClient {
+	c.clientLock.RLock()
+	defer c.clientLock.RUnlock()
+
+	return c.api
+}
+
+func (c *ElasticSearchLogger) setApi(api IElasticSearchClient) {
+	c.clientLock.Lock()
+	defer c.clientLock.Unlock()
+
+	c.api = api
+}
+
+// SetClient method sets a custom client used to write log messages instead of
+// the one created from the configured connections, for instance to emulate ElasticSearch
+// failures in tests. It shall be called before the logger is opened.
+// Connections and the transport are not used then. ILM policy, index maintenance,
+// reconnect checks and client metrics require esv8.Client and are skipped.
+// Parameters:
+//   - client IElasticSearchClient  a client to write messages or nil to create the default one.
+func (c *ElasticSearchLogger) SetClient(client IElasticSearchClient) {
+	c.customClient = client
+}
+
+// SetTransport method sets a custom HTTP transport used by ElasticSearch client
+// instead of the default one, for instance to add instrumentation or custom authentication.
+// It shall be called before the logger is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to send requests or nil to use the default one.
+func (c *ElasticSearchLogger) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// SetClock method sets a source of time used to timestamp messages, compute daily
+// index names and schedule flushes, their jitter, reconnect checks and failback probes,
+// for instance ManualClock in tests.
+// It shall be called before the logger is opened.
+// Parameters:
+//   - clock IClock  a clock to be used or nil to use the system time.
+func (c *ElasticSearchLogger) SetClock(clock IClock) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	c.clock = clock
+	c.LastDumpTime = clock.Now()
+}
+
+// SetSrvLookup method sets a custom resolver of DNS SRV records used for connections
+// with srv protocol, for instance to emulate DNS in tests.
+// It shall be called before the logger is opened.
+// Parameters:
+//   - lookup SrvLookup  a resolver to be used or nil to use net.LookupSRV.
+func (c *ElasticSearchLogger) SetSrvLookup(lookup SrvLookup) {
+	if lookup == nil {
+		lookup = net.LookupSRV
+	}
+	c.lookupSrv = lookup
 }
 
 // IsOpen method are checks if the component is opened.
 // Returns true if the component has been opened and false otherwise.
 func (c *ElasticSearchLogger) IsOpen() bool {
-	return c.timer != nil
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	return c.timer != nil || c.immediateTimer != nil || c.closing
+}
+
+// isImmediate checks if cached messages are saved right after log calls.
+func (c *ElasticSearchLogger) isImmediate() bool {
+	return c.immediate || c.Interval <= 0
 }
 
 // Open method are ppens the component.
@@ -138,37 +939,48 @@ func (c *ElasticSearchLogger) Open(correlationId string) (err error) {
 		return nil
 	}
 
-	connection, _, err := c.connectionResolver.Resolve(correlationId)
-
-	if connection == nil {
-		err = cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection is not configured")
+	if c.configError != nil {
+		return c.configError
 	}
 
-	if err != nil {
+	c.prepareMigration()
+	if err := c.openRetryQueue(); err != nil {
 		return err
 	}
-
-	uri := connection.Uri()
-
-	options := esv8.Config{
-		Addresses: []string{uri},
-		Transport: &http.Transport{
-			ResponseHeaderTimeout: (time.Duration)(c.timeout) * time.Millisecond,
-			IdleConnTimeout:       (time.Duration)(c.reconnect) * time.Millisecond},
-		MaxRetries: c.maxRetries,
+	if !c.connectOnDemand {
+		if err := c.connect(correlationId); err != nil {
+			return err
+		}
 	}
 
-	elasticsearch, esErr := esv8.NewClient(options)
-	if esErr != nil {
-		return esErr
+	flush := func() {
+		c.cacheSuppressedSummary()
+		c.Dump()
+		c.publishMetrics()
 	}
-	c.client = elasticsearch
+	c.Lock.Lock()
+	if c.Interval > 0 {
+		c.timer = setIntervalWithClock(c.clock, func() {
+			if delay := c.jitterDelay(); delay > 0 {
+				sleepWithClock(c.clock, delay)
+			}
+			flush()
+		}, c.Interval, true)
+	}
+	if c.isImmediate() {
+		c.flushTrigger = make(chan bool, 1)
+		c.immediateTimer = setTrigger(c.flushTrigger, flush)
+	}
+	c.Lock.Unlock()
 
-	err = c.createIndexIfNeeded(correlationId, true)
-	if err == nil {
-		c.timer = setInterval(func() { c.Dump() }, c.Interval, true)
+	if c.reconnect > 0 {
+		c.reconnectTimer = setIntervalWithClock(c.clock, func() {
+			c.checkConnection(correlationId)
+		}, c.reconnect, false)
 	}
 
+	c.startMaintenance()
+
 	return nil
 }
 
@@ -177,97 +989,204 @@ func (c *ElasticSearchLogger) Open(correlationId string) (err error) {
 //   - correlationId  string	(optional) transaction id to trace execution through call chain.
 // Returns error or nil, if no errors occured.
 func (c *ElasticSearchLogger) Close(correlationId string) (err error) {
-	svErr := c.Save(c.Cache)
-	if svErr == nil {
-		return svErr
+	// Detach the flush timers first, so log calls made while closing
+	// stay in the cache for the final save instead of triggering new flushes
+	c.Lock.Lock()
+	timer := c.timer
+	immediateTimer := c.immediateTimer
+	c.timer = nil
+	c.immediateTimer = nil
+	c.flushTrigger = nil
+	c.closing = true
+	c.Lock.Unlock()
+
+	// Stopping a timer does not wait for a flush it started asynchronously,
+	// the final dump below is serialized with running flushes by dumpLock
+	if timer != nil {
+		timer <- true
+		close(timer)
+	}
+	if immediateTimer != nil {
+		immediateTimer <- true
+		close(immediateTimer)
 	}
 
-	if c.timer != nil {
-		c.timer <- true
+	if c.reconnectTimer != nil {
+		c.reconnectTimer <- true
+		close(c.reconnectTimer)
+		c.reconnectTimer = nil
 	}
 
-	c.Cache = make([]*clog.LogMessage, 0, 0)
+	c.stopMaintenance()
+	c.stopFailback()
 
-	close(c.timer)
-	c.timer = nil
-	c.client = nil
-	return nil
+	c.cacheSuppressedSummary()
+
+	c.dumpLock.Lock()
+	var svErr error
+	if c.retries != nil {
+		svErr = c.closeRetryQueue()
+	} else {
+		c.Lock.Lock()
+		messages := c.Cache
+		c.Cache = []*clog.LogMessage{}
+		c.Updated = false
+		c.Lock.Unlock()
+		if svErr = c.Save(messages); svErr != nil {
+			// Messages that were not saved are reported by the returned error
+			c.releaseMessageContexts(messages)
+		}
+	}
+	c.dumpLock.Unlock()
+
+	c.Lock.Lock()
+	c.closing = false
+	c.Lock.Unlock()
+
+	// Release the client even when the last flush failed
+	c.disconnect()
+	if c.migration != nil {
+		// Let the write of the final batch to the migration cluster complete
+		c.migrationDone.Wait()
+		c.migration.disconnect()
+	}
+	return svErr
 }
 
-func (c *ElasticSearchLogger) getCurrentIndex() string {
+func (c *ElasticSearchLogger) getCurrentIndex(source string, date time.Time) string {
+	index := c.index
+	if c.indexPerSource {
+		if name := sanitizeIndexName(source); name != "" {
+			index += "-" + name
+		}
+	}
+
+	return c.withDateSuffix(index, date)
+}
+
+func (c *ElasticSearchLogger) withDateSuffix(index string, date time.Time) string {
 	if !c.dailyIndex {
-		return c.index
+		return index
 	}
-	now := time.Now()
-	return c.index + "-" + now.UTC().Format("20060102")
+	return index + "-" + c.dateSuffix(date)
 }
 
-func (c *ElasticSearchLogger) createIndexIfNeeded(correlationId string, force bool) (err error) {
-	newIndex := c.getCurrentIndex()
-	if !force && c.currentIndex == newIndex {
-		return nil
+// indexDate returns the date of the daily index the message is written to.
+// Documents with ids derived from messages go to the index of the message time,
+// so a batch retried after midnight is rejected as duplicate by the same index.
+func (c *ElasticSearchLogger) indexDate(message *clog.LogMessage) time.Time {
+	if c.opType == CreateOpType && !message.Time.IsZero() {
+		return message.Time
 	}
+	return c.clock.Now()
+}
 
-	c.currentIndex = newIndex
-	exists, err := c.client.Indices.Exists([]string{c.currentIndex})
-	if err != nil || exists.StatusCode == 404 {
-		return err
+func (c *ElasticSearchLogger) dateSuffix(date time.Time) string {
+	if c.naming == LogstashNaming {
+		return date.UTC().Format("2006.01.02")
 	}
+	return date.UTC().Format("20060102")
+}
 
-	indBody := `{
-		"settings": {
-			"number_of_shards": "1"
-		},
-		"mappings": {
-			"log_message": {
-				"properties": {
-					"time": { "type": "date", "index": true },
-					"source": { "type": "keyword", "index": true },
-					"level": { "type": "keyword", "index": true },
-					"correlation_id": { "type": "text", "index": true },
-					"error": {
-						"type": "object",
-						"properties": {
-							"type": { "type": "keyword", "index": true },
-							"category": { "type": "keyword", "index": true },
-							"status": { "type": "integer", "index": false },
-							"code": { "type": "keyword", "index": true },
-							"message": { "type": "text", "index": false },
-							"details": { "type": "object" },
-							"correlation_id": { "type": "text", "index": false },
-							"cause": { "type": "text", "index": false },
-							"stack_trace": { "type": "text", "index": false }
-						}
-					},
-					"message": { "type": "text", "index":` + strconv.FormatBool(c.indexMessage) + ` }
-				}
+func (c *ElasticSearchLogger) createIndexIfNeeded(correlationId string, source string, date time.Time,
+	force bool) (index string, err error) {
+	return c.createIndex(correlationId, c.getCurrentIndex(source, date), c.composeIndexBody, force)
+}
+
+// createAlertsIndexIfNeeded creates the current alerts index when alerts_index option is set.
+// Returns an empty name when alerts are disabled.
+func (c *ElasticSearchLogger) createAlertsIndexIfNeeded(correlationId string, date time.Time,
+	force bool) (index string, err error) {
+	if c.alertsIndex == "" {
+		return "", nil
+	}
+	return c.createIndex(correlationId, c.withDateSuffix(c.alertsIndex, date), c.composeAlertsIndexBody, force)
+}
+
+func (c *ElasticSearchLogger) createIndex(correlationId string, newIndex string,
+	composeBody func() map[string]interface{}, force bool) (index string, err error) {
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	if !force && c.indices[newIndex] {
+		return newIndex, nil
+	}
+
+	api := c.getApi()
+	exists, err := api.IndicesExists([]string{newIndex})
+	if err != nil {
+		return "", err
+	}
+	// Only 404 means the index is missing, other failures must not be taken as existing index
+	var existsErr *cerr.ApplicationError
+	if exists.StatusCode != 200 && exists.StatusCode != 404 {
+		existsErr = econnect.NewErrorFromResponse(correlationId, exists)
+	}
+	exists.Body.Close()
+	if existsErr != nil {
+		return "", existsErr
+	}
+	if exists.StatusCode == 200 {
+		if c.ensureMappings {
+			if err := c.ensureMapping(correlationId, newIndex, composeBody); err != nil {
+				return "", err
 			}
 		}
-	}`
+		c.addIndex(newIndex)
+		return newIndex, nil
+	}
 
-	resp, err := c.client.Indices.Create(c.currentIndex,
-		c.client.Indices.Create.WithBody(strings.NewReader(indBody)),
+	indBody, err := json.Marshal(composeBody())
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	resp, err := api.IndicesCreate(newIndex,
+		indicesCreateOptions.WithBody(bytes.NewReader(indBody)),
 	)
+	c.traceRequest(correlationId, "create index", newIndex, start, len(indBody))
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if resp.IsError() {
-		var e map[string]interface{}
-		if err = json.NewDecoder(resp.Body).Decode(&e); err != nil {
-			return err
-		}
-		// Skip already exist errors
-		if strings.Index(e["error"].(map[string]interface{})["type"].(string), "resource_already_exists") >= 0 {
-			return nil
+	// Skip already exist errors
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil &&
+		appErr.Code != "RESOURCE_ALREADY_EXISTS_EXCEPTION" {
+		return "", appErr
+	}
+
+	c.addIndex(newIndex)
+	return newIndex, nil
+}
+
+// Maximum number of created indices remembered to skip existence checks
+const maxKnownIndices = 1000
+
+// addIndex remembers the created index. Must be called under c.indexLock.
+func (c *ElasticSearchLogger) addIndex(index string) {
+	// Daily indices accumulate over time, start over instead of growing forever
+	if c.indices == nil || len(c.indices) >= maxKnownIndices {
+		c.indices = map[string]bool{}
+	}
+	c.indices[index] = true
+}
+
+// sanitizeIndexName converts a source name into a part of a valid index name.
+func sanitizeIndexName(name string) string {
+	var builder strings.Builder
+	for _, ch := range strings.ToLower(name) {
+		if (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') || ch == '_' || ch == '-' {
+			builder.WriteRune(ch)
+		} else {
+			builder.WriteRune('-')
 		}
-		err = cerr.NewError(e["error"].(map[string]interface{})["type"].(string)).WithCauseString(e["error"].(map[string]interface{})["reason"].(string))
 	}
-	return nil
+	return strings.Trim(builder.String(), "-_")
 }
 
 // Save method are saves log messages from the cache.
@@ -280,61 +1199,277 @@ func (c *ElasticSearchLogger) Save(messages []*clog.LogMessage) (err error) {
 		return nil
 	}
 
-	err = c.createIndexIfNeeded("elasticsearch_logger", false)
-
+	// Batch id is sent as X-Opaque-ID to find the bulk request in ElasticSearch task and slow logs
+	correlationId := "elasticsearch_logger." + cdata.IdGenerator.NextShort()
+	err = c.saveMessages(correlationId, messages)
+	c.dualWrite(correlationId, messages, err)
 	if err != nil {
-		return nil
+		c.countFailed(len(messages))
+		atomic.AddInt32(&c.failedFlushes, 1)
+		c.checkFailover(correlationId)
+	} else {
+		atomic.StoreInt32(&c.failedFlushes, 0)
+		c.releaseMessageContexts(messages)
+	}
+	if onError := c.getOnError(); err != nil && onError != nil {
+		onError(correlationId, err, len(messages))
+	}
+	return err
+}
+
+// traceRequest records execution time of ElasticSearch requests to referenced counters
+// and logs requests that took longer than slow_request_threshold to the referenced loggers.
+func (c *ElasticSearchLogger) traceRequest(correlationId string, operation string, index string,
+	start time.Time, size int) {
+	duration := time.Since(start)
+	if c.enableMetrics {
+		name := metricsPrefix + "." + strings.Replace(operation, " ", "_", -1) + ".exec_time"
+		c.counters.EndTiming(name, float32(duration.Milliseconds()))
 	}
 
-	var buf bytes.Buffer
+	if c.slowRequestThreshold <= 0 || duration < time.Duration(c.slowRequestThreshold)*time.Millisecond {
+		return
+	}
+
+	c.logger.Warn(correlationId, "Slow ElasticSearch %s request to index %s took %d ms with %d bytes payload",
+		operation, index, duration.Milliseconds(), size)
+}
+
+// SetOnError method sets a callback invoked for every failure to save log messages,
+// so embedding applications can raise alarms or switch to degraded mode.
+// It can be called while the logger is open.
+// Parameters:
+//   - callback FlushErrorCallback  a callback to be invoked or nil to remove it.
+func (c *ElasticSearchLogger) SetOnError(callback FlushErrorCallback) {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	c.onError = callback
+}
+
+func (c *ElasticSearchLogger) getOnError() FlushErrorCallback {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	return c.onError
+}
+
+func (c *ElasticSearchLogger) saveMessages(correlationId string, messages []*clog.LogMessage) (err error) {
+	// Flushes fail fast instead of waiting for ping retries while holding the dump lock
+	if atomic.LoadInt32(&c.recovering) != 0 && c.getApi() == nil {
+		return cerr.NewConnectionError(correlationId, "RECONNECTING",
+			"ElasticSearch client is being reconnected")
+	}
+	if err := c.connect(correlationId); err != nil {
+		return err
+	}
+
+	// Action metadata is serialized once per index
+	actions := map[string]string{}
+	indices := make([]string, 0, 1)
+	entries := make([]bulkEntry, 0, len(messages))
+
+	flushInterceptors := c.getInterceptors(true)
 	for _, message := range messages {
-		meta := []byte(fmt.Sprintf(`{ "index": { "_index":"%s", "_type":"log_message", "_id":"%s"}}%s`, c.currentIndex, cdata.IdGenerator.NextLong(), "\n"))
-		data, err := json.Marshal(message)
+		context := c.getMessageContext(message)
+		id := cdata.IdGenerator.NextLong()
+		if c.opType == CreateOpType {
+			id = documentId(message)
+		}
+		date := c.indexDate(message)
+		message = c.intercept(flushInterceptors, message)
+		if message == nil {
+			c.countDropped(dropInterceptor, 1)
+			continue
+		}
 
+		index, err := c.createIndexIfNeeded(correlationId, message.Source, date, false)
+		if err != nil {
+			return err
+		}
+		alertsIndex, err := c.createAlertsIndexIfNeeded(correlationId, date, false)
 		if err != nil {
-			c.Logger.Error("", err, "Cannot encode message "+err.Error())
+			return err
+		}
+		action, ok := actions[index]
+		if !ok {
+			action = bulkActionPrefix(c.opType, index, c.documentType())
+			actions[index] = action
+			indices = append(indices, index)
+		}
+
+		entry := bulkEntry{
+			message: message,
+			id:      id,
+			index:   index,
+			action:  action,
+			context: context,
+		}
+		entries = append(entries, entry)
+
+		// Mirror errors under the same id to find the original message from an alert
+		if alertsIndex != "" && message.Level > clog.None && message.Level <= clog.Error {
+			action, ok := actions[alertsIndex]
+			if !ok {
+				action = bulkActionPrefix(c.opType, alertsIndex, c.documentType())
+				actions[alertsIndex] = action
+				indices = append(indices, alertsIndex)
+			}
+			entry.index = alertsIndex
+			entry.action = action
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Errors are reported to other loggers, logging them to itself would reenter the dump
+	onEncodeError := func(err error) {
+		c.logger.Error(correlationId, err, "Cannot encode message %s", err.Error())
+		c.countDropped(dropEncoding, 1)
+	}
+
+	api := c.getApi()
+	index := strings.Join(indices, ",")
+	options := []func(*esapi.BulkRequest){
+		bulkOptions.WithOpaqueID(c.requestOpaqueId(correlationId)),
+	}
+	if len(indices) == 1 {
+		options = append(options, bulkOptions.WithIndex(index))
+	}
+	if c.refresh != "" {
+		options = append(options, bulkOptions.WithRefresh(c.refresh))
+	}
+	if c.pipeline != "" {
+		options = append(options, bulkOptions.WithPipeline(c.pipeline))
+	}
+
+	var sent []bulkEntry
+	var resp *esapi.Response
+	if c.streamBulk {
+		stream := newBulkStream(entries, c.composeEntry, onEncodeError)
+		if stream == nil {
+			return nil
+		}
+		start := time.Now()
+		resp, err = api.Bulk(stream, options...)
+		stream.Close()
+		c.traceRequest(correlationId, "bulk", index, start, stream.Size())
+		sent = stream.Sent()
+	} else {
+		encoder := getBulkEncoder()
+		defer putBulkEncoder(encoder)
+
+		sent = make([]bulkEntry, 0, len(entries))
+		for _, entry := range entries {
+			if err := encoder.WriteDocument(entry.action, entry.id, c.composeEntry(entry)); err != nil {
+				onEncodeError(err)
+				continue
+			}
+			sent = append(sent, entry)
+		}
+		if len(sent) == 0 {
+			return nil
 		}
-		data = append(data, "\n"...)
-		buf.Grow(len(meta) + len(data))
-		buf.Write(meta)
-		buf.Write(data)
+
+		start := time.Now()
+		resp, err = api.Bulk(bytes.NewReader(encoder.buf.Bytes()), options...)
+		c.traceRequest(correlationId, "bulk", index, start, encoder.buf.Len())
+	}
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_INDEX",
+			"Failure indexing batch").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
 	}
 
-	resp, err := c.client.Bulk(bytes.NewReader(buf.Bytes()), c.client.Bulk.WithIndex(c.currentIndex))
+	bulk, err := econnect.ReadBulkResponse(resp.Body)
+	if err != nil && c.verifyAcks {
+		return cerr.NewInternalError(correlationId, "ACK_MISMATCH",
+			"Cannot read bulk response to verify acknowledgements").WithCause(err)
+	}
 	if err != nil {
-		c.Logger.Error("", err, "Failure indexing batch %s", err.Error())
+		return nil
 	}
-	if resp != nil {
-		defer resp.Body.Close()
+	var ackErr error
+	if c.verifyAcks {
+		ackErr = c.checkAcks(correlationId, sent, bulk)
+	}
+	if !bulk.Errors {
+		return ackErr
 	}
-	buf.Reset()
 
-	if resp.IsError() {
-		var e map[string]interface{}
-		if err = json.NewDecoder(resp.Body).Decode(&e); err != nil {
-			return err
+	failures := make([]*BulkItemFailure, 0)
+	duplicates := 0
+	for i, item := range bulk.Items {
+		if i >= len(sent) || !item.Failed() {
+			continue
 		}
-		err = cerr.NewError(e["error"].(map[string]interface{})["type"].(string)).WithCauseString(e["error"].(map[string]interface{})["reason"].(string))
+		if c.isDelivered(item.Status) {
+			duplicates++
+			continue
+		}
+		message := sent[i].message
+		failures = append(failures, &BulkItemFailure{
+			Message:  message,
+			Document: c.composeEntry(sent[i]),
+			Index:    sent[i].index,
+			Id:       sent[i].id,
+			Status:   item.Status,
+			Error:    econnect.NewErrorFromInfo(message.CorrelationId, item.Status, item.Error),
+		})
 	}
-	return err
+	c.notifyBulkFailures(correlationId, failures)
+	c.countDropped(dropRejected, len(failures))
+	if duplicates > 0 {
+		c.counters.Increment(metricsPrefix+".duplicates", duplicates)
+	}
+
+	return ackErr
 }
 
 func setInterval(someFunc func(), milliseconds int, async bool) chan bool {
+	return setIntervalWithClock(systemClock{}, someFunc, milliseconds, async)
+}
+
+// setTrigger calls the function in background every time a value is sent to the trigger.
+// Values sent while the function runs are coalesced into a single call when the trigger is buffered.
+func setTrigger(trigger chan bool, someFunc func()) chan bool {
+	clear := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-trigger:
+				someFunc()
+			case <-clear:
+				return
+			}
+		}
+	}()
+
+	return clear
+}
+
+func setIntervalWithClock(clock IClock, someFunc func(), milliseconds int, async bool) chan bool {
 
 	interval := time.Duration(milliseconds) * time.Millisecond
-	ticker := time.NewTicker(interval)
+	ticks, stop := clock.NewTicker(interval)
 	clear := make(chan bool)
 	go func() {
 		for {
 			select {
-			case <-ticker.C:
+			case <-ticks:
 				if async {
 					go someFunc()
 				} else {
 					someFunc()
 				}
 			case <-clear:
-				ticker.Stop()
+				stop()
 				return
 			}
 
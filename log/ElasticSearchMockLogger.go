@@ -0,0 +1,277 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// MockDocument is a document captured by ElasticSearchMockLogger.
+type MockDocument struct {
+	// Name of the index the document was written to
+	Index string
+	// Id of the document
+	Id string
+	// Document fields
+	Source map[string]interface{}
+}
+
+/*
+ElasticSearchMockLogger is a test double for ElasticSearchLogger that keeps
+written documents in memory instead of sending them to ElasticSearch.
+It composes documents and creates indices exactly like ElasticSearchLogger,
+so unit tests of microservices can assert logged messages without a running cluster.
+
+Configuration parameters are the same as in ElasticSearchLogger.
+The connection is optional, since no requests leave the process.
+Every bulk item is acknowledged as created, so options like verify_acks work as with a real cluster.
+The logger must be opened like ElasticSearchLogger: messages dumped before Open are
+discarded without error, so Documents and Messages return nothing for them.
+
+Example:
+
+    logger := NewElasticSearchMockLogger()
+    logger.Configure(cconf.NewConfigParamsFromTuples(
+        "source", "orders",
+    ))
+    logger.Open("123")
+
+    logger.Error("123", ex, "Error occured: %s", ex.message)
+
+    messages := logger.Messages()
+*/
+type ElasticSearchMockLogger struct {
+	*ElasticSearchLogger
+	sink *memorySink
+}
+
+// NewElasticSearchMockLogger method creates a new instance of the mock logger.
+// Retruns *ElasticSearchMockLogger
+// pointer on new ElasticSearchMockLogger
+func NewElasticSearchMockLogger() *ElasticSearchMockLogger {
+	c := ElasticSearchMockLogger{}
+	c.ElasticSearchLogger = NewElasticSearchLogger()
+	c.sink = newMemorySink()
+	c.ElasticSearchLogger.SetTransport(c.sink)
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchMockLogger) Configure(config *cconf.ConfigParams) {
+	if len(config.GetSection("connection").Keys()) == 0 && len(config.GetSection("connections").Keys()) == 0 {
+		config = config.Override(cconf.NewConfigParamsFromTuples("connection.uri", "http://localhost:9200"))
+	}
+	c.ElasticSearchLogger.Configure(config)
+}
+
+// SetTransport method is ignored by the mock logger that always keeps documents in memory.
+// Parameters:
+//   - transport http.RoundTripper  ignored transport
+func (c *ElasticSearchMockLogger) SetTransport(transport http.RoundTripper) {
+}
+
+// Documents method saves cached messages and returns all captured documents in the order they were sent.
+// When the logger is not opened, cached messages are discarded without error and nothing is captured.
+// Returns captured documents.
+func (c *ElasticSearchMockLogger) Documents() []*MockDocument {
+	c.Dump()
+	return c.sink.getDocuments()
+}
+
+// Messages method saves cached messages and returns captured messages sorted by time,
+// since error messages are sent ahead of others when prioritize_errors is set.
+// Messages mirrored to the alerts index are not repeated.
+// Returns captured messages.
+func (c *ElasticSearchMockLogger) Messages() []*clog.LogMessage {
+	fields := newLogDocumentFields(c.naming, c.schema)
+	messages := make([]*clog.LogMessage, 0)
+	for _, doc := range c.Documents() {
+		if c.alertsIndex != "" && strings.HasPrefix(doc.Index, c.alertsIndex) {
+			continue
+		}
+		messages = append(messages, fields.parseMessage(doc.Source))
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+	return messages
+}
+
+// GetMessagesByCorrelationId method returns captured messages written within a transaction
+// the same way as ElasticSearchLogReader does.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  the correlation id of the transaction to retrieve messages for
+//   - limit int  maximum number of returned messages, all messages are returned when it is not positive
+// Returns found messages sorted by time.
+func (c *ElasticSearchMockLogger) GetMessagesByCorrelationId(correlationId string, id string,
+	limit int) ([]*clog.LogMessage, error) {
+	messages := make([]*clog.LogMessage, 0)
+	for _, message := range c.Messages() {
+		if message.CorrelationId != id {
+			continue
+		}
+		if limit > 0 && len(messages) >= limit {
+			break
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Indices method returns bodies of created indices by their names.
+// Returns index bodies with settings and mappings.
+func (c *ElasticSearchMockLogger) Indices() map[string]map[string]interface{} {
+	return c.sink.getIndices()
+}
+
+// Reset method removes captured documents and indices.
+func (c *ElasticSearchMockLogger) Reset() {
+	c.Clear()
+	c.sink.reset()
+}
+
+// memorySink is an http.RoundTripper that emulates ElasticSearch requests made by the logger.
+type memorySink struct {
+	lock      sync.Mutex
+	documents []*MockDocument
+	indices   map[string]map[string]interface{}
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{
+		documents: make([]*MockDocument, 0),
+		indices:   map[string]map[string]interface{}{},
+	}
+}
+
+func (c *memorySink) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	status, result := http.StatusOK, "{}"
+
+	switch {
+	case strings.HasSuffix(path, "_bulk"):
+		items := c.addDocuments(body)
+		data, _ := json.Marshal(map[string]interface{}{"took": 1, "errors": false, "items": items})
+		result = string(data)
+	case req.Method == http.MethodHead && path != "" && !strings.HasPrefix(path, "_"):
+		if !c.hasIndex(path) {
+			status = http.StatusNotFound
+		}
+	case req.Method == http.MethodPut && path != "" && !strings.Contains(path, "/"):
+		c.addIndex(path, body)
+		result = `{"acknowledged":true,"index":"` + path + `"}`
+	case strings.HasPrefix(path, "_cat/indices"):
+		result = "[]"
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(result)),
+		Request:    req,
+	}, nil
+}
+
+// addDocuments captures documents of the bulk body and returns an item per action
+// acknowledging the document as created.
+func (c *memorySink) addDocuments(body []byte) []interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	items := make([]interface{}, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	var action map[string]struct {
+		Index string `json:"_index"`
+		Id    string `json:"_id"`
+	}
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if action == nil {
+			json.Unmarshal(line, &action)
+			continue
+		}
+
+		doc := &MockDocument{}
+		for operation, meta := range action {
+			doc.Index, doc.Id = meta.Index, meta.Id
+			items = append(items, map[string]interface{}{
+				operation: map[string]interface{}{
+					"_index": meta.Index,
+					"_id":    meta.Id,
+					"result": "created",
+					"status": http.StatusCreated,
+				},
+			})
+		}
+		json.Unmarshal(line, &doc.Source)
+		c.documents = append(c.documents, doc)
+		action = nil
+	}
+	return items
+}
+
+func (c *memorySink) hasIndex(index string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.indices[index]
+	return ok
+}
+
+func (c *memorySink) addIndex(index string, body []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var value map[string]interface{}
+	json.Unmarshal(body, &value)
+	c.indices[index] = value
+}
+
+func (c *memorySink) getDocuments() []*MockDocument {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := make([]*MockDocument, len(c.documents))
+	copy(result, c.documents)
+	return result
+}
+
+func (c *memorySink) getIndices() map[string]map[string]interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := make(map[string]map[string]interface{}, len(c.indices))
+	for index, body := range c.indices {
+		result[index] = body
+	}
+	return result
+}
+
+func (c *memorySink) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.documents = make([]*MockDocument, 0)
+	c.indices = map[string]map[string]interface{}{}
+}
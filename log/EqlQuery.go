@@ -0,0 +1,110 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// EqlSequence is a sequence of log messages matched by an EQL sequence or sample query.
+type EqlSequence struct {
+	// Values of the join fields shared by the messages, for instance the correlation id
+	JoinKeys []interface{}
+	// Matched messages in the order of the sequence
+	Messages []*clog.LogMessage
+}
+
+// EqlResult contains messages matched by an EQL query.
+type EqlResult struct {
+	// Messages matched by event queries
+	Messages []*clog.LogMessage
+	// Sequences matched by sequence and sample queries
+	Sequences []*EqlSequence
+}
+
+// eqlEvent is a document returned by ElasticSearch EQL API.
+type eqlEvent struct {
+	Source map[string]interface{} `json:"_source"`
+}
+
+// SearchEql method runs an EQL query over log messages, for instance to detect
+// a failed login followed by a password reset within one transaction. Sources of messages
+// serve as event categories, so queries read like "orders where level <= 2".
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - query string  an EQL query, for instance
+//     sequence by correlation_id [auth where message : "*failed*"] [auth where message : "*reset*"]
+//   - size int  maximum number of returned events or sequences, max_page_size is used when it is not positive
+// Returns matched messages and sequences or error if the search failed.
+func (c *ElasticSearchLogReader) SearchEql(correlationId string, query string, size int) (*EqlResult, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return nil, cerr.NewBadRequestError(correlationId, "NO_QUERY", "EQL query is not set")
+	}
+	if size <= 0 || size > c.maxPageSize {
+		size = c.maxPageSize
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	body, err := json.Marshal(map[string]interface{}{
+		"query":                query,
+		"size":                 size,
+		"timestamp_field":      fields.time,
+		"event_category_field": fields.source,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := c.searchIndex()
+	resp, err := client.EqlSearch(index, bytes.NewReader(body))
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to run EQL query in ElasticSearch index %s", index)
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure running EQL query").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to run EQL query in ElasticSearch index %s", index)
+		return nil, appErr
+	}
+
+	var result struct {
+		Hits struct {
+			Events    []eqlEvent `json:"events"`
+			Sequences []struct {
+				JoinKeys []interface{} `json:"join_keys"`
+				Events   []eqlEvent    `json:"events"`
+			} `json:"sequences"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	found := &EqlResult{
+		Messages:  make([]*clog.LogMessage, 0, len(result.Hits.Events)),
+		Sequences: make([]*EqlSequence, 0, len(result.Hits.Sequences)),
+	}
+	for _, event := range result.Hits.Events {
+		found.Messages = append(found.Messages, fields.parseMessage(event.Source))
+	}
+	for _, sequence := range result.Hits.Sequences {
+		matched := &EqlSequence{
+			JoinKeys: sequence.JoinKeys,
+			Messages: make([]*clog.LogMessage, 0, len(sequence.Events)),
+		}
+		for _, event := range sequence.Events {
+			matched.Messages = append(matched.Messages, fields.parseMessage(event.Source))
+		}
+		found.Sequences = append(found.Sequences, matched)
+	}
+	return found, nil
+}
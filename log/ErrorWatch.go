@@ -0,0 +1,111 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+)
+
+// composeErrorWatch creates ElasticSearch Watcher definition that counts error and fatal messages
+// in the logger indices and triggers configured actions when the count reaches watch_threshold.
+// Errors mirrored into the alerts index are not counted.
+func (c *ElasticSearchLogger) composeErrorWatch() map[string]interface{} {
+	fields := newLogDocumentFields(c.naming, c.schema)
+	text := "{{ctx.payload.hits.total}} errors were logged into " + c.index +
+		" within " + c.watchWindow + " exceeding the threshold " + strconv.Itoa(c.watchThreshold)
+
+	actions := map[string]interface{}{}
+	if c.watchWebhook != "" {
+		actions["webhook"] = map[string]interface{}{
+			"webhook": map[string]interface{}{
+				"method": "post",
+				"url":    c.watchWebhook,
+				"headers": map[string]interface{}{
+					"Content-Type": "application/json",
+				},
+				"body": `{"index":"` + c.index + `","count":{{ctx.payload.hits.total}},"threshold":` +
+					strconv.Itoa(c.watchThreshold) + `,"window":"` + c.watchWindow + `"}`,
+			},
+		}
+	}
+	if len(c.watchEmail) > 0 {
+		actions["email"] = map[string]interface{}{
+			"email": map[string]interface{}{
+				"to":      c.watchEmail,
+				"subject": "Errors in " + c.index,
+				"body":    map[string]interface{}{"text": text},
+			},
+		}
+	}
+	// Watches require at least one action, errors are reported into ElasticSearch log otherwise
+	if len(actions) == 0 {
+		actions["log"] = map[string]interface{}{
+			"logging": map[string]interface{}{"level": "warn", "text": text},
+		}
+	}
+
+	return map[string]interface{}{
+		"trigger": map[string]interface{}{
+			"schedule": map[string]interface{}{"interval": c.watchInterval},
+		},
+		"input": map[string]interface{}{
+			"search": map[string]interface{}{
+				"request": map[string]interface{}{
+					"indices":                logIndices(c.index, c.alertsIndex),
+					"rest_total_hits_as_int": true,
+					"body": map[string]interface{}{
+						"size": 0,
+						"query": equery.NewBoolQuery().Filter(
+							equery.NewRangeQuery(fields.time).Gte("now-"+c.watchWindow),
+							equery.NewTermsQuery(fields.level, fields.errorLevels...),
+						).Source(),
+					},
+				},
+			},
+		},
+		"condition": map[string]interface{}{
+			"compare": map[string]interface{}{
+				"ctx.payload.hits.total": map[string]interface{}{"gte": c.watchThreshold},
+			},
+		},
+		"throttle_period": c.watchWindow,
+		"actions":         actions,
+	}
+}
+
+// createErrorWatch creates or updates the error spike watch when error_watch is set.
+func (c *ElasticSearchLogger) createErrorWatch(correlationId string) error {
+	if c.errorWatch == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(c.composeErrorWatch())
+	if err != nil {
+		return err
+	}
+
+	client := c.getClient()
+	if client == nil {
+		c.logger.Warn(correlationId, "Watch %s is not updated by a custom ElasticSearch client", c.errorWatch)
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := client.Watcher.PutWatch(c.errorWatch,
+		client.Watcher.PutWatch.WithBody(bytes.NewReader(body)),
+	)
+	c.traceRequest(correlationId, "put watch", c.errorWatch, start, len(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
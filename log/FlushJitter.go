@@ -0,0 +1,29 @@
+package log
+
+import (
+	"math/rand"
+	"os"
+	"time"
+)
+
+// jitterDelay returns a random delay up to interval_jitter applied before timer flushes,
+// so replicas started at the same moment don't send bulk requests at the same instant.
+// The jitter is limited by the flush interval to keep flushes from overlapping.
+func (c *ElasticSearchLogger) jitterDelay() time.Duration {
+	jitter := c.intervalJitter
+	if jitter > c.Interval {
+		jitter = c.Interval
+	}
+	if jitter <= 0 {
+		return 0
+	}
+
+	c.jitterLock.Lock()
+	defer c.jitterLock.Unlock()
+
+	// The global source is not seeded, replicas would draw identical delays from it
+	if c.jitterRand == nil {
+		c.jitterRand = rand.New(rand.NewSource(time.Now().UnixNano() ^ int64(os.Getpid())<<32))
+	}
+	return time.Duration(c.jitterRand.Intn(jitter)) * time.Millisecond
+}
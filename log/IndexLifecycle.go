@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// parseAllocation parses comma-separated list of node attributes like "box_type=warm,zone=a".
+func parseAllocation(option string, value string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, item := range splitList(value) {
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+			return nil, cerr.NewConfigError("", "WRONG_ALLOCATION",
+				"Invalid node attribute "+item+" in options."+option).
+				WithDetails("option", option).WithDetails("value", item)
+		}
+		result[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+	return result, nil
+}
+
+func (c *ElasticSearchLogger) configureAllocation(config *cconf.ConfigParams) (err error) {
+	c.allocationRequire, err = parseAllocation("allocation_require",
+		config.GetAsString("options.allocation_require"))
+	if err == nil {
+		c.ilmWarmRequire, err = parseAllocation("ilm_warm_require",
+			config.GetAsString("options.ilm_warm_require"))
+	}
+	return err
+}
+
+// composeAllocationSettings adds data tier preference, node attributes and lifecycle policy to index settings.
+func (c *ElasticSearchLogger) composeAllocationSettings(settings map[string]interface{}) {
+	if c.tierPreference != "" {
+		settings["routing.allocation.include._tier_preference"] = c.tierPreference
+	}
+	for attribute, value := range c.allocationRequire {
+		settings["routing.allocation.require."+attribute] = value
+	}
+	if c.ilmPolicy != "" {
+		settings["lifecycle.name"] = c.ilmPolicy
+	}
+}
+
+// composeLifecyclePolicy creates ILM policy that moves indices to the warm phase after ilm_warm_after.
+// Without warm node attributes the indices migrate to data_warm tier automatically.
+func (c *ElasticSearchLogger) composeLifecyclePolicy() map[string]interface{} {
+	warmActions := map[string]interface{}{
+		"set_priority": map[string]interface{}{"priority": 50},
+	}
+	if len(c.ilmWarmRequire) > 0 {
+		warmActions["allocate"] = map[string]interface{}{"require": c.ilmWarmRequire}
+	}
+
+	return map[string]interface{}{
+		"policy": map[string]interface{}{
+			"phases": map[string]interface{}{
+				"hot": map[string]interface{}{
+					"min_age": "0ms",
+					"actions": map[string]interface{}{
+						"set_priority": map[string]interface{}{"priority": 100},
+					},
+				},
+				"warm": map[string]interface{}{
+					"min_age": c.ilmWarmAfter,
+					"actions": warmActions,
+				},
+			},
+		},
+	}
+}
+
+// createLifecyclePolicy creates or updates ILM policy when both ilm_policy and ilm_warm_after are set.
+// Policies managed outside of the logger are only assigned to created indices.
+func (c *ElasticSearchLogger) createLifecyclePolicy(correlationId string) error {
+	if c.ilmPolicy == "" || c.ilmWarmAfter == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(c.composeLifecyclePolicy())
+	if err != nil {
+		return err
+	}
+
+	client := c.getClient()
+	if client == nil {
+		c.logger.Warn(correlationId, "ILM policy %s is not updated by a custom ElasticSearch client", c.ilmPolicy)
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := client.ILM.PutLifecycle(c.ilmPolicy,
+		client.ILM.PutLifecycle.WithBody(bytes.NewReader(body)),
+	)
+	c.traceRequest(correlationId, "put lifecycle", c.ilmPolicy, start, len(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// Suffix of indices produced by shrinking daily indices
+const shrunkIndexSuffix = "-shrunk"
+
+func (c *ElasticSearchLogger) maintenanceEnabled() bool {
+	return c.dailyIndex && (c.forceMerge || (c.shrink && c.shards > 1))
+}
+
+// startMaintenance starts periodic maintenance of daily indices when it is enabled.
+func (c *ElasticSearchLogger) startMaintenance() {
+	if !c.maintenanceEnabled() {
+		return
+	}
+
+	c.maintenanceTimer = setIntervalWithClock(c.clock, func() {
+		correlationId := "elasticsearch_logger." + cdata.IdGenerator.NextShort()
+		if err := c.Maintain(correlationId); err != nil {
+			c.logger.Warn(correlationId, "Failed to maintain ElasticSearch indices: %s", err.Error())
+		}
+	}, c.maintenanceInterval, false)
+}
+
+func (c *ElasticSearchLogger) stopMaintenance() {
+	if c.maintenanceTimer != nil {
+		c.maintenanceTimer <- true
+		close(c.maintenanceTimer)
+		c.maintenanceTimer = nil
+	}
+}
+
+// Maintain method shrinks and force-merges yesterday's daily indices, which are not written anymore.
+// It is called periodically when forcemerge or shrink options are set and can be invoked on demand.
+// Every index is maintained once while the logger is running.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchLogger) Maintain(correlationId string) error {
+	if !c.maintenanceEnabled() {
+		return nil
+	}
+	if err := c.connect(correlationId); err != nil {
+		return err
+	}
+	// Maintenance requests are not supported by custom clients
+	if c.getClient() == nil {
+		return nil
+	}
+
+	c.maintenanceLock.Lock()
+	defer c.maintenanceLock.Unlock()
+
+	indices, err := c.getMaintainedIndices(correlationId, c.clock.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	for _, index := range indices {
+		if c.maintained[index] {
+			continue
+		}
+
+		target := index
+		if c.shrink && c.shards > 1 {
+			if target, err = c.shrinkIndex(correlationId, index); err != nil {
+				return err
+			}
+		}
+		if c.forceMerge {
+			if err = c.forceMergeIndex(correlationId, target); err != nil {
+				return err
+			}
+		}
+
+		if c.maintained == nil || len(c.maintained) >= maxKnownIndices {
+			c.maintained = map[string]bool{}
+		}
+		c.maintained[index] = true
+	}
+	return nil
+}
+
+// getMaintainedIndices lists daily indices written by the logger at the date.
+func (c *ElasticSearchLogger) getMaintainedIndices(correlationId string, date time.Time) ([]string, error) {
+	client := c.getClient()
+	resp, err := client.Cat.Indices(
+		client.Cat.Indices.WithIndex(c.index+"*"),
+		client.Cat.Indices.WithFormat("json"),
+		client.Cat.Indices.WithH("index"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, appErr
+	}
+
+	var items []struct {
+		Index string `json:"index"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, err
+	}
+
+	suffix := "-" + c.dateSuffix(date)
+	indices := make([]string, 0)
+	for _, item := range items {
+		if item.Index == c.index+suffix ||
+			(c.indexPerSource && strings.HasPrefix(item.Index, c.index+"-") && strings.HasSuffix(item.Index, suffix)) {
+			indices = append(indices, item.Index)
+		}
+	}
+	return indices, nil
+}
+
+// shrinkIndex shrinks the index into a single shard index and deletes the original index.
+// Shrinking requires a copy of every shard on one node, for instance set by allocation_require option.
+// When shrinking fails the write block is removed from the original index, so it stays writable.
+// Returns the name of the shrunk index.
+func (c *ElasticSearchLogger) shrinkIndex(correlationId string, index string) (target string, err error) {
+	client := c.getClient()
+
+	// Source index must be read-only to be shrunk
+	if err = c.putWriteBlock(correlationId, index, true); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			if blockErr := c.putWriteBlock(correlationId, index, false); blockErr != nil {
+				c.logger.Warn(correlationId, "Failed to remove write block from %s: %s", index, blockErr.Error())
+			}
+		}
+	}()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"settings": map[string]interface{}{
+			"index.number_of_shards": 1,
+			"index.blocks.write":     nil,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	target = index + shrunkIndexSuffix
+	start := time.Now()
+	resp, err := client.Indices.Shrink(index, target,
+		client.Indices.Shrink.WithBody(bytes.NewReader(body)),
+		client.Indices.Shrink.WithWaitForActiveShards("1"),
+	)
+	c.traceRequest(correlationId, "shrink", index, start, len(body))
+	if err != nil {
+		return "", err
+	}
+	appErr := econnect.NewErrorFromResponse(correlationId, resp)
+	resp.Body.Close()
+	if appErr != nil {
+		return "", appErr
+	}
+
+	resp, err = client.Indices.Delete([]string{index})
+	if err != nil {
+		return "", err
+	}
+	appErr = econnect.NewErrorFromResponse(correlationId, resp)
+	resp.Body.Close()
+	if appErr != nil {
+		return "", appErr
+	}
+
+	return target, nil
+}
+
+// putWriteBlock sets or removes index.blocks.write setting of the index.
+func (c *ElasticSearchLogger) putWriteBlock(correlationId string, index string, block bool) error {
+	client := c.getClient()
+	body := `{"index.blocks.write":null}`
+	if block {
+		body = `{"index.blocks.write":true}`
+	}
+
+	resp, err := client.Indices.PutSettings(strings.NewReader(body),
+		client.Indices.PutSettings.WithIndex(index),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// forceMergeIndex merges segments of the index down to max_num_segments.
+func (c *ElasticSearchLogger) forceMergeIndex(correlationId string, index string) error {
+	client := c.getClient()
+
+	start := time.Now()
+	resp, err := client.Indices.Forcemerge(
+		client.Indices.Forcemerge.WithIndex(index),
+		client.Indices.Forcemerge.WithMaxNumSegments(c.maxNumSegments),
+	)
+	c.traceRequest(correlationId, "forcemerge", index, start, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// parsePipeline parses ingest pipeline definition that is either a JSON array of processors
+// or a complete pipeline object with description and processors.
+func parsePipeline(option string, value string) (map[string]interface{}, error) {
+	var definition interface{}
+	if err := json.Unmarshal([]byte(value), &definition); err == nil {
+		switch pipeline := definition.(type) {
+		case []interface{}:
+			return map[string]interface{}{
+				"description": "Log messages pipeline managed by ElasticSearchLogger",
+				"processors":  pipeline,
+			}, nil
+		case map[string]interface{}:
+			if _, ok := pipeline["processors"].([]interface{}); ok {
+				return pipeline, nil
+			}
+		}
+	}
+
+	return nil, cerr.NewConfigError("", "WRONG_PIPELINE",
+		"Invalid ingest pipeline in options."+option+", expected JSON array of processors or pipeline object").
+		WithDetails("option", option)
+}
+
+func (c *ElasticSearchLogger) configurePipeline(config *cconf.ConfigParams) (err error) {
+	c.pipelineBody = nil
+
+	processors := config.GetAsString("options.pipeline_processors")
+	file := config.GetAsString("options.pipeline_file")
+	if processors != "" {
+		c.pipelineBody, err = parsePipeline("pipeline_processors", processors)
+	} else if file != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(file); err != nil {
+			return cerr.NewConfigError("", "WRONG_PIPELINE", "Cannot read ingest pipeline from "+file).
+				WithDetails("option", "pipeline_file").WithCause(err)
+		}
+		c.pipelineBody, err = parsePipeline("pipeline_file", strings.TrimSpace(string(data)))
+	}
+
+	if err == nil && c.pipelineBody != nil && c.pipeline == "" {
+		err = cerr.NewConfigError("", "NO_PIPELINE_NAME",
+			"options.pipeline must be set to create the ingest pipeline").
+			WithDetails("option", "pipeline")
+	}
+	return err
+}
+
+// createPipeline creates or updates the ingest pipeline when its processors are configured.
+// Pipelines managed outside of the logger are only referenced in bulk requests.
+func (c *ElasticSearchLogger) createPipeline(correlationId string) error {
+	if c.pipeline == "" || c.pipelineBody == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(c.pipelineBody)
+	if err != nil {
+		return err
+	}
+
+	client := c.getClient()
+	if client == nil {
+		c.logger.Warn(correlationId, "Ingest pipeline %s is not updated by a custom ElasticSearch client", c.pipeline)
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := client.Ingest.PutPipeline(c.pipeline, bytes.NewReader(body))
+	c.traceRequest(correlationId, "put pipeline", c.pipeline, start, len(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// Write method writes a log message to the logger cache after applying filters, interceptors and rate limit.
+// Parameters:
+//   - level int  a log level.
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - err error  an error object associated with this message.
+//   - message string  a human-readable message to log.
+func (c *ElasticSearchLogger) Write(level int, correlationId string, err error, message string) {
+	c.write(level, correlationId, err, nil, message)
+}
+
+func (c *ElasticSearchLogger) write(level int, correlationId string, err error,
+	fields map[string]interface{}, message string) {
+	logMessage := &clog.LogMessage{
+		Time:          c.clock.Now().UTC(),
+		Level:         level,
+		Source:        c.Source(),
+		Message:       message,
+		CorrelationId: correlationId,
+	}
+
+	if err != nil {
+		errorDescription := cerr.NewErrorDescription(err)
+		logMessage.Error = *errorDescription
+	}
+
+	if c.filter != nil && c.filter.Excludes(logMessage) {
+		c.countDropped(dropFilter, 1)
+		return
+	}
+
+	// Go errors rarely carry stacks, so point at the code that logged the error instead
+	if err != nil && c.captureStack && logMessage.Error.StackTrace == "" {
+		logMessage.Error.StackTrace = captureStackTrace(c.stackDepth)
+	}
+
+	logMessage = c.intercept(c.getInterceptors(false), logMessage)
+	if logMessage == nil {
+		c.countDropped(dropInterceptor, 1)
+		return
+	}
+
+	if c.rateLimiter != nil {
+		if !c.rateLimiter.Allow(c.clock.Now()) {
+			c.countDropped(dropRateLimit, 1)
+			return
+		}
+		c.cacheSuppressedSummary()
+	}
+
+	if c.blockOnOverflow && !c.waitForCache() {
+		c.countDropped(dropOverflow, 1)
+		return
+	}
+
+	c.setMessageContext(logMessage, c.captureMessageContext(fields))
+
+	c.Lock.Lock()
+	c.Cache = append(c.Cache, logMessage)
+	c.Lock.Unlock()
+
+	c.Update()
+}
+
+// captureMessageContext captures data of the calling goroutine required by enabled options.
+// Returns nil when nothing has to be captured.
+func (c *ElasticSearchLogger) captureMessageContext(fields map[string]interface{}) *messageContext {
+	if !c.enrichProcess && !c.captureCaller && len(fields) == 0 {
+		return nil
+	}
+
+	context := &messageContext{}
+	if len(fields) > 0 {
+		// Callers may reuse the map after the message is logged
+		context.fields = make(map[string]interface{}, len(fields))
+		for key, value := range fields {
+			context.fields[key] = value
+		}
+	}
+	if c.enrichProcess {
+		context.goroutineId = currentGoroutineId()
+	}
+	if c.captureCaller {
+		if frames := callerFrames(c.callerSkip, 1); len(frames) > 0 {
+			context.caller = &frames[0]
+		}
+	}
+	return context
+}
+
+// Update method makes message cache as updated and dumps it when timeout expires.
+func (c *ElasticSearchLogger) Update() {
+	c.Lock.Lock()
+	c.Updated = true
+	lastDumpTime := c.LastDumpTime
+	trigger := c.flushTrigger
+	c.Lock.Unlock()
+
+	// Messages are saved in background, log calls never wait for ElasticSearch
+	if c.isImmediate() {
+		if trigger != nil {
+			select {
+			case trigger <- true:
+			default:
+			}
+		}
+		return
+	}
+
+	elapsed := int(c.clock.Now().Sub(lastDumpTime).Seconds() * 1000)
+
+	if elapsed > c.Interval {
+		c.Dump()
+	}
+}
+
+// Dump method saves the currently cached log messages.
+// Messages that failed to be saved are put back into the cache. Unless blocking
+// backpressure is enabled the cache is truncated to max_cache_size.
+// When retry_queue_size is set, failed messages are moved into the retry queue instead.
+// Dumps called concurrently by timers and writers are executed one after another.
+// Returns error or nil for success.
+func (c *ElasticSearchLogger) Dump() error {
+	c.dumpLock.Lock()
+	defer c.dumpLock.Unlock()
+
+	queued := c.hasQueuedRetries()
+
+	// Messages written while the batch is saved mark the cache as updated again
+	c.Lock.Lock()
+	if !c.Updated && !queued {
+		c.Lock.Unlock()
+		return nil
+	}
+	messages := c.Cache
+	c.Cache = []*clog.LogMessage{}
+	c.Updated = false
+	c.releaseCache()
+	c.Lock.Unlock()
+
+	if c.retries != nil {
+		err := c.dumpWithRetryQueue(messages)
+		c.touchDumpTime()
+		return err
+	}
+
+	failed, err := c.saveWithPriority(messages)
+	if err != nil {
+		c.Lock.Lock()
+
+		// Put failed messages back to cache
+		c.Cache = append(failed, c.Cache...)
+		c.Updated = len(c.Cache) > 0
+
+		// Truncate cache to max size
+		if !c.blockOnOverflow && len(c.Cache) > c.MaxCacheSize {
+			c.countPrimaryDropped(len(c.Cache) - c.MaxCacheSize)
+			c.countDropped(dropOverflow, len(c.Cache)-c.MaxCacheSize)
+			c.releaseMessageContexts(c.Cache[:len(c.Cache)-c.MaxCacheSize])
+			c.Cache = c.Cache[len(c.Cache)-c.MaxCacheSize:]
+		}
+
+		c.Lock.Unlock()
+	}
+
+	c.touchDumpTime()
+	return err
+}
+
+func (c *ElasticSearchLogger) touchDumpTime() {
+	c.Lock.Lock()
+	c.LastDumpTime = c.clock.Now()
+	c.Lock.Unlock()
+}
+
+// saveWithPriority sends error and fatal messages in a dedicated bulk request ahead of other messages,
+// so they reach ElasticSearch first when a large backlog is flushed after an outage.
+// Returns messages that failed to be saved in their original order and the first error.
+func (c *ElasticSearchLogger) saveWithPriority(messages []*clog.LogMessage) ([]*clog.LogMessage, error) {
+	if !c.prioritizeErrors {
+		return c.saveWithWorkers(messages)
+	}
+
+	priority := make([]*clog.LogMessage, 0)
+	regular := make([]*clog.LogMessage, 0, len(messages))
+	for _, message := range messages {
+		if message.Level > clog.None && message.Level <= clog.Error {
+			priority = append(priority, message)
+		} else {
+			regular = append(regular, message)
+		}
+	}
+	if len(priority) == 0 || len(regular) == 0 {
+		return c.saveWithWorkers(messages)
+	}
+
+	if err := c.Save(priority); err != nil {
+		// Do not wait for another failure, keep everything for the next flush
+		return messages, err
+	}
+	return c.saveWithWorkers(regular)
+}
+
+// saveWithWorkers partitions messages between flush workers and saves the partitions concurrently.
+// Returns messages from the failed partitions and the first error.
+func (c *ElasticSearchLogger) saveWithWorkers(messages []*clog.LogMessage) ([]*clog.LogMessage, error) {
+	workers := c.workers
+	if workers > len(messages) {
+		workers = len(messages)
+	}
+	if workers <= 1 {
+		if err := c.Save(messages); err != nil {
+			return messages, err
+		}
+		return nil, nil
+	}
+
+	size := (len(messages) + workers - 1) / workers
+	partitions := make([][]*clog.LogMessage, 0, workers)
+	for start := 0; start < len(messages); start += size {
+		end := start + size
+		if end > len(messages) {
+			end = len(messages)
+		}
+		partitions = append(partitions, messages[start:end])
+	}
+
+	errs := make([]error, len(partitions))
+	var wg sync.WaitGroup
+	for i, partition := range partitions {
+		wg.Add(1)
+		go func(i int, partition []*clog.LogMessage) {
+			defer wg.Done()
+			errs[i] = c.Save(partition)
+		}(i, partition)
+	}
+	wg.Wait()
+
+	failed := make([]*clog.LogMessage, 0)
+	var err error
+	for i, partition := range partitions {
+		if errs[i] != nil {
+			failed = append(failed, partition...)
+			if err == nil {
+				err = errs[i]
+			}
+		}
+	}
+	return failed, err
+}
+
+// Clear method clears (removes) all cached log messages.
+func (c *ElasticSearchLogger) Clear() {
+	c.Lock.Lock()
+	c.Cache = []*clog.LogMessage{}
+	c.Updated = false
+	c.releaseCache()
+	c.Lock.Unlock()
+
+	c.clearMessageContexts()
+}
+
+// releaseCache wakes up writers blocked on saturated cache. Must be called under c.Lock.
+func (c *ElasticSearchLogger) releaseCache() {
+	if c.cacheReleased != nil {
+		close(c.cacheReleased)
+	}
+	c.cacheReleased = make(chan struct{})
+}
+
+// waitForCache blocks until the cache has room for a new message or block_timeout expires.
+// Returns false if the timeout expired and the message shall be dropped.
+func (c *ElasticSearchLogger) waitForCache() bool {
+	deadline := c.clock.Now().Add(time.Duration(c.blockTimeout) * time.Millisecond)
+	flushing := false
+
+	for {
+		c.Lock.Lock()
+		if len(c.Cache) < c.MaxCacheSize {
+			c.Lock.Unlock()
+			return true
+		}
+		if c.cacheReleased == nil {
+			c.cacheReleased = make(chan struct{})
+		}
+		released := c.cacheReleased
+		c.Lock.Unlock()
+
+		// Initiate flush to free up the cache
+		if !flushing && c.IsOpen() {
+			flushing = true
+			go c.Dump()
+		}
+
+		remaining := deadline.Sub(c.clock.Now())
+		if remaining <= 0 {
+			return false
+		}
+
+		ticks, stop := c.clock.NewTicker(remaining)
+		select {
+		case <-released:
+			stop()
+			flushing = false
+		case <-ticks:
+			stop()
+			return false
+		}
+	}
+}
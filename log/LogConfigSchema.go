@@ -0,0 +1,196 @@
+package log
+
+import (
+	"sort"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	ccon "github.com/pip-services3-go/pip-services3-components-go/connect"
+)
+
+// Kinds of values accepted by logger options
+const (
+	stringOption = iota
+	booleanOption
+	integerOption
+	positiveOption
+)
+
+// loggerOptionsSchema lists options supported by ElasticSearchLogger with kinds of their values.
+var loggerOptionsSchema = map[string]int{
+	"interval":                integerOption,
+	"immediate":               booleanOption,
+	"interval_jitter":         integerOption,
+	"refresh":                 stringOption,
+	"pipeline":                stringOption,
+	"pipeline_processors":     stringOption,
+	"pipeline_file":           stringOption,
+	"slm_policy":              stringOption,
+	"slm_repository":          stringOption,
+	"slm_schedule":            stringOption,
+	"slm_expire_after":        stringOption,
+	"slm_min_count":           integerOption,
+	"slm_max_count":           integerOption,
+	"error_watch":             stringOption,
+	"watch_threshold":         positiveOption,
+	"watch_window":            stringOption,
+	"watch_interval":          stringOption,
+	"watch_webhook":           stringOption,
+	"watch_email":             stringOption,
+	"max_cache_size":          positiveOption,
+	"index":                   stringOption,
+	"daily":                   booleanOption,
+	"naming":                  stringOption,
+	"schema":                  stringOption,
+	"reconnect":               integerOption,
+	"failover_after":          integerOption,
+	"failback_interval":       positiveOption,
+	"reopen_after_failures":   integerOption,
+	"open_retries":            integerOption,
+	"open_retry_timeout":      integerOption,
+	"connect_on_demand":       booleanOption,
+	"srv_protocol":            stringOption,
+	"timeout":                 positiveOption,
+	"connect_timeout":         positiveOption,
+	"tls_handshake_timeout":   positiveOption,
+	"idle_conn_timeout":       positiveOption,
+	"max_idle_conns_per_host": integerOption,
+	"max_conns_per_host":      integerOption,
+	"max_retries":             integerOption,
+	"retry_on_status":         stringOption,
+	"retry_backoff":           integerOption,
+	"disable_retry":           booleanOption,
+	"index_message":           booleanOption,
+	"index_per_source":        booleanOption,
+	"alerts_index":            stringOption,
+	"enrich_process":          booleanOption,
+	"capture_stack":           booleanOption,
+	"stack_depth":             positiveOption,
+	"capture_caller":          booleanOption,
+	"caller_skip":             integerOption,
+	"fields_dynamic":          stringOption,
+	"parse_json_messages":     booleanOption,
+	"json_field":              stringOption,
+	"correlation_id_text":     booleanOption,
+	"dynamic_mapping":         stringOption,
+	"dynamic_templates":       stringOption,
+	"index_sort":              booleanOption,
+	"codec":                   stringOption,
+	"shards":                  positiveOption,
+	"forcemerge":              booleanOption,
+	"max_num_segments":        positiveOption,
+	"shrink":                  booleanOption,
+	"maintenance_interval":    positiveOption,
+	"tier_preference":         stringOption,
+	"allocation_require":      stringOption,
+	"ilm_policy":              stringOption,
+	"ilm_warm_after":          stringOption,
+	"ilm_warm_require":        stringOption,
+	"exclude_sources":         stringOption,
+	"exclude_messages":        stringOption,
+	"exclude_levels":          stringOption,
+	"max_rate":                integerOption,
+	"max_burst":               integerOption,
+	"block_on_overflow":       booleanOption,
+	"block_timeout":           positiveOption,
+	"workers":                 positiveOption,
+	"stream_bulk":             booleanOption,
+	"prioritize_errors":       booleanOption,
+	"slow_request_threshold":  integerOption,
+	"verify_acks":             booleanOption,
+	"op_type":                 stringOption,
+	"ensure_mapping":          booleanOption,
+	"retry_queue_size":        integerOption,
+	"retry_spill":             stringOption,
+	"retry_spill_path":        stringOption,
+	"retry_queue_backoff":     integerOption,
+	"retry_queue_max_backoff": positiveOption,
+	"user_agent":              stringOption,
+	"opaque_id":               stringOption,
+	"client_log_level":        stringOption,
+	"metrics":                 booleanOption,
+}
+
+// Allowed values of enumerated options
+var loggerOptionValues = map[string][]string{
+	"naming":          {DefaultNaming, LogstashNaming},
+	"schema":          {DefaultSchema, EcsSchema},
+	"srv_protocol":    {"http", "https"},
+	"fields_dynamic":  {"true", "false", "strict"},
+	"dynamic_mapping": {"true", "false", "strict"},
+	"codec":           {"default", "best_compression"},
+	"refresh":         {"true", "false", "wait_for"},
+	"op_type":         {IndexOpType, CreateOpType},
+	"retry_spill":     {DropSpill, DiskSpill},
+}
+
+// validateConfig checks logger configuration against the options schema and connection parameters.
+// Returns ConfigError describing the first found problem or nil when configuration is valid.
+func validateConfig(config *cconf.ConfigParams) error {
+	options := config.GetSection("options")
+	names := options.Keys()
+	sort.Strings(names)
+
+	for _, name := range names {
+		kind, ok := loggerOptionsSchema[name]
+		if !ok {
+			return cerr.NewConfigError("", "UNKNOWN_OPTION",
+				"Unknown configuration option options."+name).
+				WithDetails("option", name)
+		}
+
+		value := options.GetAsString(name)
+		valid := true
+		switch kind {
+		case booleanOption:
+			valid = cconv.BooleanConverter.ToNullableBoolean(value) != nil
+		case integerOption:
+			number := cconv.IntegerConverter.ToNullableInteger(value)
+			valid = number != nil && *number >= 0
+		case positiveOption:
+			number := cconv.IntegerConverter.ToNullableInteger(value)
+			valid = number != nil && *number > 0
+		}
+		if allowed, ok := loggerOptionValues[name]; ok && valid {
+			valid = false
+			for _, item := range allowed {
+				valid = valid || item == value
+			}
+		}
+
+		if !valid {
+			return cerr.NewConfigError("", "WRONG_OPTION_VALUE",
+				"Invalid value "+value+" of configuration option options."+name).
+				WithDetails("option", name).WithDetails("value", value)
+		}
+	}
+
+	for _, name := range splitList(options.GetAsString("exclude_levels")) {
+		if _, ok := parseLogLevel(name); !ok {
+			return wrongExcludeLevel(name)
+		}
+	}
+
+	// Shards are co-located on one node for shrinking only by allocation_require
+	if options.GetAsBoolean("shrink") && options.GetAsString("allocation_require") == "" {
+		return cerr.NewConfigError("", "NO_SHRINK_ALLOCATION",
+			"Configuration option options.shrink requires options.allocation_require").
+			WithDetails("option", "shrink")
+	}
+
+	for _, connection := range ccon.NewManyConnectionParamsFromConfig(config) {
+		if connection.Uri() == "" && connection.Host() == "" && !connection.UseDiscovery() {
+			return cerr.NewConfigError("", "NO_HOST",
+				"Connection must define uri, host or discovery_key")
+		}
+		if port := connection.GetAsString("port"); port != "" {
+			if cconv.IntegerConverter.ToNullableInteger(port) == nil {
+				return cerr.NewConfigError("", "WRONG_PORT",
+					"Invalid connection port "+port).WithDetails("port", port)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"strings"
+	"time"
+
+	cconv "github.com/pip-services3-go/pip-services3-commons-go/convert"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// logDocumentFields holds names of log document fields used in queries,
+// which depend on the naming and the schema the logger writes with.
+type logDocumentFields struct {
+	ecs           bool
+	time          string
+	level         string
+	source        string
+	correlationId string
+	// W3C trace id, the same in both schemas to match APM and tracer documents
+	traceId string
+	// Values of the level field for error and fatal messages
+	errorLevels []interface{}
+}
+
+func newLogDocumentFields(naming string, schema string) *logDocumentFields {
+	c := &logDocumentFields{
+		time:          "time",
+		level:         "level",
+		source:        "source",
+		correlationId: "correlation_id",
+		traceId:       "trace.id",
+		errorLevels:   []interface{}{clog.Fatal, clog.Error},
+	}
+	if naming == LogstashNaming || schema == EcsSchema {
+		c.time = "@timestamp"
+	}
+	if schema == EcsSchema {
+		c.ecs = true
+		c.level = "log.level"
+		c.source = "service.name"
+		c.correlationId = "labels.correlation_id"
+		c.errorLevels = []interface{}{"fatal", "error"}
+	}
+	return c
+}
+
+// parseMessage converts a document read from the log index back into a log message.
+func (c *logDocumentFields) parseMessage(doc map[string]interface{}) *clog.LogMessage {
+	message := &clog.LogMessage{
+		Message: cconv.StringConverter.ToString(doc["message"]),
+	}
+	if value, ok := doc[c.time].(string); ok {
+		message.Time, _ = time.Parse(time.RFC3339Nano, value)
+	}
+
+	var errorDoc map[string]interface{}
+	if c.ecs {
+		log, _ := doc["log"].(map[string]interface{})
+		message.Level = clog.LogLevelConverter.ToLogLevel(strings.ToUpper(cconv.StringConverter.ToString(log["level"])))
+		message.Source = cconv.StringConverter.ToString(log["logger"])
+		labels, _ := doc["labels"].(map[string]interface{})
+		message.CorrelationId = cconv.StringConverter.ToString(labels["correlation_id"])
+		errorDoc, _ = doc["error"].(map[string]interface{})
+	} else {
+		message.Level = cconv.IntegerConverter.ToInteger(doc["level"])
+		message.Source = cconv.StringConverter.ToString(doc["source"])
+		message.CorrelationId = cconv.StringConverter.ToString(doc["correlation_id"])
+		errorDoc, _ = doc["error"].(map[string]interface{})
+	}
+
+	if errorDoc != nil {
+		message.Error.Type = cconv.StringConverter.ToString(errorDoc["type"])
+		message.Error.Category = cconv.StringConverter.ToString(errorDoc["category"])
+		message.Error.Code = cconv.StringConverter.ToString(errorDoc["code"])
+		message.Error.Message = cconv.StringConverter.ToString(errorDoc["message"])
+		message.Error.StackTrace = cconv.StringConverter.ToString(errorDoc["stack_trace"])
+		message.Error.Cause = cconv.StringConverter.ToString(errorDoc["cause"])
+		message.Error.CorrelationId = cconv.StringConverter.ToString(errorDoc["correlation_id"])
+		message.Error.Status = cconv.IntegerConverter.ToInteger(errorDoc["status"])
+	}
+	return message
+}
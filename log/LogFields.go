@@ -0,0 +1,92 @@
+package log
+
+import (
+	"fmt"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// LogWithFields method logs a message with structured fields at specified log level.
+// The fields are indexed as "fields" object instead of being formatted into the message.
+// Parameters:
+//   - level int  a log level.
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - err error  an error object associated with this message.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) LogWithFields(level int, correlationId string, err error,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	c.write(level, correlationId, err, fields, message)
+}
+
+// FatalWithFields method logs fatal (unrecoverable) message with structured fields.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - err error  an error object associated with this message.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) FatalWithFields(correlationId string, err error,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	c.LogWithFields(clog.Fatal, correlationId, err, fields, message, args...)
+}
+
+// ErrorWithFields method logs recoverable application error with structured fields.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - err error  an error object associated with this message.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) ErrorWithFields(correlationId string, err error,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	c.LogWithFields(clog.Error, correlationId, err, fields, message, args...)
+}
+
+// WarnWithFields method logs a warning with structured fields.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) WarnWithFields(correlationId string,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	c.LogWithFields(clog.Warn, correlationId, nil, fields, message, args...)
+}
+
+// InfoWithFields method logs an important information message with structured fields.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) InfoWithFields(correlationId string,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	c.LogWithFields(clog.Info, correlationId, nil, fields, message, args...)
+}
+
+// DebugWithFields method logs a high-level debug information with structured fields.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) DebugWithFields(correlationId string,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	c.LogWithFields(clog.Debug, correlationId, nil, fields, message, args...)
+}
+
+// TraceWithFields method logs a low-level debug information with structured fields.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - fields map[string]interface{}  structured fields to be indexed with the message.
+//   - message string  a human-readable message to log.
+//   - args ...interface{}  arguments to parameterize the message.
+func (c *ElasticSearchLogger) TraceWithFields(correlationId string,
+	fields map[string]interface{}, message string, args ...interface{}) {
+	c.LogWithFields(clog.Trace, correlationId, nil, fields, message, args...)
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"regexp"
+	"strings"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// logFilter suppresses noisy log messages by source, message text or level.
+type logFilter struct {
+	sources  []*regexp.Regexp
+	messages *regexp.Regexp
+	levels   map[int]bool
+}
+
+// newLogFilter creates a filter from options.exclude_* configuration parameters.
+// Returns nil filter when no exclusions are configured.
+func newLogFilter(config *cconf.ConfigParams) (*logFilter, error) {
+	filter := &logFilter{levels: map[int]bool{}}
+
+	for _, source := range splitList(config.GetAsString("options.exclude_sources")) {
+		pattern := "^" + strings.Replace(regexp.QuoteMeta(source), `\*`, ".*", -1) + "$"
+		filter.sources = append(filter.sources, regexp.MustCompile(pattern))
+	}
+
+	if value := config.GetAsString("options.exclude_messages"); value != "" {
+		messages, err := regexp.Compile(value)
+		if err != nil {
+			return nil, cerr.NewConfigError("", "WRONG_EXCLUDE_MESSAGES",
+				"Invalid regular expression in options.exclude_messages").
+				WithDetails("pattern", value).WithCause(err)
+		}
+		filter.messages = messages
+	}
+
+	for _, name := range splitList(config.GetAsString("options.exclude_levels")) {
+		level, ok := parseLogLevel(name)
+		if !ok {
+			return nil, wrongExcludeLevel(name)
+		}
+		filter.levels[level] = true
+	}
+
+	if len(filter.sources) == 0 && filter.messages == nil && len(filter.levels) == 0 {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Excludes checks if the message shall be suppressed.
+func (c *logFilter) Excludes(message *clog.LogMessage) bool {
+	if c.levels[message.Level] {
+		return true
+	}
+	for _, source := range c.sources {
+		if source.MatchString(message.Source) {
+			return true
+		}
+	}
+	if c.messages != nil && c.messages.MatchString(message.Message) {
+		return true
+	}
+	return false
+}
+
+// Names and numbers of log levels accepted by options.exclude_levels
+var logLevelNames = map[string]int{
+	"0": clog.None, "nothing": clog.None, "none": clog.None,
+	"1": clog.Fatal, "fatal": clog.Fatal,
+	"2": clog.Error, "error": clog.Error,
+	"3": clog.Warn, "warn": clog.Warn, "warning": clog.Warn,
+	"4": clog.Info, "info": clog.Info,
+	"5": clog.Debug, "debug": clog.Debug,
+	"6": clog.Trace, "trace": clog.Trace,
+}
+
+// parseLogLevel converts a level name or number into the log level.
+// Unlike LogLevelConverter it does not turn unknown names into Info.
+func parseLogLevel(name string) (int, bool) {
+	level, ok := logLevelNames[strings.ToLower(name)]
+	return level, ok
+}
+
+func wrongExcludeLevel(name string) error {
+	return cerr.NewConfigError("", "WRONG_EXCLUDE_LEVEL",
+		"Unknown log level "+name+" in options.exclude_levels").
+		WithDetails("option", "exclude_levels").WithDetails("value", name)
+}
+
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,12 @@
+package log
+
+// logIndices returns the indices written by the logger: the index itself and its daily
+// and per-source indices. Neighbouring indices like logs-* of other shippers are not matched
+// and the alerts index is excluded, so errors mirrored there are not counted twice.
+func logIndices(index string, alertsIndex string) []string {
+	indices := []string{index, index + "-*"}
+	if alertsIndex != "" {
+		indices = append(indices, "-"+alertsIndex, "-"+alertsIndex+"-*")
+	}
+	return indices
+}
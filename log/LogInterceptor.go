@@ -0,0 +1,56 @@
+package log
+
+import (
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// LogInterceptor is a function that enriches, rewrites or drops log messages.
+// It returns the message to be processed further or nil to drop the message.
+type LogInterceptor func(message *clog.LogMessage) *clog.LogMessage
+
+// AddInterceptor method adds an interceptor applied to log messages before they are cached.
+// Interceptors are applied in the order they were added.
+// Parameters:
+//   - interceptor LogInterceptor  an interceptor to be added.
+func (c *ElasticSearchLogger) AddInterceptor(interceptor LogInterceptor) {
+	c.interceptorsLock.Lock()
+	defer c.interceptorsLock.Unlock()
+
+	c.interceptors = append(c.interceptors, interceptor)
+}
+
+// AddFlushInterceptor method adds an interceptor applied to cached log messages
+// right before they are sent to ElasticSearch.
+// Interceptors are applied in the order they were added.
+// Parameters:
+//   - interceptor LogInterceptor  an interceptor to be added.
+func (c *ElasticSearchLogger) AddFlushInterceptor(interceptor LogInterceptor) {
+	c.interceptorsLock.Lock()
+	defer c.interceptorsLock.Unlock()
+
+	c.flushInterceptors = append(c.flushInterceptors, interceptor)
+}
+
+func (c *ElasticSearchLogger) intercept(interceptors []LogInterceptor, message *clog.LogMessage) *clog.LogMessage {
+	for _, interceptor := range interceptors {
+		message = interceptor(message)
+		if message == nil {
+			return nil
+		}
+	}
+	return message
+}
+
+func (c *ElasticSearchLogger) getInterceptors(flush bool) []LogInterceptor {
+	if c.parent != nil {
+		return c.parent.getInterceptors(flush)
+	}
+
+	c.interceptorsLock.Lock()
+	defer c.interceptorsLock.Unlock()
+
+	if flush {
+		return c.flushInterceptors
+	}
+	return c.interceptors
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"strconv"
+
+	"github.com/elastic/go-elasticsearch/v8/estransport"
+)
+
+// Prefix of counters published by the logger
+const metricsPrefix = "elasticsearch_logger"
+
+// Reasons messages are dropped for, reported as elasticsearch_logger.dropped.<reason> counters
+const (
+	dropFilter      = "filter"
+	dropInterceptor = "interceptor"
+	dropRateLimit   = "rate_limit"
+	dropOverflow    = "overflow"
+	dropRejected    = "rejected"
+	dropEncoding    = "encoding"
+)
+
+// countDropped reports messages that will never reach ElasticSearch to referenced counters.
+// The migration logger does not report messages the primary one already counted.
+func (c *ElasticSearchLogger) countDropped(reason string, count int) {
+	if count <= 0 || c.parent != nil {
+		return
+	}
+	c.counters.Increment(metricsPrefix+".dropped."+reason, count)
+}
+
+// countFailed reports messages of failed flushes, which are kept in the cache and retried.
+func (c *ElasticSearchLogger) countFailed(count int) {
+	if count <= 0 || c.parent != nil {
+		return
+	}
+	c.counters.Increment(metricsPrefix+".failed", count)
+}
+
+// publishMetrics reports ElasticSearch client transport metrics collected since the last call
+// to referenced counters.
+func (c *ElasticSearchLogger) publishMetrics() {
+	client := c.getClient()
+	if !c.enableMetrics || client == nil {
+		return
+	}
+
+	metrics, err := client.Metrics()
+	if err != nil {
+		return
+	}
+
+	// Flushes may overlap, serialize reporting to keep deltas consistent
+	c.metricsLock.Lock()
+	defer c.metricsLock.Unlock()
+
+	last := c.lastMetrics
+	if c.metricsClient != client {
+		// Metrics are collected per client, start over when it was recreated
+		last = estransport.Metrics{}
+	}
+	c.metricsClient = client
+	c.lastMetrics = metrics
+
+	c.counters.Increment(metricsPrefix+".requests", metrics.Requests-last.Requests)
+	c.counters.Increment(metricsPrefix+".failures", metrics.Failures-last.Failures)
+	for status, count := range metrics.Responses {
+		if delta := count - last.Responses[status]; delta > 0 {
+			c.counters.Increment(metricsPrefix+".responses."+strconv.Itoa(status), delta)
+		}
+	}
+
+	dead := 0
+	for _, connection := range metrics.Connections {
+		if metric, ok := connection.(estransport.ConnectionMetric); ok && metric.IsDead {
+			dead++
+		}
+	}
+	c.counters.Last(metricsPrefix+".connections", float32(len(metrics.Connections)))
+	c.counters.Last(metricsPrefix+".dead_connections", float32(dead))
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// logRateLimiter is a token bucket that limits the rate of cached log messages
+// and counts suppressed messages. The time is passed by the logger from its clock.
+type logRateLimiter struct {
+	lock       sync.Mutex
+	rate       float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+	suppressed int
+}
+
+func newLogRateLimiter(rate int, burst int) *logRateLimiter {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &logRateLimiter{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Allow takes a token from the bucket or counts the message as suppressed.
+// The bucket starts full at the time of the first call and does not refill when the time goes back.
+func (c *logRateLimiter) Allow(now time.Time) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.last.IsZero() {
+		c.last = now
+	}
+	if now.After(c.last) {
+		c.tokens += now.Sub(c.last).Seconds() * c.rate
+		if c.tokens > c.burst {
+			c.tokens = c.burst
+		}
+		c.last = now
+	}
+
+	if c.tokens < 1 {
+		c.suppressed++
+		return false
+	}
+	c.tokens--
+	return true
+}
+
+// TakeSuppressed returns the number of suppressed messages and resets the counter.
+func (c *logRateLimiter) TakeSuppressed() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	suppressed := c.suppressed
+	c.suppressed = 0
+	return suppressed
+}
+
+// cacheSuppressedSummary puts a single summary message into the cache
+// when messages were suppressed by the rate limit.
+func (c *ElasticSearchLogger) cacheSuppressedSummary() {
+	if c.rateLimiter == nil {
+		return
+	}
+
+	suppressed := c.rateLimiter.TakeSuppressed()
+	if suppressed == 0 {
+		return
+	}
+
+	summary := &clog.LogMessage{
+		Time:    c.clock.Now().UTC(),
+		Level:   clog.Warn,
+		Source:  c.Source(),
+		Message: fmt.Sprintf("%d messages suppressed by rate limit of %d messages per second", suppressed, c.maxRate),
+	}
+
+	c.Lock.Lock()
+	c.Cache = append(c.Cache, summary)
+	c.Updated = true
+	c.Lock.Unlock()
+}
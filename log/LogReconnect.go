@@ -0,0 +1,137 @@
+package log
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// ping verifies that ElasticSearch is reachable retrying open_retries times.
+// Returns ConnectionError with the uris when all attempts failed.
+func (c *ElasticSearchLogger) ping(correlationId string, uris []string) error {
+	var err error
+	for attempt := 0; attempt <= c.openRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(c.openRetryTimeout) * time.Millisecond)
+		}
+
+		var resp *esapi.Response
+		resp, err = c.getApi().Info()
+		if err != nil {
+			continue
+		}
+		appErr := econnect.NewErrorFromResponse(correlationId, resp)
+		resp.Body.Close()
+		if appErr == nil {
+			return nil
+		}
+		err = appErr
+	}
+
+	return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT",
+		"Cannot connect to ElasticSearch at "+redactUris(uris)).
+		WithDetails("uri", redactUris(uris)).WithCause(err)
+}
+
+// checkConnection re-resolves the connections, rebuilds the client when node addresses
+// have changed and pings ElasticSearch. When ElasticSearch is not reachable or flushes
+// keep failing the client is torn down and recreated by the next check.
+func (c *ElasticSearchLogger) checkConnection(correlationId string) {
+	// Not connected yet in connect_on_demand mode or torn down by the previous check
+	if c.getApi() == nil {
+		if atomic.LoadInt32(&c.recovering) != 0 {
+			if err := c.connect(correlationId); err != nil {
+				c.logger.Warn(correlationId, "Failed to reconnect to ElasticSearch: %v", err)
+			}
+		}
+		return
+	}
+
+	failures := int(atomic.LoadInt32(&c.failedFlushes))
+	if c.reopenAfterFailures > 0 && failures >= c.reopenAfterFailures {
+		c.logger.Warn(correlationId, "Reopening ElasticSearch client after %d failed flushes", failures)
+		c.disconnectForRecovery()
+		return
+	}
+
+	// Custom clients are not created from connections
+	if c.getClient() == nil {
+		return
+	}
+
+	uris, err := c.resolveUris(correlationId)
+	if err != nil {
+		c.logger.Warn(correlationId, "Failed to resolve ElasticSearch connection: %v", err)
+		return
+	}
+
+	address := redactUris(uris)
+	if err := c.reconnectTo(uris); err != nil {
+		c.logger.Warn(correlationId, "Failed to connect to ElasticSearch at %s: %v", address, err)
+		return
+	}
+
+	client := c.getClient()
+	if client == nil {
+		return
+	}
+
+	resp, err := client.Ping()
+	if err != nil {
+		c.logger.Warn(correlationId, "ElasticSearch at %s is not reachable: %v", address, err)
+		c.disconnectForRecovery()
+		return
+	}
+	resp.Body.Close()
+	if resp.IsError() {
+		c.logger.Warn(correlationId, "ElasticSearch at %s responded to ping with status %d", address, resp.StatusCode)
+	}
+}
+
+// reconnectTo replaces the client when the node addresses have changed.
+func (c *ElasticSearchLogger) reconnectTo(uris []string) error {
+	c.connectLock.Lock()
+	defer c.connectLock.Unlock()
+
+	c.clientLock.RLock()
+	current := c.client
+	changed := strings.Join(c.uris, ",") != strings.Join(uris, ",")
+	c.clientLock.RUnlock()
+
+	if current == nil || !changed {
+		return nil
+	}
+
+	client, err := c.createClient(uris)
+	if err != nil {
+		return err
+	}
+	c.setClient(client, uris)
+	c.logger.Info("", "Reconnected to ElasticSearch at %s", redactUris(uris))
+	return nil
+}
+
+// disconnect tears down the client and its default transport, so they are recreated
+// with freshly resolved connection and DNS records on the next flush.
+func (c *ElasticSearchLogger) disconnect() {
+	c.connectLock.Lock()
+	defer c.connectLock.Unlock()
+
+	c.setClient(nil, nil)
+	if c.defaultTransport != nil {
+		c.defaultTransport.CloseIdleConnections()
+		c.defaultTransport = nil
+	}
+	atomic.StoreInt32(&c.failedFlushes, 0)
+	atomic.StoreInt32(&c.recovering, 0)
+}
+
+// disconnectForRecovery tears down the client, which is then recreated by the reconnect timer.
+func (c *ElasticSearchLogger) disconnectForRecovery() {
+	c.disconnect()
+	atomic.StoreInt32(&c.recovering, 1)
+}
@@ -0,0 +1,169 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// ensureMapping compares the mapping of an existing index with the mapping the logger declares.
+// Missing fields are added with Put Mapping, fields mapped with a different type are reported
+// as INCOMPATIBLE_MAPPING error, since they fail every bulk request with mapper exceptions.
+// Typed mappings returned by ElasticSearch 6 are unwrapped by the logger document type
+// and updated with the type in Put Mapping request.
+func (c *ElasticSearchLogger) ensureMapping(correlationId string, index string,
+	composeBody func() map[string]interface{}) error {
+	client := c.getClient()
+	if client == nil {
+		c.logger.Warn(correlationId, "Mapping of index %s is not verified by a custom ElasticSearch client", index)
+		return nil
+	}
+
+	resp, err := client.Indices.GetMapping(client.Indices.GetMapping.WithIndex(index))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+
+	var live map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&live); err != nil {
+		return err
+	}
+
+	// Aliases are reported under the name of the concrete index
+	var properties map[string]interface{}
+	typed := false
+	for _, mapping := range live {
+		properties, _ = mapping.Mappings["properties"].(map[string]interface{})
+		if typedMapping, ok := mapping.Mappings[c.documentType()].(map[string]interface{}); ok && properties == nil {
+			properties, _ = typedMapping["properties"].(map[string]interface{})
+			typed = true
+		}
+	}
+
+	declared := declaredProperties(composeBody(), c.documentType())
+	additions := map[string]interface{}{}
+	conflicts := make([]string, 0)
+	diffProperties(declared, properties, "", additions, &conflicts)
+
+	if len(conflicts) > 0 {
+		return cerr.NewConfigError(correlationId, "INCOMPATIBLE_MAPPING",
+			"Mapping of index "+index+" is incompatible with the logger: "+strings.Join(conflicts, ", ")).
+			WithDetails("index", index).WithDetails("conflicts", conflicts)
+	}
+	if len(additions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"properties": additions})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var putResp *esapi.Response
+	if typed {
+		putResp, err = c.putTypedMapping(index, body)
+	} else {
+		putResp, err = client.Indices.PutMapping([]string{index}, bytes.NewReader(body))
+	}
+	c.traceRequest(correlationId, "put mapping", index, start, len(body))
+	if err != nil {
+		return err
+	}
+	defer putResp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, putResp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// putTypedMapping updates the mapping of the logger document type in ElasticSearch 6 index,
+// the client API supports only typeless mappings.
+func (c *ElasticSearchLogger) putTypedMapping(index string, body []byte) (*esapi.Response, error) {
+	path := "/" + url.PathEscape(index) + "/_mapping/" + url.PathEscape(c.documentType())
+	req, err := http.NewRequest(http.MethodPut, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.getClient().Perform(req)
+	if err != nil {
+		return nil, err
+	}
+	return &esapi.Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}, nil
+}
+
+// declaredProperties extracts field mappings from the index body composed by the logger.
+func declaredProperties(body map[string]interface{}, documentType string) map[string]interface{} {
+	mappings, _ := body["mappings"].(map[string]interface{})
+	if typed, ok := mappings[documentType].(map[string]interface{}); ok {
+		mappings = typed
+	}
+	properties, _ := mappings["properties"].(map[string]interface{})
+	return properties
+}
+
+// mappingType returns the type of the field mapping, fields with properties default to object.
+func mappingType(mapping map[string]interface{}) string {
+	if value, ok := mapping["type"].(string); ok {
+		return value
+	}
+	return "object"
+}
+
+// diffProperties collects declared fields missing in the live mapping into additions
+// and fields mapped with a different type into conflicts.
+func diffProperties(declared map[string]interface{}, live map[string]interface{}, path string,
+	additions map[string]interface{}, conflicts *[]string) {
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		declaredField, _ := declared[name].(map[string]interface{})
+		liveField, ok := live[name].(map[string]interface{})
+		if !ok {
+			additions[name] = declared[name]
+			continue
+		}
+
+		if declaredType, liveType := mappingType(declaredField), mappingType(liveField); declaredType != liveType {
+			*conflicts = append(*conflicts, path+name+" is "+liveType+" instead of "+declaredType)
+			continue
+		}
+
+		declaredProps, _ := declaredField["properties"].(map[string]interface{})
+		if len(declaredProps) == 0 {
+			continue
+		}
+		liveProps, _ := liveField["properties"].(map[string]interface{})
+		nested := map[string]interface{}{}
+		diffProperties(declaredProps, liveProps, path+name+".", nested, conflicts)
+		if len(nested) > 0 {
+			field := map[string]interface{}{"properties": nested}
+			if value, ok := declaredField["type"]; ok {
+				field["type"] = value
+			}
+			additions[name] = field
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"runtime"
+
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// messageContext holds data captured at the moment a message is logged
+// that cannot be stored in clog.LogMessage.
+type messageContext struct {
+	// Id of the goroutine that logged the message
+	goroutineId int64
+	// Location of the code that logged the message
+	caller *runtime.Frame
+	// Structured fields passed with the message
+	fields map[string]interface{}
+}
+
+// setMessageContext attaches the context to the cached message.
+func (c *ElasticSearchLogger) setMessageContext(message *clog.LogMessage, context *messageContext) {
+	if context == nil {
+		return
+	}
+
+	c.contextsLock.Lock()
+	defer c.contextsLock.Unlock()
+
+	if c.contexts == nil {
+		c.contexts = map[*clog.LogMessage]*messageContext{}
+	}
+	c.contexts[message] = context
+}
+
+// getMessageContext returns the context attached to the message or nil if there is none.
+func (c *ElasticSearchLogger) getMessageContext(message *clog.LogMessage) *messageContext {
+	c.contextsLock.Lock()
+	defer c.contextsLock.Unlock()
+
+	return c.contexts[message]
+}
+
+// releaseMessageContexts drops contexts of messages that left the cache.
+func (c *ElasticSearchLogger) releaseMessageContexts(messages []*clog.LogMessage) {
+	c.contextsLock.Lock()
+	defer c.contextsLock.Unlock()
+
+	if len(c.contexts) == 0 {
+		return
+	}
+	for _, message := range messages {
+		delete(c.contexts, message)
+	}
+}
+
+// clearMessageContexts drops all attached contexts.
+func (c *ElasticSearchLogger) clearMessageContexts() {
+	c.contextsLock.Lock()
+	defer c.contextsLock.Unlock()
+
+	c.contexts = nil
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// processInfo describes the process that writes log messages.
+type processInfo struct {
+	hostName  string
+	pid       int
+	goVersion string
+}
+
+func newProcessInfo() *processInfo {
+	hostName, _ := os.Hostname()
+	return &processInfo{
+		hostName:  hostName,
+		pid:       os.Getpid(),
+		goVersion: runtime.Version(),
+	}
+}
+
+// currentGoroutineId parses the id of the calling goroutine from its stack header
+// that looks like "goroutine 123 [running]:". Returns 0 when the id cannot be parsed.
+func currentGoroutineId() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	header := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i > 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseInt(string(header), 10, 64)
+	return id
+}
@@ -0,0 +1,405 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+const (
+	// DropSpill drops the oldest batches when the retry queue is full
+	DropSpill = "drop"
+	// DiskSpill writes the oldest batches into files when the retry queue is full
+	DiskSpill = "disk"
+)
+
+const (
+	spillFilePrefix = "retry-"
+	spillFileSuffix = ".json"
+)
+
+// retryQueue keeps batches that failed to be saved until they are retried.
+// Spilled batches are older than batches kept in memory and are retried first.
+type retryQueue struct {
+	lock        sync.Mutex
+	batches     [][]*clog.LogMessage
+	size        int
+	files       []string
+	spilling    int
+	retrying    bool
+	idle        *sync.Cond
+	backoff     time.Duration
+	nextAttempt time.Time
+}
+
+// configureRetryQueue reads retry queue options.
+func (c *ElasticSearchLogger) configureRetryQueue(config *cconf.ConfigParams) error {
+	c.retryQueueSize = config.GetAsIntegerWithDefault("options.retry_queue_size", c.retryQueueSize)
+	c.retrySpill = config.GetAsStringWithDefault("options.retry_spill", c.retrySpill)
+	c.retrySpillPath = config.GetAsStringWithDefault("options.retry_spill_path", c.retrySpillPath)
+	c.retryQueueBackoff = config.GetAsIntegerWithDefault("options.retry_queue_backoff", c.retryQueueBackoff)
+	c.retryQueueMaxBackoff = config.GetAsIntegerWithDefault("options.retry_queue_max_backoff", c.retryQueueMaxBackoff)
+
+	if c.retryQueueSize > 0 && c.retrySpill == DiskSpill && c.retrySpillPath == "" {
+		return cerr.NewConfigError("", "NO_SPILL_PATH",
+			"options.retry_spill_path must be set to spill failed batches to disk").
+			WithDetails("option", "retry_spill_path")
+	}
+	return nil
+}
+
+// openRetryQueue creates the retry queue and picks up batches spilled before a restart.
+func (c *ElasticSearchLogger) openRetryQueue() error {
+	c.retries = nil
+	if c.retryQueueSize <= 0 {
+		return nil
+	}
+
+	queue := &retryQueue{}
+	queue.idle = sync.NewCond(&queue.lock)
+	if c.retrySpill == DiskSpill {
+		files, err := ioutil.ReadDir(c.retrySpillPath)
+		if err != nil && !os.IsNotExist(err) {
+			return cerr.NewFileError("", "CANNOT_READ_SPILL", "Cannot read spilled batches").
+				WithDetails("path", c.retrySpillPath).WithCause(err)
+		}
+		for _, file := range files {
+			name := file.Name()
+			if !file.IsDir() && strings.HasPrefix(name, spillFilePrefix) && strings.HasSuffix(name, spillFileSuffix) {
+				queue.files = append(queue.files, filepath.Join(c.retrySpillPath, name))
+			}
+		}
+		sort.Strings(queue.files)
+	}
+	c.retries = queue
+	return nil
+}
+
+// hasQueuedRetries checks if there are batches waiting to be retried.
+func (c *ElasticSearchLogger) hasQueuedRetries() bool {
+	if c.retries == nil {
+		return false
+	}
+
+	c.retries.lock.Lock()
+	defer c.retries.lock.Unlock()
+
+	return len(c.retries.batches) > 0 || len(c.retries.files) > 0 || c.retries.spilling > 0
+}
+
+// enqueueRetry puts a failed batch into the retry queue. When the queue exceeds retry_queue_size
+// messages, the oldest batches are spilled to disk or dropped according to retry_spill.
+func (c *ElasticSearchLogger) enqueueRetry(messages []*clog.LogMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	queue := c.retries
+	queue.lock.Lock()
+	queue.batches = append(queue.batches, messages)
+	queue.size += len(messages)
+	spilled := make([][]*clog.LogMessage, 0)
+	for queue.size > c.retryQueueSize && len(queue.batches) > 0 {
+		oldest := queue.batches[0]
+		queue.batches = queue.batches[1:]
+		queue.size -= len(oldest)
+
+		if c.retrySpill == DiskSpill {
+			spilled = append(spilled, oldest)
+			queue.spilling += len(oldest)
+		} else {
+			c.countDropped(dropOverflow, len(oldest))
+			c.releaseMessageContexts(oldest)
+		}
+	}
+	queue.lock.Unlock()
+
+	// Files are written without holding the lock, so a slow disk does not stall flushes and retries
+	for _, batch := range spilled {
+		file, err := c.spillBatch(batch)
+
+		queue.lock.Lock()
+		queue.spilling -= len(batch)
+		if err == nil {
+			queue.files = append(queue.files, file)
+		}
+		queue.lock.Unlock()
+
+		if err != nil {
+			c.logger.Error("", err, "Failed to spill %d log messages to %s", len(batch), c.retrySpillPath)
+			c.countDropped(dropOverflow, len(batch))
+		}
+		c.releaseMessageContexts(batch)
+	}
+}
+
+// spilledMessage is a log message stored in a spill file together with its context.
+type spilledMessage struct {
+	clog.LogMessage
+	GoroutineId int64                  `json:"goroutine_id,omitempty"`
+	Caller      *spilledCaller         `json:"caller,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+}
+
+// spilledCaller is a location of the code that logged a spilled message.
+type spilledCaller struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// spillBatch writes the batch with contexts of the messages into a new file named in the order of spilling.
+// Returns the file path.
+func (c *ElasticSearchLogger) spillBatch(messages []*clog.LogMessage) (string, error) {
+	if err := os.MkdirAll(c.retrySpillPath, 0755); err != nil {
+		return "", err
+	}
+
+	spilled := make([]spilledMessage, len(messages))
+	for i, message := range messages {
+		spilled[i].LogMessage = *message
+		if context := c.getMessageContext(message); context != nil {
+			spilled[i].GoroutineId = context.goroutineId
+			spilled[i].Fields = context.fields
+			if context.caller != nil {
+				spilled[i].Caller = &spilledCaller{
+					Function: context.caller.Function,
+					File:     context.caller.File,
+					Line:     context.caller.Line,
+				}
+			}
+		}
+	}
+
+	data, err := json.Marshal(spilled)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s%020d-%s%s", spillFilePrefix, time.Now().UnixNano(),
+		cdata.IdGenerator.NextShort(), spillFileSuffix)
+	file := filepath.Join(c.retrySpillPath, name)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// readSpilledBatch reads messages of a spilled batch and restores their contexts.
+func (c *ElasticSearchLogger) readSpilledBatch(file string) ([]*clog.LogMessage, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers in fields are kept as they were written
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	spilled := make([]spilledMessage, 0)
+	if err := decoder.Decode(&spilled); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*clog.LogMessage, len(spilled))
+	for i := range spilled {
+		message := spilled[i].LogMessage
+		messages[i] = &message
+		if spilled[i].GoroutineId == 0 && spilled[i].Caller == nil && len(spilled[i].Fields) == 0 {
+			continue
+		}
+
+		context := &messageContext{
+			goroutineId: spilled[i].GoroutineId,
+			fields:      spilled[i].Fields,
+		}
+		if caller := spilled[i].Caller; caller != nil {
+			context.caller = &runtime.Frame{Function: caller.Function, File: caller.File, Line: caller.Line}
+		}
+		c.setMessageContext(messages[i], context)
+	}
+	return messages, nil
+}
+
+// retryQueued saves queued batches, oldest first, until one of them fails.
+// Failures double the delay before the next attempt up to retry_queue_max_backoff.
+// Parameters:
+//   - force bool  true to retry without waiting for the backoff delay,
+//     it waits for a retry started by another flush to finish
+// Returns true if batches are still pending and the error of the failed attempt.
+func (c *ElasticSearchLogger) retryQueued(force bool) (pending bool, err error) {
+	queue := c.retries
+	queue.lock.Lock()
+	for force && queue.retrying {
+		queue.idle.Wait()
+	}
+	if queue.retrying || (!force && c.clock.Now().Before(queue.nextAttempt)) {
+		pending = len(queue.batches) > 0 || len(queue.files) > 0
+		queue.lock.Unlock()
+		return pending, nil
+	}
+	queue.retrying = true
+	queue.lock.Unlock()
+
+	defer func() {
+		queue.lock.Lock()
+		queue.retrying = false
+		queue.idle.Broadcast()
+		queue.lock.Unlock()
+	}()
+
+	for {
+		queue.lock.Lock()
+		var file string
+		var messages []*clog.LogMessage
+		if len(queue.files) > 0 {
+			file = queue.files[0]
+		} else if queue.spilling > 0 {
+			// Batches being spilled are older than the ones in memory, they are retried first
+			queue.lock.Unlock()
+			return true, nil
+		} else if len(queue.batches) > 0 {
+			messages = queue.batches[0]
+		} else {
+			queue.backoff = 0
+			queue.nextAttempt = time.Time{}
+			queue.lock.Unlock()
+			return false, nil
+		}
+		queue.lock.Unlock()
+
+		if file != "" {
+			if messages, err = c.readSpilledBatch(file); err != nil {
+				c.logger.Error("", err, "Dropped unreadable spilled log messages in %s", file)
+				c.popSpilledBatch(file)
+				continue
+			}
+		}
+
+		if err = c.Save(messages); err != nil {
+			// Spilled messages are read again on the next attempt
+			if file != "" {
+				c.releaseMessageContexts(messages)
+			}
+			c.backOffRetries()
+			return true, err
+		}
+
+		if file != "" {
+			c.popSpilledBatch(file)
+		} else {
+			queue.lock.Lock()
+			queue.batches = queue.batches[1:]
+			queue.size -= len(messages)
+			queue.lock.Unlock()
+		}
+	}
+}
+
+func (c *ElasticSearchLogger) popSpilledBatch(file string) {
+	os.Remove(file)
+
+	c.retries.lock.Lock()
+	defer c.retries.lock.Unlock()
+
+	if len(c.retries.files) > 0 && c.retries.files[0] == file {
+		c.retries.files = c.retries.files[1:]
+	}
+}
+
+// backOffRetries postpones the next retry attempt.
+func (c *ElasticSearchLogger) backOffRetries() {
+	queue := c.retries
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	if queue.backoff == 0 {
+		queue.backoff = time.Duration(c.retryQueueBackoff) * time.Millisecond
+	} else {
+		queue.backoff *= 2
+	}
+	if max := time.Duration(c.retryQueueMaxBackoff) * time.Millisecond; queue.backoff > max {
+		queue.backoff = max
+	}
+	queue.nextAttempt = c.clock.Now().Add(queue.backoff)
+}
+
+// dumpWithRetryQueue saves cached messages after the queued batches. While queued batches
+// are pending, new messages are queued behind them to keep the order of messages.
+func (c *ElasticSearchLogger) dumpWithRetryQueue(messages []*clog.LogMessage) error {
+	pending, err := c.retryQueued(false)
+	if pending {
+		c.enqueueRetry(messages)
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	failed, err := c.saveWithPriority(messages)
+	if err != nil {
+		c.enqueueRetry(failed)
+		c.backOffRetries()
+	}
+	return err
+}
+
+// closeRetryQueue makes the last attempt to save queued and cached messages.
+// Messages that still fail are spilled to disk to be retried after a restart.
+func (c *ElasticSearchLogger) closeRetryQueue() error {
+	c.Lock.Lock()
+	messages := c.Cache
+	c.Cache = []*clog.LogMessage{}
+	c.Lock.Unlock()
+
+	pending, err := c.retryQueued(true)
+	if !pending {
+		if len(messages) == 0 {
+			return nil
+		}
+		if messages, err = c.saveWithPriority(messages); err == nil {
+			return nil
+		}
+	}
+
+	c.enqueueRetry(messages)
+	if c.retrySpill != DiskSpill {
+		return err
+	}
+
+	queue := c.retries
+	queue.lock.Lock()
+	batches := queue.batches
+	queue.batches = nil
+	queue.size = 0
+	queue.lock.Unlock()
+
+	for i, batch := range batches {
+		file, spillErr := c.spillBatch(batch)
+		queue.lock.Lock()
+		if spillErr != nil {
+			// Batches that were not spilled stay in the queue
+			for _, rest := range batches[i:] {
+				queue.size += len(rest)
+			}
+			queue.batches = append(batches[i:], queue.batches...)
+			queue.lock.Unlock()
+			return spillErr
+		}
+		queue.files = append(queue.files, file)
+		queue.lock.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// PutSearchTemplate method stores a mustache search template in the cluster,
+// so complex queries can be maintained in one place and invoked by id from several services.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  an id of the template
+//   - source interface{}  a mustache template of the search request as a string or a map
+// Returns error or nil if the template was stored.
+func (c *ElasticSearchLogReader) PutSearchTemplate(correlationId string, id string, source interface{}) error {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return cerr.NewBadRequestError(correlationId, "NO_TEMPLATE", "Search template source is not set").
+			WithDetails("id", id)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang":   "mustache",
+			"source": source,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.PutScript(id, bytes.NewReader(body))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_PUT_TEMPLATE",
+			"Failure storing search template "+id).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// DeleteSearchTemplate method removes a stored search template.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  an id of the template
+// Returns error or nil if the template was removed or did not exist.
+func (c *ElasticSearchLogReader) DeleteSearchTemplate(correlationId string, id string) error {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.DeleteScript(id)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_DELETE_TEMPLATE",
+			"Failure deleting search template "+id).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil
+	}
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// SearchTemplate method retrieves log messages with a stored search template.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - id string  an id of the template
+//   - params map[string]interface{}  values of the template parameters
+// Returns found messages in the order of hits or error if the search failed.
+func (c *ElasticSearchLogReader) SearchTemplate(correlationId string, id string,
+	params map[string]interface{}) ([]*clog.LogMessage, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":     id,
+		"params": params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := c.searchIndex()
+	resp, err := client.SearchTemplate(
+		bytes.NewReader(body),
+		client.SearchTemplate.WithIndex(index),
+		client.SearchTemplate.WithIgnoreUnavailable(true),
+		client.SearchTemplate.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to search messages with template %s in ElasticSearch index %s", id, index)
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure searching log messages").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to search messages with template %s in ElasticSearch index %s", id, index)
+		return nil, appErr
+	}
+
+	var result searchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	fields := newLogDocumentFields(c.naming, c.schema)
+	messages := make([]*clog.LogMessage, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		messages = append(messages, fields.parseMessage(hit.Source))
+	}
+	return messages, nil
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+func (c *ElasticSearchLogger) configureSnapshotLifecycle(config *cconf.ConfigParams) error {
+	if c.slmPolicy != "" && c.slmRepository == "" {
+		return cerr.NewConfigError("", "NO_SLM_REPOSITORY",
+			"options.slm_repository must be set to create snapshot lifecycle policy").
+			WithDetails("option", "slm_repository")
+	}
+	return nil
+}
+
+// composeSnapshotPolicy creates SLM policy that takes snapshots of the logger indices
+// into slm_repository on slm_schedule and removes them after slm_expire_after.
+// Indices of other shippers and the alerts index, which only mirrors errors, are not included.
+func (c *ElasticSearchLogger) composeSnapshotPolicy() map[string]interface{} {
+	policy := map[string]interface{}{
+		"schedule":   c.slmSchedule,
+		"name":       "<" + c.index + "-snapshot-{now/d}>",
+		"repository": c.slmRepository,
+		"config": map[string]interface{}{
+			"indices":              logIndices(c.index, c.alertsIndex),
+			"ignore_unavailable":   true,
+			"include_global_state": false,
+		},
+	}
+
+	retention := map[string]interface{}{}
+	if c.slmExpireAfter != "" {
+		retention["expire_after"] = c.slmExpireAfter
+	}
+	if c.slmMinCount > 0 {
+		retention["min_count"] = c.slmMinCount
+	}
+	if c.slmMaxCount > 0 {
+		retention["max_count"] = c.slmMaxCount
+	}
+	if len(retention) > 0 {
+		policy["retention"] = retention
+	}
+	return policy
+}
+
+// createSnapshotPolicy creates or updates SLM policy when slm_policy is set.
+func (c *ElasticSearchLogger) createSnapshotPolicy(correlationId string) error {
+	if c.slmPolicy == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(c.composeSnapshotPolicy())
+	if err != nil {
+		return err
+	}
+
+	client := c.getClient()
+	if client == nil {
+		c.logger.Warn(correlationId, "SLM policy %s is not updated by a custom ElasticSearch client", c.slmPolicy)
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := client.SlmPutLifecycle(c.slmPolicy,
+		client.SlmPutLifecycle.WithBody(bytes.NewReader(body)),
+	)
+	c.traceRequest(correlationId, "put snapshot lifecycle", c.slmPolicy, start, len(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
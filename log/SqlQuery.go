@@ -0,0 +1,156 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+)
+
+// SqlColumn describes a column returned by an ES SQL statement.
+type SqlColumn struct {
+	// Name of the column
+	Name string `json:"name"`
+	// ElasticSearch type of the column, for instance keyword, long or datetime
+	Type string `json:"type"`
+}
+
+// SqlResult is a page of rows returned by an ES SQL statement.
+type SqlResult struct {
+	// Columns of the rows
+	Columns []SqlColumn
+	// Rows as maps of column names to values
+	Rows []map[string]interface{}
+	// Cursor of the next page or empty string if all rows were returned
+	Cursor string
+}
+
+// sqlResponse is a decoded response of ElasticSearch SQL API.
+type sqlResponse struct {
+	Columns []SqlColumn     `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+	Cursor  string          `json:"cursor"`
+}
+
+// QuerySql method executes an ES SQL statement, which is often simpler than aggregations
+// for reports over log indices. Log indices are referenced in the statement, for instance
+// SELECT source, COUNT(*) AS errors FROM "log*" WHERE level <= ? GROUP BY source.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - statement string  an ES SQL statement with ? placeholders
+//   - params []interface{}  (optional) values of the placeholders
+//   - fetchSize int  maximum number of rows per page, the server default is used when it is not positive
+// Returns the first page of rows or error if the statement failed. Cursor of the result
+// is set when more rows are available and must be passed to NextSqlPage or CloseSqlCursor.
+func (c *ElasticSearchLogReader) QuerySql(correlationId string, statement string, params []interface{},
+	fetchSize int) (*SqlResult, error) {
+	if statement == "" {
+		return nil, cerr.NewBadRequestError(correlationId, "NO_STATEMENT", "SQL statement is not set")
+	}
+
+	request := map[string]interface{}{"query": statement}
+	if len(params) > 0 {
+		request["params"] = params
+	}
+	if fetchSize > 0 {
+		request["fetch_size"] = fetchSize
+	}
+	return c.executeSql(correlationId, request, nil)
+}
+
+// NextSqlPage method retrieves the next page of rows of an ES SQL statement.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - previous *SqlResult  the previous page with the cursor
+// Returns the next page of rows or error if the request failed.
+func (c *ElasticSearchLogReader) NextSqlPage(correlationId string, previous *SqlResult) (*SqlResult, error) {
+	if previous == nil || previous.Cursor == "" {
+		return nil, cerr.NewBadRequestError(correlationId, "NO_CURSOR", "SQL result has no more pages")
+	}
+	// Subsequent pages contain only rows, columns are taken from the first page
+	return c.executeSql(correlationId, map[string]interface{}{"cursor": previous.Cursor}, previous.Columns)
+}
+
+// CloseSqlCursor method releases resources of a cursor when remaining pages are not needed.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - cursor string  a cursor of the SQL result
+// Returns error or nil if the cursor was closed.
+func (c *ElasticSearchLogReader) CloseSqlCursor(correlationId string, cursor string) error {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+	if cursor == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"cursor": cursor})
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.SQL.ClearCursor(bytes.NewReader(body))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CLOSE_CURSOR",
+			"Failure closing SQL cursor").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func (c *ElasticSearchLogReader) executeSql(correlationId string, request map[string]interface{},
+	columns []SqlColumn) (*SqlResult, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.SQL.Query(bytes.NewReader(body), client.SQL.Query.WithFormat("json"))
+	if err != nil {
+		c.logger.Error(correlationId, err, "Failed to execute SQL statement in ElasticSearch")
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_SEARCH",
+			"Failure executing SQL statement").WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		c.logger.Error(correlationId, appErr, "Failed to execute SQL statement in ElasticSearch")
+		return nil, appErr
+	}
+
+	var result sqlResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Columns) > 0 {
+		columns = result.Columns
+	}
+
+	rows := make([]map[string]interface{}, 0, len(result.Rows))
+	for _, values := range result.Rows {
+		row := make(map[string]interface{}, len(columns))
+		for index, column := range columns {
+			if index < len(values) {
+				row[column.Name] = values[index]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return &SqlResult{
+		Columns: columns,
+		Rows:    rows,
+		Cursor:  result.Cursor,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Packages whose frames are skipped to start the stack at the code that called the logger
+var loggerPackages = []string{
+	"github.com/pip-services3-go/pip-services3-components-go/log.",
+	"github.com/pip-services3-go/pip-services3-elasticsearch-go/log.",
+}
+
+func isLoggerFrame(function string) bool {
+	for _, pkg := range loggerPackages {
+		if strings.HasPrefix(function, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerFrames returns stack frames of the code that called the logger.
+// Parameters:
+//   - skip int  number of additional frames to skip above the logger
+//   - depth int  maximum number of returned frames
+func callerFrames(skip int, depth int) []runtime.Frame {
+	pcs := make([]uintptr, depth+skip+16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	result := make([]runtime.Frame, 0, depth)
+	inLogger := true
+	for len(result) < depth {
+		frame, more := frames.Next()
+		if inLogger && isLoggerFrame(frame.Function) {
+			if !more {
+				break
+			}
+			continue
+		}
+		inLogger = false
+
+		if skip > 0 {
+			skip--
+		} else {
+			result = append(result, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return result
+}
+
+// captureStackTrace formats the stack of the code that called the logger
+// the same way as runtime/debug.Stack does.
+func captureStackTrace(depth int) string {
+	var builder strings.Builder
+	for _, frame := range callerFrames(0, depth) {
+		builder.WriteString(frame.Function)
+		builder.WriteString("\n\t")
+		builder.WriteString(frame.File)
+		builder.WriteString(":")
+		builder.WriteString(strconv.Itoa(frame.Line))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+ITraceContextProvider is an interface for components, usually tracers, that are able
+to resolve W3C trace context (trace and span ids) for a correlation id.
+When such component is referenced as *:tracer:*:*:1.0, ElasticSearchLogger uses it
+to populate trace.id and span.id fields of the indexed log messages.
+*/
+type ITraceContextProvider interface {
+	// GetTraceContext resolves trace and span ids for the given correlation id.
+	// Returns ok = false when trace context is not available.
+	GetTraceContext(correlationId string) (traceId string, spanId string, ok bool)
+}
+
+// Matches whole W3C traceparent header value: version-traceid-parentid-flags
+var traceparentRegex = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// isTraceparentSeparator checks if the rune separates traceparent from other parts of the correlation id.
+func isTraceparentSeparator(r rune) bool {
+	return r == ';' || r == ',' || r == ' '
+}
+
+// ParseTraceparent extracts trace and span ids from W3C traceparent value carried in the correlation id.
+// The correlation id may be the traceparent itself or contain it as a part separated by ";", "," or space,
+// for instance "abc;00-4bf9...-00f0...-01". Version ff and all-zero trace or parent ids are invalid.
+// Parameters:
+//   - correlationId string  transaction id to trace execution through call chain.
+// Returns trace id, span id and true if a valid traceparent was found.
+func ParseTraceparent(correlationId string) (traceId string, spanId string, ok bool) {
+	if correlationId == "" {
+		return "", "", false
+	}
+
+	for _, part := range strings.FieldsFunc(strings.ToLower(correlationId), isTraceparentSeparator) {
+		match := traceparentRegex.FindStringSubmatch(part)
+		if match == nil || match[1] == "ff" {
+			continue
+		}
+
+		traceId, spanId = match[2], match[3]
+		if strings.Trim(traceId, "0") == "" || strings.Trim(spanId, "0") == "" {
+			continue
+		}
+		return traceId, spanId, true
+	}
+
+	return "", "", false
+}
+
+// resolveTraceContext returns trace and span ids from the traceparent carried in the correlation id
+// or from the referenced tracers. Ids of tracers are lowercased like traceparent ids,
+// because trace.id and span.id are keywords searched in lower case.
+func (c *ElasticSearchLogger) resolveTraceContext(correlationId string) (traceId string, spanId string, ok bool) {
+	traceId, spanId, ok = ParseTraceparent(correlationId)
+	if ok {
+		return traceId, spanId, ok
+	}
+
+	for _, provider := range c.traceProviders {
+		traceId, spanId, ok = provider.GetTraceContext(correlationId)
+		if ok {
+			return strings.ToLower(traceId), strings.ToLower(spanId), ok
+		}
+	}
+
+	return "", "", false
+}
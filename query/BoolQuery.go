@@ -0,0 +1,116 @@
+package query
+
+// BoolQuery combines clauses that must, should or must not match documents.
+// Filter clauses must match but do not affect the score and can be cached.
+type BoolQuery struct {
+	must               []IQuery
+	filter             []IQuery
+	should             []IQuery
+	mustNot            []IQuery
+	minimumShouldMatch interface{}
+	boost              *float64
+}
+
+// NewBoolQuery method creates a new empty bool query.
+// Retruns *BoolQuery
+// pointer on new BoolQuery
+func NewBoolQuery() *BoolQuery {
+	c := BoolQuery{}
+	return &c
+}
+
+// Must method adds clauses that must match and contribute to the score.
+// Parameters:
+//   - queries ...IQuery  clauses to be added
+// Returns the same query to chain calls.
+func (c *BoolQuery) Must(queries ...IQuery) *BoolQuery {
+	c.must = append(c.must, queries...)
+	return c
+}
+
+// Filter method adds clauses that must match without contributing to the score.
+// Parameters:
+//   - queries ...IQuery  clauses to be added
+// Returns the same query to chain calls.
+func (c *BoolQuery) Filter(queries ...IQuery) *BoolQuery {
+	c.filter = append(c.filter, queries...)
+	return c
+}
+
+// Should method adds clauses that should match.
+// Parameters:
+//   - queries ...IQuery  clauses to be added
+// Returns the same query to chain calls.
+func (c *BoolQuery) Should(queries ...IQuery) *BoolQuery {
+	c.should = append(c.should, queries...)
+	return c
+}
+
+// MustNot method adds clauses that must not match.
+// Parameters:
+//   - queries ...IQuery  clauses to be added
+// Returns the same query to chain calls.
+func (c *BoolQuery) MustNot(queries ...IQuery) *BoolQuery {
+	c.mustNot = append(c.mustNot, queries...)
+	return c
+}
+
+// MinimumShouldMatch method sets the number or percentage of should clauses that must match.
+// Parameters:
+//   - value interface{}  a number like 2 or a string like "75%"
+// Returns the same query to chain calls.
+func (c *BoolQuery) MinimumShouldMatch(value interface{}) *BoolQuery {
+	c.minimumShouldMatch = value
+	return c
+}
+
+// Boost method sets the factor the score of matched documents is multiplied by.
+// Parameters:
+//   - boost float64  a boost factor
+// Returns the same query to chain calls.
+func (c *BoolQuery) Boost(boost float64) *BoolQuery {
+	c.boost = &boost
+	return c
+}
+
+// IsEmpty method checks if the query has no clauses and matches all documents.
+func (c *BoolQuery) IsEmpty() bool {
+	return len(c.must) == 0 && len(c.filter) == 0 && len(c.should) == 0 && len(c.mustNot) == 0
+}
+
+// Validate method checks all clauses of the query.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error of the first invalid clause or nil if the query is valid.
+func (c *BoolQuery) Validate(correlationId string) error {
+	for _, queries := range [][]IQuery{c.must, c.filter, c.should, c.mustNot} {
+		if err := validateAll(correlationId, queries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *BoolQuery) Source() map[string]interface{} {
+	params := map[string]interface{}{}
+	if len(c.must) > 0 {
+		params["must"] = sources(c.must)
+	}
+	if len(c.filter) > 0 {
+		params["filter"] = sources(c.filter)
+	}
+	if len(c.should) > 0 {
+		params["should"] = sources(c.should)
+	}
+	if len(c.mustNot) > 0 {
+		params["must_not"] = sources(c.mustNot)
+	}
+	if c.minimumShouldMatch != nil {
+		params["minimum_should_match"] = c.minimumShouldMatch
+	}
+	if c.boost != nil {
+		params["boost"] = *c.boost
+	}
+	return map[string]interface{}{"bool": params}
+}
@@ -0,0 +1,23 @@
+package query
+
+// ExistsQuery matches documents that have an indexed value in the field.
+type ExistsQuery struct {
+	field string
+}
+
+// NewExistsQuery method creates a new exists query.
+// Parameters:
+//   - field string  a name of the field
+// Retruns *ExistsQuery
+// pointer on new ExistsQuery
+func NewExistsQuery(field string) *ExistsQuery {
+	c := ExistsQuery{field: field}
+	return &c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *ExistsQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"exists": map[string]interface{}{"field": c.field},
+	}
+}
@@ -0,0 +1,59 @@
+package query
+
+// FuzzyQuery matches documents with terms similar to the value within the edit distance,
+// for "did you mean" searches of misspelled keywords.
+type FuzzyQuery struct {
+	field   string
+	value   string
+	options map[string]interface{}
+}
+
+// NewFuzzyQuery method creates a new fuzzy query with AUTO fuzziness.
+// Parameters:
+//   - field string  a name of the field
+//   - value string  a term to match approximately
+// Retruns *FuzzyQuery
+// pointer on new FuzzyQuery
+func NewFuzzyQuery(field string, value string) *FuzzyQuery {
+	c := FuzzyQuery{
+		field:   field,
+		value:   value,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// Fuzziness method sets the maximum edit distance: 0, 1, 2 or "AUTO" (default).
+// Parameters:
+//   - fuzziness interface{}  a number of allowed edits or "AUTO"
+// Returns the same query to chain calls.
+func (c *FuzzyQuery) Fuzziness(fuzziness interface{}) *FuzzyQuery {
+	c.options["fuzziness"] = fuzziness
+	return c
+}
+
+// PrefixLength method sets the number of leading characters that must match exactly.
+// Longer prefixes considerably reduce the number of examined terms.
+// Parameters:
+//   - length int  a number of characters
+// Returns the same query to chain calls.
+func (c *FuzzyQuery) PrefixLength(length int) *FuzzyQuery {
+	c.options["prefix_length"] = length
+	return c
+}
+
+// MaxExpansions method sets the maximum number of variations the query is expanded to (default: 50).
+// Parameters:
+//   - count int  a number of variations
+// Returns the same query to chain calls.
+func (c *FuzzyQuery) MaxExpansions(count int) *FuzzyQuery {
+	c.options["max_expansions"] = count
+	return c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *FuzzyQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"fuzzy": map[string]interface{}{c.field: withOptions(c.value, "value", c.options)},
+	}
+}
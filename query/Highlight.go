@@ -0,0 +1,91 @@
+package query
+
+// Highlight configures highlighting of matched terms in search hits.
+// Fragments of highlighted fields are returned with every hit in the "highlight" section.
+type Highlight struct {
+	fields  map[string]map[string]interface{}
+	options map[string]interface{}
+}
+
+// NewHighlight method creates a new highlight configuration for the fields.
+// Parameters:
+//   - fields ...string  names of the fields to be highlighted, patterns like "error.*" are allowed
+// Retruns *Highlight
+// pointer on new Highlight
+func NewHighlight(fields ...string) *Highlight {
+	c := Highlight{
+		fields:  map[string]map[string]interface{}{},
+		options: map[string]interface{}{},
+	}
+	for _, field := range fields {
+		c.fields[field] = map[string]interface{}{}
+	}
+	return &c
+}
+
+// Field method adds a field with its own fragment parameters.
+// Parameters:
+//   - field string  a name of the field
+//   - fragmentSize int  maximum length of fragments in characters or 0 to use the default
+//   - numberOfFragments int  maximum number of fragments, 0 returns the whole field highlighted
+// Returns the same configuration to chain calls.
+func (c *Highlight) Field(field string, fragmentSize int, numberOfFragments int) *Highlight {
+	params := map[string]interface{}{"number_of_fragments": numberOfFragments}
+	if fragmentSize > 0 {
+		params["fragment_size"] = fragmentSize
+	}
+	c.fields[field] = params
+	return c
+}
+
+// Tags method sets the tags inserted before and after highlighted terms (default: <em> and </em>).
+// Parameters:
+//   - preTag string  a tag inserted before highlighted terms
+//   - postTag string  a tag inserted after highlighted terms
+// Returns the same configuration to chain calls.
+func (c *Highlight) Tags(preTag string, postTag string) *Highlight {
+	c.options["pre_tags"] = []string{preTag}
+	c.options["post_tags"] = []string{postTag}
+	return c
+}
+
+// FragmentSize method sets the default maximum length of fragments in characters (default: 100).
+// Parameters:
+//   - size int  a fragment length
+// Returns the same configuration to chain calls.
+func (c *Highlight) FragmentSize(size int) *Highlight {
+	c.options["fragment_size"] = size
+	return c
+}
+
+// NumberOfFragments method sets the default maximum number of fragments per field (default: 5).
+// Parameters:
+//   - count int  a number of fragments, 0 returns whole fields highlighted
+// Returns the same configuration to chain calls.
+func (c *Highlight) NumberOfFragments(count int) *Highlight {
+	c.options["number_of_fragments"] = count
+	return c
+}
+
+// RequireFieldMatch method sets if only fields matched by the query are highlighted (default: true).
+// Parameters:
+//   - value bool  false to highlight terms in all configured fields
+// Returns the same configuration to chain calls.
+func (c *Highlight) RequireFieldMatch(value bool) *Highlight {
+	c.options["require_field_match"] = value
+	return c
+}
+
+// Source method returns the configuration as a map serializable into JSON.
+func (c *Highlight) Source() map[string]interface{} {
+	fields := map[string]interface{}{}
+	for field, params := range c.fields {
+		fields[field] = params
+	}
+
+	source := map[string]interface{}{"fields": fields}
+	for key, option := range c.options {
+		source[key] = option
+	}
+	return source
+}
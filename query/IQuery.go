@@ -0,0 +1,107 @@
+package query
+
+import (
+	"encoding/json"
+)
+
+/*
+IQuery is a clause of ElasticSearch Query DSL created by the query builders of this package.
+Clauses are combined into compound queries and serialized into JSON request bodies.
+
+Example:
+
+    query := NewBoolQuery().
+        Filter(NewTermQuery("source", "orders"), NewRangeQuery("time").Gte("now-1h")).
+        Must(NewMatchQuery("message", "payment failed"))
+
+    body, err := json.Marshal(map[string]interface{}{"query": query.Source()})
+*/
+type IQuery interface {
+	// Source returns the clause as a map serializable into JSON.
+	Source() map[string]interface{}
+}
+
+// IValidator is implemented by queries that can be too expensive or invalid,
+// like wildcard queries with leading wildcards, and by compound queries.
+type IValidator interface {
+	// Validate checks the query and returns error if it must not be sent.
+	Validate(correlationId string) error
+}
+
+// Validate method checks the query and all its clauses.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - query IQuery  a query to be checked
+// Returns error of the first invalid clause or nil if the query is valid.
+func Validate(correlationId string, query IQuery) error {
+	if validator, ok := query.(IValidator); ok {
+		return validator.Validate(correlationId)
+	}
+	return nil
+}
+
+// validateAll checks the clauses of a compound query.
+func validateAll(correlationId string, queries []IQuery) error {
+	for _, query := range queries {
+		if query == nil {
+			continue
+		}
+		if err := Validate(correlationId, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshal method validates the query and serializes it into a JSON query clause.
+// Parameters:
+//   - query IQuery  a query to be serialized
+// Returns JSON bytes or error if the query is invalid or contains values that cannot be serialized.
+func Marshal(query IQuery) ([]byte, error) {
+	if err := Validate("", query); err != nil {
+		return nil, err
+	}
+	return json.Marshal(query.Source())
+}
+
+// sources converts the queries into their sources.
+func sources(queries []IQuery) []interface{} {
+	result := make([]interface{}, 0, len(queries))
+	for _, query := range queries {
+		if query != nil {
+			result = append(result, query.Source())
+		}
+	}
+	return result
+}
+
+// withOptions adds the options to the parameters of a field query
+// or uses the value itself when there are no options.
+func withOptions(value interface{}, valueKey string, options map[string]interface{}) interface{} {
+	if len(options) == 0 {
+		return value
+	}
+
+	params := map[string]interface{}{valueKey: value}
+	for key, option := range options {
+		params[key] = option
+	}
+	return params
+}
+
+// rawQuery wraps a clause composed without the builders.
+type rawQuery map[string]interface{}
+
+// Raw method wraps a query clause composed as a map, for instance received from a client,
+// to combine it with clauses created by the builders.
+// Parameters:
+//   - source map[string]interface{}  a query clause
+// Returns the clause as IQuery.
+func Raw(source map[string]interface{}) IQuery {
+	return rawQuery(source)
+}
+
+// Source returns the wrapped clause.
+func (c rawQuery) Source() map[string]interface{} {
+	return c
+}
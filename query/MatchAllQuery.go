@@ -0,0 +1,17 @@
+package query
+
+// MatchAllQuery matches all documents.
+type MatchAllQuery struct{}
+
+// NewMatchAllQuery method creates a new match all query.
+// Retruns *MatchAllQuery
+// pointer on new MatchAllQuery
+func NewMatchAllQuery() *MatchAllQuery {
+	c := MatchAllQuery{}
+	return &c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *MatchAllQuery) Source() map[string]interface{} {
+	return map[string]interface{}{"match_all": map[string]interface{}{}}
+}
@@ -0,0 +1,75 @@
+package query
+
+// MatchQuery matches documents with analyzed text fields containing the terms of the text.
+type MatchQuery struct {
+	field   string
+	text    interface{}
+	options map[string]interface{}
+}
+
+// NewMatchQuery method creates a new match query.
+// Parameters:
+//   - field string  a name of the field
+//   - text interface{}  a text, number or date to match
+// Retruns *MatchQuery
+// pointer on new MatchQuery
+func NewMatchQuery(field string, text interface{}) *MatchQuery {
+	c := MatchQuery{
+		field:   field,
+		text:    text,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// Operator method sets how terms of the text are combined: "or" (default) or "and".
+// Parameters:
+//   - operator string  a boolean operator
+// Returns the same query to chain calls.
+func (c *MatchQuery) Operator(operator string) *MatchQuery {
+	c.options["operator"] = operator
+	return c
+}
+
+// MinimumShouldMatch method sets the number or percentage of terms that must match.
+// Parameters:
+//   - value interface{}  a number like 2 or a string like "75%"
+// Returns the same query to chain calls.
+func (c *MatchQuery) MinimumShouldMatch(value interface{}) *MatchQuery {
+	c.options["minimum_should_match"] = value
+	return c
+}
+
+// Analyzer method sets the analyzer used to split the text into terms.
+// Parameters:
+//   - analyzer string  a name of the analyzer
+// Returns the same query to chain calls.
+func (c *MatchQuery) Analyzer(analyzer string) *MatchQuery {
+	c.options["analyzer"] = analyzer
+	return c
+}
+
+// Fuzziness method allows terms of the text to match terms with typos.
+// Parameters:
+//   - fuzziness interface{}  a number of allowed edits or "AUTO"
+// Returns the same query to chain calls.
+func (c *MatchQuery) Fuzziness(fuzziness interface{}) *MatchQuery {
+	c.options["fuzziness"] = fuzziness
+	return c
+}
+
+// Boost method sets the factor the score of matched documents is multiplied by.
+// Parameters:
+//   - boost float64  a boost factor
+// Returns the same query to chain calls.
+func (c *MatchQuery) Boost(boost float64) *MatchQuery {
+	c.options["boost"] = boost
+	return c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *MatchQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"match": map[string]interface{}{c.field: withOptions(c.text, "query", c.options)},
+	}
+}
@@ -0,0 +1,55 @@
+package query
+
+// NestedQuery matches documents with nested objects at the path that match the query,
+// so conditions are checked against the same nested object.
+type NestedQuery struct {
+	path      string
+	query     IQuery
+	scoreMode string
+}
+
+// NewNestedQuery method creates a new nested query.
+// Parameters:
+//   - path string  a path of the nested field
+//   - query IQuery  a query nested objects shall match, fields are referenced with the full path
+// Retruns *NestedQuery
+// pointer on new NestedQuery
+func NewNestedQuery(path string, query IQuery) *NestedQuery {
+	c := NestedQuery{
+		path:  path,
+		query: query,
+	}
+	return &c
+}
+
+// ScoreMode method sets how scores of matched nested objects are combined:
+// "avg" (default), "max", "min", "sum" or "none".
+// Parameters:
+//   - mode string  a score mode
+// Returns the same query to chain calls.
+func (c *NestedQuery) ScoreMode(mode string) *NestedQuery {
+	c.scoreMode = mode
+	return c
+}
+
+// Validate method checks the nested query.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error of the first invalid clause or nil if the query is valid.
+func (c *NestedQuery) Validate(correlationId string) error {
+	return validateAll(correlationId, []IQuery{c.query})
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *NestedQuery) Source() map[string]interface{} {
+	params := map[string]interface{}{"path": c.path}
+	if c.query != nil {
+		params["query"] = c.query.Source()
+	} else {
+		params["query"] = NewMatchAllQuery().Source()
+	}
+	if c.scoreMode != "" {
+		params["score_mode"] = c.scoreMode
+	}
+	return map[string]interface{}{"nested": params}
+}
@@ -0,0 +1,39 @@
+package query
+
+// PrefixQuery matches documents with keyword values that start with the prefix.
+type PrefixQuery struct {
+	field   string
+	prefix  string
+	options map[string]interface{}
+}
+
+// NewPrefixQuery method creates a new prefix query.
+// Parameters:
+//   - field string  a name of the field
+//   - prefix string  a prefix values shall start with
+// Retruns *PrefixQuery
+// pointer on new PrefixQuery
+func NewPrefixQuery(field string, prefix string) *PrefixQuery {
+	c := PrefixQuery{
+		field:   field,
+		prefix:  prefix,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// CaseInsensitive method allows to match values ignoring case.
+// Parameters:
+//   - value bool  true to ignore case
+// Returns the same query to chain calls.
+func (c *PrefixQuery) CaseInsensitive(value bool) *PrefixQuery {
+	c.options["case_insensitive"] = value
+	return c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *PrefixQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"prefix": map[string]interface{}{c.field: withOptions(c.prefix, "value", c.options)},
+	}
+}
@@ -0,0 +1,82 @@
+package query
+
+// RangeQuery matches documents with field values within a range.
+// Dates can be set as strings with date math, for instance "now-1h".
+type RangeQuery struct {
+	field  string
+	params map[string]interface{}
+}
+
+// NewRangeQuery method creates a new range query without bounds.
+// Parameters:
+//   - field string  a name of the field
+// Retruns *RangeQuery
+// pointer on new RangeQuery
+func NewRangeQuery(field string) *RangeQuery {
+	c := RangeQuery{
+		field:  field,
+		params: map[string]interface{}{},
+	}
+	return &c
+}
+
+// Gt method sets the exclusive lower bound.
+// Parameters:
+//   - value interface{}  a bound value
+// Returns the same query to chain calls.
+func (c *RangeQuery) Gt(value interface{}) *RangeQuery {
+	c.params["gt"] = value
+	return c
+}
+
+// Gte method sets the inclusive lower bound.
+// Parameters:
+//   - value interface{}  a bound value
+// Returns the same query to chain calls.
+func (c *RangeQuery) Gte(value interface{}) *RangeQuery {
+	c.params["gte"] = value
+	return c
+}
+
+// Lt method sets the exclusive upper bound.
+// Parameters:
+//   - value interface{}  a bound value
+// Returns the same query to chain calls.
+func (c *RangeQuery) Lt(value interface{}) *RangeQuery {
+	c.params["lt"] = value
+	return c
+}
+
+// Lte method sets the inclusive upper bound.
+// Parameters:
+//   - value interface{}  a bound value
+// Returns the same query to chain calls.
+func (c *RangeQuery) Lte(value interface{}) *RangeQuery {
+	c.params["lte"] = value
+	return c
+}
+
+// Format method sets the date format used to parse date bounds.
+// Parameters:
+//   - format string  a date format, for instance "yyyy-MM-dd"
+// Returns the same query to chain calls.
+func (c *RangeQuery) Format(format string) *RangeQuery {
+	c.params["format"] = format
+	return c
+}
+
+// TimeZone method sets the time zone used to convert date bounds to UTC.
+// Parameters:
+//   - timeZone string  a UTC offset like "+01:00" or a time zone id like "Europe/Berlin"
+// Returns the same query to chain calls.
+func (c *RangeQuery) TimeZone(timeZone string) *RangeQuery {
+	c.params["time_zone"] = timeZone
+	return c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *RangeQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"range": map[string]interface{}{c.field: c.params},
+	}
+}
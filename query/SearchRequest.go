@@ -0,0 +1,194 @@
+package query
+
+// SearchRequest composes the body of a search request with a query and parameters
+// of returned hits and aggregations.
+type SearchRequest struct {
+	query           IQuery
+	size            *int
+	sort            []interface{}
+	fields          []string
+	runtimeMappings map[string]interface{}
+	scriptFields    map[string]interface{}
+	collapse        map[string]interface{}
+	aggregations    map[string]interface{}
+}
+
+// NewSearchRequest method creates a new search request that matches all documents.
+// Retruns *SearchRequest
+// pointer on new SearchRequest
+func NewSearchRequest() *SearchRequest {
+	c := SearchRequest{
+		sort:            []interface{}{},
+		fields:          []string{},
+		runtimeMappings: map[string]interface{}{},
+		scriptFields:    map[string]interface{}{},
+		aggregations:    map[string]interface{}{},
+	}
+	return &c
+}
+
+// Query method sets the query documents shall match.
+// Parameters:
+//   - query IQuery  a query
+// Returns the same request to chain calls.
+func (c *SearchRequest) Query(query IQuery) *SearchRequest {
+	c.query = query
+	return c
+}
+
+// Size method sets the maximum number of returned hits.
+// Parameters:
+//   - size int  a number of hits, 0 returns only aggregations
+// Returns the same request to chain calls.
+func (c *SearchRequest) Size(size int) *SearchRequest {
+	c.size = &size
+	return c
+}
+
+// Sort method adds a sort field.
+// Parameters:
+//   - field string  a name of the field
+//   - ascending bool  true to sort in ascending and false in descending order
+// Returns the same request to chain calls.
+func (c *SearchRequest) Sort(field string, ascending bool) *SearchRequest {
+	order := "desc"
+	if ascending {
+		order = "asc"
+	}
+	c.sort = append(c.sort, map[string]interface{}{field: map[string]interface{}{"order": order}})
+	return c
+}
+
+// Fields method adds fields returned by every hit in addition to the source,
+// including runtime fields defined in the request.
+// Parameters:
+//   - fields ...string  names or patterns of the fields
+// Returns the same request to chain calls.
+func (c *SearchRequest) Fields(fields ...string) *SearchRequest {
+	c.fields = append(c.fields, fields...)
+	return c
+}
+
+// RuntimeField method defines a field computed at search time by a painless script,
+// which can be queried, sorted, aggregated and returned like a mapped field without reindexing.
+// It requires ElasticSearch 7.11 or newer.
+// Parameters:
+//   - name string  a name of the field
+//   - fieldType string  a type of the field: keyword, long, double, date, boolean, ip or geo_point
+//   - script string  a painless script that calls emit() with values of the field
+// Returns the same request to chain calls.
+func (c *SearchRequest) RuntimeField(name string, fieldType string, script string) *SearchRequest {
+	c.runtimeMappings[name] = map[string]interface{}{
+		"type":   fieldType,
+		"script": map[string]interface{}{"source": script},
+	}
+	return c
+}
+
+// ScriptField method adds a value computed by a painless script for every returned hit,
+// for instance a duration converted into seconds. Values are returned with other fields of hits.
+// Parameters:
+//   - name string  a name of the field
+//   - script string  a painless script that returns the value
+//   - params map[string]interface{}  (optional) parameters available in the script as params
+// Returns the same request to chain calls.
+func (c *SearchRequest) ScriptField(name string, script string, params map[string]interface{}) *SearchRequest {
+	definition := map[string]interface{}{"source": script}
+	if len(params) > 0 {
+		definition["params"] = params
+	}
+	c.scriptFields[name] = map[string]interface{}{"script": definition}
+	return c
+}
+
+// Collapse method deduplicates hits by the value of a keyword or numeric field,
+// only the top hit by sort order is returned for every value.
+// Parameters:
+//   - field string  a name of the field, it must have doc values
+// Returns the same request to chain calls.
+func (c *SearchRequest) Collapse(field string) *SearchRequest {
+	c.collapse = map[string]interface{}{"field": field}
+	return c
+}
+
+// CollapseInnerHits method returns other hits with the same value of the collapse field
+// with every top hit, for instance recent messages of every source.
+// Collapse must be set before.
+// Parameters:
+//   - name string  a name the inner hits are returned under
+//   - size int  maximum number of inner hits per top hit
+//   - sortField string  (optional) a field inner hits are sorted by in descending order
+// Returns the same request to chain calls.
+func (c *SearchRequest) CollapseInnerHits(name string, size int, sortField string) *SearchRequest {
+	if c.collapse == nil {
+		return c
+	}
+
+	innerHits := map[string]interface{}{"name": name, "size": size}
+	if sortField != "" {
+		innerHits["sort"] = []interface{}{
+			map[string]interface{}{sortField: map[string]interface{}{"order": "desc"}},
+		}
+	}
+	c.collapse["inner_hits"] = innerHits
+	return c
+}
+
+// Aggregation method adds an aggregation written in Query DSL.
+// Parameters:
+//   - name string  a name the results are returned under
+//   - aggregation map[string]interface{}  an aggregation, for instance {"terms": {"field": "source"}}
+// Returns the same request to chain calls.
+func (c *SearchRequest) Aggregation(name string, aggregation map[string]interface{}) *SearchRequest {
+	c.aggregations[name] = aggregation
+	return c
+}
+
+// GetSize method returns the maximum number of returned hits.
+// Returns the size and false if it was not set.
+func (c *SearchRequest) GetSize() (int, bool) {
+	if c.size == nil {
+		return 0, false
+	}
+	return *c.size, true
+}
+
+// Validate method checks the query of the request.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error of the first invalid clause or nil if the request is valid.
+func (c *SearchRequest) Validate(correlationId string) error {
+	return validateAll(correlationId, []IQuery{c.query})
+}
+
+// Source method returns the request body as a map serializable into JSON.
+func (c *SearchRequest) Source() map[string]interface{} {
+	source := map[string]interface{}{}
+	if c.query != nil {
+		source["query"] = c.query.Source()
+	}
+	if c.size != nil {
+		source["size"] = *c.size
+	}
+	if len(c.sort) > 0 {
+		source["sort"] = c.sort
+	}
+	if len(c.fields) > 0 {
+		source["fields"] = c.fields
+	}
+	if len(c.runtimeMappings) > 0 {
+		source["runtime_mappings"] = c.runtimeMappings
+	}
+	if len(c.scriptFields) > 0 {
+		source["script_fields"] = c.scriptFields
+		// Script fields replace the source unless it is requested explicitly
+		source["_source"] = true
+	}
+	if c.collapse != nil {
+		source["collapse"] = c.collapse
+	}
+	if len(c.aggregations) > 0 {
+		source["aggs"] = c.aggregations
+	}
+	return source
+}
@@ -0,0 +1,161 @@
+package query
+
+// ISuggester is a suggester of ElasticSearch suggest API created by the builders of this package.
+type ISuggester interface {
+	// Name returns the name the suggestions are returned under.
+	Name() string
+	// Source returns the suggester as a map serializable into JSON.
+	Source() map[string]interface{}
+}
+
+// TermSuggester suggests terms of a field similar to the terms of the text,
+// for "did you mean" hints on misspelled searches.
+type TermSuggester struct {
+	name    string
+	text    string
+	field   string
+	options map[string]interface{}
+}
+
+// NewTermSuggester method creates a new term suggester.
+// Parameters:
+//   - name string  a name the suggestions are returned under
+//   - text string  a text to suggest corrections for
+//   - field string  a name of the field to take terms from
+// Retruns *TermSuggester
+// pointer on new TermSuggester
+func NewTermSuggester(name string, text string, field string) *TermSuggester {
+	c := TermSuggester{
+		name:    name,
+		text:    text,
+		field:   field,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// Size method sets the maximum number of suggestions per term (default: 5).
+// Parameters:
+//   - size int  a number of suggestions
+// Returns the same suggester to chain calls.
+func (c *TermSuggester) Size(size int) *TermSuggester {
+	c.options["size"] = size
+	return c
+}
+
+// SuggestMode method sets when suggestions are returned: "missing" (default) for terms
+// not found in the index, "popular" for terms found in fewer documents or "always".
+// Parameters:
+//   - mode string  a suggest mode
+// Returns the same suggester to chain calls.
+func (c *TermSuggester) SuggestMode(mode string) *TermSuggester {
+	c.options["suggest_mode"] = mode
+	return c
+}
+
+// Name method returns the name the suggestions are returned under.
+func (c *TermSuggester) Name() string {
+	return c.name
+}
+
+// Source method returns the suggester as a map serializable into JSON.
+func (c *TermSuggester) Source() map[string]interface{} {
+	params := map[string]interface{}{"field": c.field}
+	for key, option := range c.options {
+		params[key] = option
+	}
+	return map[string]interface{}{"text": c.text, "term": params}
+}
+
+// CompletionSuggester suggests values of a completion field that start with the prefix,
+// for type-ahead inputs. The field must be mapped with CompletionMapping.
+type CompletionSuggester struct {
+	name    string
+	prefix  string
+	field   string
+	options map[string]interface{}
+}
+
+// NewCompletionSuggester method creates a new completion suggester.
+// Parameters:
+//   - name string  a name the suggestions are returned under
+//   - prefix string  a typed prefix to complete
+//   - field string  a name of the completion field
+// Retruns *CompletionSuggester
+// pointer on new CompletionSuggester
+func NewCompletionSuggester(name string, prefix string, field string) *CompletionSuggester {
+	c := CompletionSuggester{
+		name:    name,
+		prefix:  prefix,
+		field:   field,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// Size method sets the maximum number of suggestions (default: 5).
+// Parameters:
+//   - size int  a number of suggestions
+// Returns the same suggester to chain calls.
+func (c *CompletionSuggester) Size(size int) *CompletionSuggester {
+	c.options["size"] = size
+	return c
+}
+
+// SkipDuplicates method removes suggestions with the same text.
+// Parameters:
+//   - value bool  true to skip duplicates
+// Returns the same suggester to chain calls.
+func (c *CompletionSuggester) SkipDuplicates(value bool) *CompletionSuggester {
+	c.options["skip_duplicates"] = value
+	return c
+}
+
+// Fuzzy method allows suggestions for prefixes with typos.
+// Parameters:
+//   - fuzziness interface{}  a number of allowed edits or "AUTO"
+// Returns the same suggester to chain calls.
+func (c *CompletionSuggester) Fuzzy(fuzziness interface{}) *CompletionSuggester {
+	c.options["fuzzy"] = map[string]interface{}{"fuzziness": fuzziness}
+	return c
+}
+
+// Name method returns the name the suggestions are returned under.
+func (c *CompletionSuggester) Name() string {
+	return c.name
+}
+
+// Source method returns the suggester as a map serializable into JSON.
+func (c *CompletionSuggester) Source() map[string]interface{} {
+	params := map[string]interface{}{"field": c.field}
+	for key, option := range c.options {
+		params[key] = option
+	}
+	return map[string]interface{}{"prefix": c.prefix, "completion": params}
+}
+
+// SuggestSource method composes the suggest section of a search request.
+// Parameters:
+//   - suggesters ...ISuggester  suggesters to be combined
+// Returns the section as a map serializable into JSON.
+func SuggestSource(suggesters ...ISuggester) map[string]interface{} {
+	source := map[string]interface{}{}
+	for _, suggester := range suggesters {
+		source[suggester.Name()] = suggester.Source()
+	}
+	return source
+}
+
+// CompletionMapping method composes the mapping of a field used by completion suggesters.
+// Parameters:
+//   - analyzer string  (optional) an analyzer of the suggestions, "simple" is used when it is empty
+// Returns the field mapping to be put under the field name into mapping properties.
+func CompletionMapping(analyzer string) map[string]interface{} {
+	if analyzer == "" {
+		analyzer = "simple"
+	}
+	return map[string]interface{}{
+		"type":     "completion",
+		"analyzer": analyzer,
+	}
+}
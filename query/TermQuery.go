@@ -0,0 +1,78 @@
+package query
+
+// TermQuery matches documents that contain the exact value in a keyword, numeric or date field.
+type TermQuery struct {
+	field   string
+	value   interface{}
+	options map[string]interface{}
+}
+
+// NewTermQuery method creates a new term query.
+// Parameters:
+//   - field string  a name of the field
+//   - value interface{}  an exact value to match
+// Retruns *TermQuery
+// pointer on new TermQuery
+func NewTermQuery(field string, value interface{}) *TermQuery {
+	c := TermQuery{
+		field:   field,
+		value:   value,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// Boost method sets the factor the score of matched documents is multiplied by.
+// Parameters:
+//   - boost float64  a boost factor
+// Returns the same query to chain calls.
+func (c *TermQuery) Boost(boost float64) *TermQuery {
+	c.options["boost"] = boost
+	return c
+}
+
+// CaseInsensitive method allows to match values of keyword fields ignoring case.
+// Parameters:
+//   - value bool  true to ignore case
+// Returns the same query to chain calls.
+func (c *TermQuery) CaseInsensitive(value bool) *TermQuery {
+	c.options["case_insensitive"] = value
+	return c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *TermQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"term": map[string]interface{}{c.field: withOptions(c.value, "value", c.options)},
+	}
+}
+
+// TermsQuery matches documents that contain any of the exact values in a field.
+type TermsQuery struct {
+	field  string
+	values []interface{}
+}
+
+// NewTermsQuery method creates a new terms query.
+// Parameters:
+//   - field string  a name of the field
+//   - values ...interface{}  exact values to match
+// Retruns *TermsQuery
+// pointer on new TermsQuery
+func NewTermsQuery(field string, values ...interface{}) *TermsQuery {
+	c := TermsQuery{
+		field:  field,
+		values: values,
+	}
+	if c.values == nil {
+		c.values = []interface{}{}
+	}
+	return &c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *TermsQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"terms": map[string]interface{}{c.field: c.values},
+	}
+}
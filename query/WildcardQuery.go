@@ -0,0 +1,80 @@
+package query
+
+import (
+	"strings"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// WildcardQuery matches keyword values against a pattern with ? for a single character
+// and * for any number of characters. Patterns starting with a wildcard examine every term
+// of the field and are rejected by Validate unless explicitly allowed.
+type WildcardQuery struct {
+	field        string
+	pattern      string
+	allowLeading bool
+	options      map[string]interface{}
+}
+
+// NewWildcardQuery method creates a new wildcard query.
+// Parameters:
+//   - field string  a name of the field
+//   - pattern string  a pattern to match, for instance "orders-*"
+// Retruns *WildcardQuery
+// pointer on new WildcardQuery
+func NewWildcardQuery(field string, pattern string) *WildcardQuery {
+	c := WildcardQuery{
+		field:   field,
+		pattern: pattern,
+		options: map[string]interface{}{},
+	}
+	return &c
+}
+
+// CaseInsensitive method allows to match values ignoring case.
+// Parameters:
+//   - value bool  true to ignore case
+// Returns the same query to chain calls.
+func (c *WildcardQuery) CaseInsensitive(value bool) *WildcardQuery {
+	c.options["case_insensitive"] = value
+	return c
+}
+
+// AllowLeadingWildcard method allows patterns starting with ? or *,
+// for small indices or fields where the cost is acceptable.
+// Parameters:
+//   - value bool  true to allow leading wildcards
+// Returns the same query to chain calls.
+func (c *WildcardQuery) AllowLeadingWildcard(value bool) *WildcardQuery {
+	c.allowLeading = value
+	return c
+}
+
+// Validate method checks that the pattern does not start with a wildcard unless it is allowed.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns BadRequestError LEADING_WILDCARD or nil if the query is valid.
+func (c *WildcardQuery) Validate(correlationId string) error {
+	if c.allowLeading || !strings.HasPrefix(c.pattern, "*") && !strings.HasPrefix(c.pattern, "?") {
+		return nil
+	}
+	return cerr.NewBadRequestError(correlationId, "LEADING_WILDCARD",
+		"Wildcard pattern "+c.pattern+" for "+c.field+" starts with a wildcard").
+		WithDetails("field", c.field).WithDetails("pattern", c.pattern)
+}
+
+// Boost method sets the factor the score of matched documents is multiplied by.
+// Parameters:
+//   - boost float64  a boost factor
+// Returns the same query to chain calls.
+func (c *WildcardQuery) Boost(boost float64) *WildcardQuery {
+	c.options["boost"] = boost
+	return c
+}
+
+// Source method returns the query as a map serializable into JSON.
+func (c *WildcardQuery) Source() map[string]interface{} {
+	return map[string]interface{}{
+		"wildcard": map[string]interface{}{c.field: withOptions(c.pattern, "value", c.options)},
+	}
+}
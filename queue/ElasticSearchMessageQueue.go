@@ -0,0 +1,668 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	esv8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	crpccon "github.com/pip-services3-go/pip-services3-rpc-go/connect"
+)
+
+/*
+ElasticSearchMessageQueue is a message queue that keeps messages in an ElasticSearch index.
+It is meant for low-throughput work queues in deployments that run ElasticSearch
+and no message broker.
+
+Received messages are locked for lock_timeout and must be completed, abandoned or renewed.
+Locks are claimed with conditional writes guarded by sequence numbers, so only one receiver
+gets a message even when several of them poll the index. Messages whose locks expired are
+delivered again, so handlers must be idempotent.
+
+Configuration parameters:
+
+- name:                  name of the queue, used in the default index name
+- connection(s):
+    - discovery_key:         (optional) a key to retrieve the connection from IDiscovery
+    - protocol:              connection protocol: http or https
+    - host:                  host name or IP address
+    - port:                  port number
+    - uri:                   resource URI or connection string with all parameters in it
+- options:
+    - index:             index name (default: "queue_" + name or "queue")
+    - lock_timeout:      time in milliseconds received messages are locked for (default: 30 sec)
+    - receive_interval:  interval in milliseconds between polls in Receive, it must be positive (default: 1 sec)
+    - batch_size:        number of candidate messages read by a poll, it must be positive (default: 10)
+    - timeout:           invocation timeout in milliseconds (default: 30 sec)
+
+References:
+
+- *:logger:*:*:1.0            (optional)  ILogger components to write own diagnostics
+- *:discovery:*:*:1.0         (optional)  IDiscovery services to resolve connection
+
+Example:
+
+    queue := NewElasticSearchMessageQueue("jobs")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.uri", "http://localhost:9200",
+        "options.lock_timeout", 60000,
+    ))
+
+    err := queue.Open("123")
+    ...
+
+    err = queue.Send("123", NewMessageEnvelope("123", "resize", []byte("image-1.png")))
+
+    message, err := queue.Receive("123", 10*time.Second)
+    if message != nil {
+        ...
+        err = queue.Complete(message)
+    }
+*/
+type ElasticSearchMessageQueue struct {
+	connectionResolver *crpccon.HttpConnectionResolver
+	logger             *clog.CompositeLogger
+	transport          http.RoundTripper
+
+	lock   sync.Mutex
+	client *esv8.Client
+
+	name            string
+	index           string
+	lockTimeout     int
+	receiveInterval int
+	batchSize       int
+	timeout         int
+
+	configError error
+}
+
+// queueDocument is a message stored in the queue index.
+type queueDocument struct {
+	MessageEnvelope
+	// Time in milliseconds since epoch until the message is locked by a receiver
+	LockedUntil int64 `json:"locked_until"`
+	// Number of times the message was received
+	ReceiveCount int `json:"receive_count"`
+}
+
+// queueHit is a queue document read with its sequence number.
+type queueHit struct {
+	Id          string        `json:"_id"`
+	SeqNo       int           `json:"_seq_no"`
+	PrimaryTerm int           `json:"_primary_term"`
+	Source      queueDocument `json:"_source"`
+}
+
+// NewElasticSearchMessageQueue method creates a new instance of the queue.
+// Parameters:
+//   - name string  (optional) a name of the queue
+// Retruns *ElasticSearchMessageQueue
+// pointer on new ElasticSearchMessageQueue
+func NewElasticSearchMessageQueue(name string) *ElasticSearchMessageQueue {
+	c := ElasticSearchMessageQueue{}
+	c.connectionResolver = crpccon.NewHttpConnectionResolver()
+	c.logger = clog.NewCompositeLogger()
+	c.name = name
+	c.lockTimeout = 30000
+	c.receiveInterval = 1000
+	c.batchSize = 10
+	c.timeout = 30000
+	return &c
+}
+
+// Configure are configures component by passing configuration parameters.
+// Parameters:
+//   - config  *cconf.ConfigParams   configuration parameters to be set.
+func (c *ElasticSearchMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.connectionResolver.Configure(config)
+
+	c.name = config.GetAsStringWithDefault("name", c.name)
+	c.index = config.GetAsStringWithDefault("options.index", c.index)
+	c.lockTimeout = config.GetAsIntegerWithDefault("options.lock_timeout", c.lockTimeout)
+	c.receiveInterval = config.GetAsIntegerWithDefault("options.receive_interval", c.receiveInterval)
+	c.batchSize = config.GetAsIntegerWithDefault("options.batch_size", c.batchSize)
+	c.timeout = config.GetAsIntegerWithDefault("options.timeout", c.timeout)
+
+	c.configError = nil
+	if c.receiveInterval <= 0 {
+		c.configError = cerr.NewConfigError("", "WRONG_RECEIVE_INTERVAL",
+			"Configuration option options.receive_interval must be positive").
+			WithDetails("receive_interval", c.receiveInterval)
+	} else if c.batchSize <= 0 {
+		c.configError = cerr.NewConfigError("", "WRONG_BATCH_SIZE",
+			"Configuration option options.batch_size must be positive").
+			WithDetails("batch_size", c.batchSize)
+	}
+}
+
+// SetReferences method are sets references to dependent components.
+// Parameters:
+//   - references cref.IReferences 	references to locate the component dependencies.
+func (c *ElasticSearchMessageQueue) SetReferences(references cref.IReferences) {
+	c.connectionResolver.SetReferences(references)
+	c.logger.SetReferences(references)
+}
+
+// SetTransport method sets a custom HTTP transport used to send requests to ElasticSearch.
+// It must be called before the queue is opened.
+// Parameters:
+//   - transport http.RoundTripper  a transport to be used
+func (c *ElasticSearchMessageQueue) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// Name method returns the name of the queue.
+func (c *ElasticSearchMessageQueue) Name() string {
+	return c.name
+}
+
+// getIndex returns the name of the queue index.
+func (c *ElasticSearchMessageQueue) getIndex() string {
+	if c.index != "" {
+		return c.index
+	}
+	if c.name != "" {
+		return "queue_" + strings.ToLower(c.name)
+	}
+	return "queue"
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns true if the component has been opened and false otherwise.
+func (c *ElasticSearchMessageQueue) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.client != nil
+}
+
+// Open method are opens the component and creates the queue index if it does not exist.
+// Parameters:
+//  - correlationId string 	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchMessageQueue) Open(correlationId string) (err error) {
+	if c.IsOpen() {
+		return nil
+	}
+	if c.configError != nil {
+		return c.configError
+	}
+
+	client, err := econnect.NewBasicClient(correlationId, c.connectionResolver, c.transport, c.timeout)
+	if err != nil {
+		return err
+	}
+	if err := c.createIndex(correlationId, client); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.client = client
+	c.lock.Unlock()
+
+	return nil
+}
+
+// Close method are closes component.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil, if no errors occured.
+func (c *ElasticSearchMessageQueue) Close(correlationId string) (err error) {
+	c.lock.Lock()
+	c.client = nil
+	c.lock.Unlock()
+
+	return nil
+}
+
+func (c *ElasticSearchMessageQueue) getClient(correlationId string) (*esv8.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.client == nil {
+		return nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Queue is not opened")
+	}
+	return c.client, nil
+}
+
+func (c *ElasticSearchMessageQueue) createIndex(correlationId string, client *esv8.Client) error {
+	index := c.getIndex()
+	resp, err := client.Indices.Exists([]string{index})
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT",
+			"Failed to check queue index "+index).WithCause(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == 200 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"correlation_id": map[string]interface{}{"type": "keyword"},
+				"message_id":     map[string]interface{}{"type": "keyword"},
+				"message_type":   map[string]interface{}{"type": "keyword"},
+				"sent_time":      map[string]interface{}{"type": "date"},
+				"message":        map[string]interface{}{"type": "binary"},
+				"locked_until":   map[string]interface{}{"type": "long"},
+				"receive_count":  map[string]interface{}{"type": "integer"},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err = client.Indices.Create(index, client.Indices.Create.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CREATE_INDEX",
+			"Failed to create queue index "+index).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	appErr := econnect.NewErrorFromResponse(correlationId, resp)
+	// The index could be created by another instance of the queue
+	if appErr != nil && appErr.Code == "RESOURCE_ALREADY_EXISTS_EXCEPTION" {
+		return nil
+	}
+	if appErr != nil {
+		return appErr
+	}
+	c.logger.Debug(correlationId, "Created queue index %s", index)
+	return nil
+}
+
+// Send method sends a message into the queue.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - envelope *MessageEnvelope  a message to be sent
+// Returns error or nil if the message was stored.
+func (c *ElasticSearchMessageQueue) Send(correlationId string, envelope *MessageEnvelope) error {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+	if envelope == nil {
+		return cerr.NewBadRequestError(correlationId, "NO_MESSAGE", "Message is not set")
+	}
+
+	doc := queueDocument{MessageEnvelope: *envelope}
+	if doc.MessageId == "" {
+		doc.MessageId = NewMessageEnvelope("", "", nil).MessageId
+	}
+	if doc.CorrelationId == "" {
+		doc.CorrelationId = correlationId
+	}
+	doc.SentTime = time.Now().UTC()
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	index := c.getIndex()
+	resp, err := client.Index(index, bytes.NewReader(body),
+		client.Index.WithDocumentID(doc.MessageId),
+		client.Index.WithOpType("create"),
+		client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_SEND",
+			"Failed to send message to queue "+index).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+
+	envelope.MessageId = doc.MessageId
+	envelope.CorrelationId = doc.CorrelationId
+	envelope.SentTime = doc.SentTime
+	c.logger.Trace(correlationId, "Sent message %s to queue %s", doc.MessageId, index)
+	return nil
+}
+
+// Peek method returns the oldest message available for receiving without locking it.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns the message, nil if the queue is empty or error if the request failed.
+func (c *ElasticSearchMessageQueue) Peek(correlationId string) (*MessageEnvelope, error) {
+	hits, err := c.findAvailable(correlationId, 1)
+	if err != nil || len(hits) == 0 {
+		return nil, err
+	}
+
+	envelope := hits[0].Source.MessageEnvelope
+	return &envelope, nil
+}
+
+// Receive method receives the oldest available message and locks it for lock_timeout.
+// It polls the queue every receive_interval until a message arrives or the wait times out.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+//   - waitTimeout time.Duration  time to wait for a message, 0 checks the queue once
+// Returns the message, nil if no message arrived or error if the request failed.
+func (c *ElasticSearchMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*MessageEnvelope, error) {
+	deadline := time.Now().Add(waitTimeout)
+	interval := time.Duration(c.receiveInterval) * time.Millisecond
+
+	for {
+		envelope, err := c.tryReceive(correlationId)
+		if err != nil || envelope != nil {
+			return envelope, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+		if remaining < interval {
+			interval = remaining
+		}
+		time.Sleep(interval)
+	}
+}
+
+// findAvailable reads the oldest messages that are not locked.
+func (c *ElasticSearchMessageQueue) findAvailable(correlationId string, size int) ([]queueHit, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := equery.NewSearchRequest().
+		Query(equery.NewBoolQuery().Filter(
+			equery.NewRangeQuery("locked_until").Lt(time.Now().UnixNano()/int64(time.Millisecond)))).
+		Sort("sent_time", true).
+		Size(size)
+	body, err := json.Marshal(query.Source())
+	if err != nil {
+		return nil, err
+	}
+
+	index := c.getIndex()
+	resp, err := client.Search(
+		client.Search.WithIndex(index),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithSeqNoPrimaryTerm(true),
+	)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_RECEIVE",
+			"Failed to read messages from queue "+index).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, appErr
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []queueHit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Hits.Hits, nil
+}
+
+// tryReceive claims the first available message that was not claimed by another receiver.
+func (c *ElasticSearchMessageQueue) tryReceive(correlationId string) (*MessageEnvelope, error) {
+	hits, err := c.findAvailable(correlationId, c.batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		doc := hit.Source
+		doc.ReceiveCount++
+		doc.LockedUntil = c.lockDeadline(c.lockTimeout)
+
+		current := &messageLock{id: hit.Id, seqNo: hit.SeqNo, primaryTerm: hit.PrimaryTerm}
+		claimed, err := c.writeLocked(correlationId, current, &doc)
+		if err != nil {
+			if isConflict(err) {
+				// Another receiver claimed the message first
+				continue
+			}
+			return nil, err
+		}
+
+		envelope := doc.MessageEnvelope
+		envelope.reference = claimed
+		c.logger.Trace(correlationId, "Received message %s from queue %s", envelope.MessageId, c.getIndex())
+		return &envelope, nil
+	}
+	return nil, nil
+}
+
+// lockDeadline returns the time in milliseconds since epoch a new lock expires at.
+func (c *ElasticSearchMessageQueue) lockDeadline(timeout int) int64 {
+	return time.Now().Add(time.Duration(timeout)*time.Millisecond).UnixNano() / int64(time.Millisecond)
+}
+
+// writeLocked replaces the message document if it was not changed since the lock was taken.
+// Returns the lock of the new version or ConflictError if the document was changed.
+func (c *ElasticSearchMessageQueue) writeLocked(correlationId string, current *messageLock,
+	doc *queueDocument) (*messageLock, error) {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Index(c.getIndex(), bytes.NewReader(body),
+		client.Index.WithDocumentID(current.id),
+		client.Index.WithIfSeqNo(current.seqNo),
+		client.Index.WithIfPrimaryTerm(current.primaryTerm),
+		client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_LOCK",
+			"Failed to update message "+current.id).WithCause(err)
+	}
+	return readLock(correlationId, resp, doc)
+}
+
+// readLock reads the version of the written document from the response.
+func readLock(correlationId string, resp *esapi.Response, doc *queueDocument) (*messageLock, error) {
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return nil, appErr
+	}
+
+	var result struct {
+		Id          string `json:"_id"`
+		SeqNo       int    `json:"_seq_no"`
+		PrimaryTerm int    `json:"_primary_term"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &messageLock{
+		id:           result.Id,
+		seqNo:        result.SeqNo,
+		primaryTerm:  result.PrimaryTerm,
+		receiveCount: doc.ReceiveCount,
+		lockedUntil:  doc.LockedUntil,
+	}, nil
+}
+
+// checkLocked returns error when the message was not received from the queue.
+func checkLocked(message *MessageEnvelope) error {
+	if message == nil || message.reference == nil {
+		return cerr.NewInvalidStateError("", "NOT_RECEIVED", "Message was not received from the queue")
+	}
+	return nil
+}
+
+// isConflict checks if a guarded write failed because the document was changed.
+func isConflict(err error) bool {
+	appErr, ok := err.(*cerr.ApplicationError)
+	return ok && appErr.Category == cerr.Conflict
+}
+
+// lockLost converts conflicts of guarded writes into LOCK_LOST errors.
+func lockLost(message *MessageEnvelope, err error) error {
+	if isConflict(err) {
+		return cerr.NewConflictError(message.CorrelationId, "LOCK_LOST",
+			"Lock of message "+message.MessageId+" expired and it was received again").
+			WithDetails("message_id", message.MessageId).WithCause(err)
+	}
+	return err
+}
+
+// RenewLock method extends the lock of a received message that takes long to process.
+// Parameters:
+//   - message *MessageEnvelope  a received message
+//   - lockTimeout time.Duration  time the message is locked for from now
+// Returns error or ConflictError LOCK_LOST if the message was received by another receiver.
+func (c *ElasticSearchMessageQueue) RenewLock(message *MessageEnvelope, lockTimeout time.Duration) error {
+	if err := checkLocked(message); err != nil {
+		return err
+	}
+
+	doc := queueDocument{
+		MessageEnvelope: *message,
+		LockedUntil:     c.lockDeadline(int(lockTimeout / time.Millisecond)),
+		ReceiveCount:    message.reference.receiveCount,
+	}
+	renewed, err := c.writeLocked(message.CorrelationId, message.reference, &doc)
+	if err != nil {
+		return lockLost(message, err)
+	}
+
+	message.reference = renewed
+	return nil
+}
+
+// Complete method removes a processed message from the queue.
+// Parameters:
+//   - message *MessageEnvelope  a received message
+// Returns error or ConflictError LOCK_LOST if the message was received by another receiver.
+func (c *ElasticSearchMessageQueue) Complete(message *MessageEnvelope) error {
+	if err := checkLocked(message); err != nil {
+		return err
+	}
+	client, err := c.getClient(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Delete(c.getIndex(), message.reference.id,
+		client.Delete.WithIfSeqNo(message.reference.seqNo),
+		client.Delete.WithIfPrimaryTerm(message.reference.primaryTerm),
+		client.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		return cerr.NewConnectionError(message.CorrelationId, "CANNOT_COMPLETE",
+			"Failed to complete message "+message.MessageId).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(message.CorrelationId, resp); appErr != nil {
+		return lockLost(message, appErr)
+	}
+
+	message.reference = nil
+	return nil
+}
+
+// Abandon method releases the lock of a received message, so it can be received again right away.
+// Parameters:
+//   - message *MessageEnvelope  a received message
+// Returns error or ConflictError LOCK_LOST if the message was received by another receiver.
+func (c *ElasticSearchMessageQueue) Abandon(message *MessageEnvelope) error {
+	if err := checkLocked(message); err != nil {
+		return err
+	}
+
+	doc := queueDocument{
+		MessageEnvelope: *message,
+		ReceiveCount:    message.reference.receiveCount,
+	}
+	if _, err := c.writeLocked(message.CorrelationId, message.reference, &doc); err != nil {
+		return lockLost(message, err)
+	}
+
+	message.reference = nil
+	return nil
+}
+
+// ReadMessageCount method counts messages in the queue, including locked ones.
+// Returns number of messages or error if the request failed.
+func (c *ElasticSearchMessageQueue) ReadMessageCount() (int64, error) {
+	client, err := c.getClient("")
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Count(client.Count.WithIndex(c.getIndex()))
+	if err != nil {
+		return 0, cerr.NewConnectionError("", "CANNOT_COUNT",
+			"Failed to count messages in queue "+c.getIndex()).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse("", resp); appErr != nil {
+		return 0, appErr
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// Clear method removes all messages from the queue.
+// Parameters:
+//   - correlationId  string	(optional) transaction id to trace execution through call chain.
+// Returns error or nil if the messages were removed.
+func (c *ElasticSearchMessageQueue) Clear(correlationId string) error {
+	client, err := c.getClient(correlationId)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"query": equery.NewMatchAllQuery().Source()})
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.DeleteByQuery([]string{c.getIndex()}, bytes.NewReader(body),
+		client.DeleteByQuery.WithRefresh(true),
+		client.DeleteByQuery.WithConflicts("proceed"),
+	)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CLEAR",
+			"Failed to clear queue "+c.getIndex()).WithCause(err)
+	}
+	defer resp.Body.Close()
+
+	if appErr := econnect.NewErrorFromResponse(correlationId, resp); appErr != nil {
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"encoding/json"
+	"time"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// MessageEnvelope is a message sent to ElasticSearchMessageQueue with its routing details.
+// It follows MessageEnvelope of pip-services messaging, so handlers can be shared
+// with other queue implementations.
+type MessageEnvelope struct {
+	// Transaction id to trace execution through call chain
+	CorrelationId string `json:"correlation_id"`
+	// Unique message id, it is generated when the message is sent without it
+	MessageId string `json:"message_id"`
+	// Type of the message used by receivers to parse it
+	MessageType string `json:"message_type"`
+	// Time when the message was sent
+	SentTime time.Time `json:"sent_time"`
+	// Message content
+	Message []byte `json:"message"`
+
+	// Lock of the received message
+	reference *messageLock
+}
+
+// messageLock identifies the version of the document claimed by a receiver.
+// Writes guarded by it fail when the lock expired and the message was claimed again.
+type messageLock struct {
+	id           string
+	seqNo        int
+	primaryTerm  int
+	receiveCount int
+	lockedUntil  int64
+}
+
+// NewMessageEnvelope method creates a new message envelope.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - messageType string  a type of the message
+//   - message []byte  content of the message
+// Retruns *MessageEnvelope
+// pointer on new MessageEnvelope
+func NewMessageEnvelope(correlationId string, messageType string, message []byte) *MessageEnvelope {
+	c := MessageEnvelope{
+		CorrelationId: correlationId,
+		MessageId:     cdata.IdGenerator.NextLong(),
+		MessageType:   messageType,
+		Message:       message,
+	}
+	return &c
+}
+
+// NewMessageEnvelopeFromObject method creates a new message envelope with the value serialized into JSON.
+// Parameters:
+//   - correlationId string  (optional) transaction id to trace execution through call chain.
+//   - messageType string  a type of the message
+//   - value interface{}  a value to be serialized
+// Returns the envelope or error if the value cannot be serialized.
+func NewMessageEnvelopeFromObject(correlationId string, messageType string, value interface{}) (*MessageEnvelope, error) {
+	message, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return NewMessageEnvelope(correlationId, messageType, message), nil
+}
+
+// GetMessageAsString method returns the message content as a string.
+func (c *MessageEnvelope) GetMessageAsString() string {
+	return string(c.Message)
+}
+
+// GetMessageAsObject method deserializes the JSON message content into the value.
+// Parameters:
+//   - value interface{}  a pointer to the value to be set
+// Returns error if the content is not valid JSON.
+func (c *MessageEnvelope) GetMessageAsObject(value interface{}) error {
+	return json.Unmarshal(c.Message, value)
+}
+
+// ReceiveCount method returns how many times the message has been received,
+// including the current delivery. It is 0 for messages that were not received.
+func (c *MessageEnvelope) ReceiveCount() int {
+	if c.reference == nil {
+		return 0
+	}
+	return c.reference.receiveCount
+}
@@ -0,0 +1,28 @@
+package test_connect
+
+import (
+	"strings"
+	"testing"
+
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBulkResponse(t *testing.T) {
+	body := `{"took":3,"errors":true,"items":[
+		{"index":{"_index":"log","_id":"1","status":201,"result":"created"}},
+		{"index":{"_index":"log","_id":"2","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [level]"}}}
+	]}`
+
+	resp, err := econnect.ReadBulkResponse(strings.NewReader(body))
+	assert.Nil(t, err)
+	assert.True(t, resp.Errors)
+	assert.Len(t, resp.Items, 2)
+
+	assert.Equal(t, "index", resp.Items[0].Operation)
+	assert.False(t, resp.Items[0].Failed())
+
+	assert.True(t, resp.Items[1].Failed())
+	assert.Equal(t, "2", resp.Items[1].Id)
+	assert.Equal(t, "mapper_parsing_exception", resp.Items[1].Error.Type)
+}
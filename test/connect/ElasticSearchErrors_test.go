@@ -0,0 +1,55 @@
+package test_connect
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	esapi "github.com/elastic/go-elasticsearch/v8/esapi"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	econnect "github.com/pip-services3-go/pip-services3-elasticsearch-go/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func newResponse(status int, body string) *esapi.Response {
+	return &esapi.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestErrorFromResponse(t *testing.T) {
+	err := econnect.NewErrorFromResponse("123", newResponse(200, `{}`))
+	assert.Nil(t, err)
+
+	err = econnect.NewErrorFromResponse("123", newResponse(404,
+		`{"error":{"type":"index_not_found_exception","reason":"no such index [log]","index":"log"},"status":404}`))
+	assert.NotNil(t, err)
+	assert.Equal(t, cerr.NotFound, err.Category)
+	assert.Equal(t, "INDEX_NOT_FOUND_EXCEPTION", err.Code)
+	assert.Equal(t, "no such index [log]", err.Message)
+	assert.Equal(t, "123", err.CorrelationId)
+	assert.Equal(t, "log", err.Details["index"])
+
+	err = econnect.NewErrorFromResponse("123", newResponse(409,
+		`{"error":{"type":"version_conflict_engine_exception","reason":"version conflict"},"status":409}`))
+	assert.Equal(t, cerr.Conflict, err.Category)
+	assert.Equal(t, 409, err.Status)
+
+	err = econnect.NewErrorFromResponse("123", newResponse(403,
+		`{"error":{"type":"security_exception","reason":"action is unauthorized"},"status":403}`))
+	assert.Equal(t, cerr.Unauthorized, err.Category)
+	assert.Equal(t, 403, err.Status)
+
+	err = econnect.NewErrorFromResponse("123", newResponse(429,
+		`{"error":{"type":"circuit_breaking_exception","reason":"data too large"},"status":429}`))
+	assert.Equal(t, cerr.FailedInvocation, err.Category)
+	assert.Equal(t, 429, err.Status)
+
+	err = econnect.NewErrorFromResponse("123", newResponse(500, `{"error":"old style error","status":500}`))
+	assert.Equal(t, cerr.Unknown, err.Category)
+	assert.Equal(t, "old style error", err.Message)
+
+	err = econnect.NewErrorFromResponse("123", newResponse(503, ``))
+	assert.Equal(t, cerr.NoResponse, err.Category)
+}
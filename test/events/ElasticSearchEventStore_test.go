@@ -0,0 +1,285 @@
+package test_events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ebuild "github.com/pip-services3-go/pip-services3-elasticsearch-go/build"
+	eevents "github.com/pip-services3-go/pip-services3-elasticsearch-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventServer emulates document APIs of the "events" index with create-only writes.
+// Documents listed in unrefreshed are readable by id but not found by searches yet.
+type eventServer struct {
+	lock        sync.Mutex
+	created     bool
+	docs        map[string]*eevents.Event
+	unrefreshed map[string]bool
+}
+
+func (c *eventServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.TrimPrefix(r.URL.Path, "/events")
+
+	switch {
+	case path == "" && r.Method == http.MethodHead:
+		if !c.created {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case path == "" && r.Method == http.MethodPut:
+		c.created = true
+		w.Write([]byte(`{"acknowledged":true}`))
+	case strings.HasPrefix(path, "/_doc/"):
+		id := strings.TrimPrefix(path, "/_doc/")
+		_, exists := c.docs[id]
+		if r.Method == http.MethodHead {
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+		if exists && r.URL.Query().Get("op_type") == "create" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":{"type":"version_conflict_engine_exception","reason":"document already exists"},"status":409}`))
+			return
+		}
+		event := &eevents.Event{}
+		json.Unmarshal(data, event)
+		c.docs[id] = event
+		w.Write([]byte(`{"_id":"` + id + `","result":"created"}`))
+	case path == "/_search":
+		var body struct {
+			Size  int `json:"size"`
+			Query struct {
+				Bool struct {
+					Filter []struct {
+						Term struct {
+							AggregateId string `json:"aggregate_id"`
+						} `json:"term"`
+						Range struct {
+							Version struct {
+								Gte int64 `json:"gte"`
+							} `json:"version"`
+						} `json:"range"`
+					} `json:"filter"`
+				} `json:"bool"`
+			} `json:"query"`
+			Sort []map[string]struct {
+				Order string `json:"order"`
+			} `json:"sort"`
+		}
+		json.Unmarshal(data, &body)
+		aggregateId := ""
+		var from int64
+		for _, filter := range body.Query.Bool.Filter {
+			if filter.Term.AggregateId != "" {
+				aggregateId = filter.Term.AggregateId
+			}
+			if filter.Range.Version.Gte > 0 {
+				from = filter.Range.Version.Gte
+			}
+		}
+
+		events := make([]*eevents.Event, 0)
+		for id, event := range c.docs {
+			if event.AggregateId == aggregateId && event.Version >= from && !c.unrefreshed[id] {
+				events = append(events, event)
+			}
+		}
+		descending := len(body.Sort) > 0 && body.Sort[0]["version"].Order == "desc"
+		sort.Slice(events, func(i, j int) bool {
+			return (events[i].Version < events[j].Version) != descending
+		})
+		if len(events) > body.Size {
+			events = events[:body.Size]
+		}
+
+		hits := make([]interface{}, 0)
+		for _, event := range events {
+			hits = append(hits, map[string]interface{}{"_source": event})
+		}
+		result, _ := json.Marshal(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+		w.Write(result)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newEvent(t *testing.T, eventType string, value interface{}) *eevents.Event {
+	event, err := eevents.NewEvent(eventType, value)
+	assert.Nil(t, err)
+	return event
+}
+
+func isWrongVersion(err error) bool {
+	appErr, ok := err.(*cerr.ApplicationError)
+	return ok && appErr.Category == cerr.Conflict && appErr.Code == "WRONG_EXPECTED_VERSION"
+}
+
+func TestElasticSearchEventStore(t *testing.T) {
+	backend := &eventServer{docs: map[string]*eevents.Event{}}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	store := eevents.NewElasticSearchEventStore()
+	store.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	_, err := store.Append("123", "order-1", eevents.NoStream, newEvent(t, "OrderPlaced", nil))
+	assert.NotNil(t, err)
+
+	err = store.Open("123")
+	assert.Nil(t, err)
+	defer store.Close("123")
+	assert.True(t, backend.created)
+
+	version, err := store.Append("123", "order-1", eevents.NoStream,
+		newEvent(t, "OrderPlaced", map[string]interface{}{"total": 10}),
+		newEvent(t, "OrderPaid", nil),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+
+	// The stream already exists
+	_, err = store.Append("123", "order-1", eevents.NoStream, newEvent(t, "OrderPlaced", nil))
+	assert.True(t, isWrongVersion(err))
+
+	// The stream is ahead of the expected version
+	_, err = store.Append("123", "order-1", 1, newEvent(t, "OrderCancelled", nil))
+	assert.True(t, isWrongVersion(err))
+
+	// The stream is behind the expected version
+	_, err = store.Append("123", "order-1", 5, newEvent(t, "OrderCancelled", nil))
+	assert.True(t, isWrongVersion(err))
+
+	shipped := newEvent(t, "OrderShipped", nil)
+	version, err = store.Append("123", "order-1", 2, shipped)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), version)
+	assert.Equal(t, int64(3), shipped.Version)
+	assert.Equal(t, "order-1", shipped.AggregateId)
+
+	version, err = store.Append("123", "order-1", eevents.AnyVersion, newEvent(t, "OrderClosed", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), version)
+
+	_, err = store.Append("123", "order-2", eevents.NoStream, newEvent(t, "OrderPlaced", nil))
+	assert.Nil(t, err)
+
+	// Events before the failed one stay stored in the partial append
+	backend.lock.Lock()
+	backend.docs["order-4:2"] = &eevents.Event{AggregateId: "order-4", Version: 2, EventType: "OrderPaid"}
+	backend.lock.Unlock()
+	version, err = store.Append("123", "order-4", eevents.NoStream,
+		newEvent(t, "OrderPlaced", nil),
+		newEvent(t, "OrderCancelled", nil),
+	)
+	assert.True(t, isWrongVersion(err))
+	assert.Equal(t, int64(1), version)
+
+	events, err := store.ReadStream("123", "order-1", 1, 0)
+	assert.Nil(t, err)
+	assert.Len(t, events, 4)
+	types := make([]string, 0)
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	assert.Equal(t, []string{"OrderPlaced", "OrderPaid", "OrderShipped", "OrderClosed"}, types)
+	// Events of one append share the time
+	assert.True(t, events[0].Time.Equal(events[1].Time))
+
+	var placed map[string]interface{}
+	err = events[0].GetDataAsObject(&placed)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(10), placed["total"])
+
+	events, err = store.ReadStream("123", "order-1", 3, 1)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, int64(3), events[0].Version)
+
+	version, err = store.GetStreamVersion("123", "order-1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), version)
+
+	version, err = store.GetStreamVersion("123", "order-3")
+	assert.Nil(t, err)
+	assert.Equal(t, eevents.NoStream, version)
+}
+
+func TestElasticSearchEventStoreAnyVersion(t *testing.T) {
+	backend := &eventServer{docs: map[string]*eevents.Event{}, unrefreshed: map[string]bool{}}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	store := eevents.NewElasticSearchEventStore()
+	store.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+	err := store.Open("123")
+	assert.Nil(t, err)
+	defer store.Close("123")
+
+	// Events of another writer are not visible to searches yet
+	backend.lock.Lock()
+	for _, version := range []int64{1, 2} {
+		id := "order-1:" + strconv.FormatInt(version, 10)
+		backend.docs[id] = &eevents.Event{AggregateId: "order-1", Version: version, EventType: "OrderPaid"}
+		backend.unrefreshed[id] = true
+	}
+	backend.lock.Unlock()
+
+	version, err := store.Append("123", "order-1", eevents.AnyVersion,
+		newEvent(t, "OrderShipped", nil),
+		newEvent(t, "OrderClosed", nil),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), version)
+
+	// Concurrent appends never fail and get unique versions
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = store.Append("123", "order-2", eevents.AnyVersion, newEvent(t, "OrderUpdated", i))
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.Nil(t, err)
+	}
+
+	events, err := store.ReadStream("123", "order-2", 1, 0)
+	assert.Nil(t, err)
+	assert.Len(t, events, 10)
+	for i, event := range events {
+		assert.Equal(t, int64(i+1), event.Version)
+	}
+}
+
+func TestElasticSearchEventStoreFactory(t *testing.T) {
+	factory := ebuild.NewDefaultElasticSearchFactory()
+
+	component, err := factory.Create(cref.NewDescriptor("pip-services", "event-store", "elasticsearch", "default", "1.0"))
+	assert.Nil(t, err)
+	_, ok := component.(*eevents.ElasticSearchEventStore)
+	assert.True(t, ok)
+}
@@ -0,0 +1,218 @@
+package test_feed
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	efeed "github.com/pip-services3-go/pip-services3-elasticsearch-go/feed"
+	"github.com/stretchr/testify/assert"
+)
+
+type sequencedDocument struct {
+	id  string
+	seq int
+}
+
+// feedServer emulates searches by a numeric "seq" field in the "orders" index
+// and documents of the checkpoint index.
+type feedServer struct {
+	lock        sync.Mutex
+	docs        []sequencedDocument
+	checkpoints map[string][]byte
+}
+
+func (c *feedServer) add(id string, seq int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.docs = append(c.docs, sequencedDocument{id: id, seq: seq})
+}
+
+func (c *feedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/change_feed_checkpoints/_doc/"):
+		id := strings.TrimPrefix(r.URL.Path, "/change_feed_checkpoints/_doc/")
+		if r.Method == http.MethodGet {
+			checkpoint, ok := c.checkpoints[id]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"_id":"` + id + `","found":false}`))
+				return
+			}
+			w.Write([]byte(`{"_id":"` + id + `","found":true,"_source":` + string(checkpoint) + `}`))
+			return
+		}
+		c.checkpoints[id] = data
+		w.Write([]byte(`{"_id":"` + id + `","result":"updated"}`))
+	case r.URL.Path == "/orders/_search":
+		var body struct {
+			Size  int `json:"size"`
+			Query struct {
+				Bool struct {
+					Filter []struct {
+						Range struct {
+							Seq struct {
+								Gte *int `json:"gte"`
+							} `json:"seq"`
+						} `json:"range"`
+					} `json:"filter"`
+				} `json:"bool"`
+			} `json:"query"`
+		}
+		json.Unmarshal(data, &body)
+		from := 0
+		for _, filter := range body.Query.Bool.Filter {
+			if filter.Range.Seq.Gte != nil {
+				from = *filter.Range.Seq.Gte
+			}
+		}
+
+		docs := append([]sequencedDocument{}, c.docs...)
+		sort.SliceStable(docs, func(i, j int) bool { return docs[i].seq < docs[j].seq })
+		hits := make([]interface{}, 0)
+		for _, doc := range docs {
+			if doc.seq >= from && len(hits) < body.Size {
+				hits = append(hits, map[string]interface{}{
+					"_index": "orders", "_id": doc.id,
+					"_source": map[string]interface{}{"seq": doc.seq}, "sort": []interface{}{doc.seq},
+				})
+			}
+		}
+		result, _ := json.Marshal(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+		w.Write(result)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+type recordingHandler struct {
+	ids  []string
+	fail bool
+}
+
+func (c *recordingHandler) HandleChanges(correlationId string, documents []*efeed.ChangedDocument) error {
+	if c.fail {
+		return errors.New("handler failed")
+	}
+	for _, document := range documents {
+		c.ids = append(c.ids, document.Id)
+	}
+	return nil
+}
+
+func newFeed(url string, handler efeed.IChangeHandler) *efeed.ElasticSearchChangeFeed {
+	feed := efeed.NewElasticSearchChangeFeed()
+	feed.Configure(cconf.NewConfigParamsFromTuples(
+		"name", "orders-feed",
+		"connection.uri", url,
+		"options.index", "orders",
+		"options.checkpoint_field", "seq",
+		"options.batch_size", 2,
+		"options.interval", 3600000,
+	))
+	feed.SetHandler(handler)
+	return feed
+}
+
+func TestElasticSearchChangeFeed(t *testing.T) {
+	backend := &feedServer{checkpoints: map[string][]byte{}}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	backend.add("a", 1)
+	backend.add("b", 2)
+	backend.add("c", 2)
+
+	handler := &recordingHandler{}
+	feed := newFeed(server.URL, handler)
+
+	_, err := feed.Poll("123")
+	assert.NotNil(t, err)
+
+	err = feed.Open("123")
+	assert.Nil(t, err)
+	defer feed.Close("123")
+
+	// Pages are read until all documents are delivered
+	count, err := feed.Poll("123")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []string{"a", "b", "c"}, handler.ids)
+
+	// Documents with the checkpoint value are not delivered again
+	backend.add("d", 2)
+	backend.add("e", 3)
+	count, err = feed.Poll("123")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, handler.ids)
+
+	// Failed batches are delivered again
+	backend.add("f", 4)
+	handler.fail = true
+	_, err = feed.Poll("123")
+	assert.NotNil(t, err)
+
+	handler.fail = false
+	count, err = feed.Poll("123")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "f", handler.ids[len(handler.ids)-1])
+
+	// Checkpoint survives restarts
+	restarted := &recordingHandler{}
+	other := newFeed(server.URL, restarted)
+	err = other.Open("123")
+	assert.Nil(t, err)
+	defer other.Close("123")
+
+	count, err = other.Poll("123")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	backend.add("g", 5)
+	count, err = other.Poll("123")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"g"}, restarted.ids)
+}
+
+func TestElasticSearchChangeFeedWrongOptions(t *testing.T) {
+	options := []struct {
+		name  string
+		value int
+		code  string
+	}{
+		{"options.interval", 0, "WRONG_INTERVAL"},
+		{"options.interval", -1000, "WRONG_INTERVAL"},
+		{"options.batch_size", 0, "WRONG_BATCH_SIZE"},
+		{"options.batch_size", -1, "WRONG_BATCH_SIZE"},
+	}
+	for _, option := range options {
+		feed := efeed.NewElasticSearchChangeFeed()
+		feed.Configure(cconf.NewConfigParamsFromTuples(
+			"connection.uri", "http://localhost:9200",
+			"options.index", "orders",
+			option.name, option.value,
+		))
+
+		err := feed.Open("")
+		assert.NotNil(t, err)
+		assert.Equal(t, option.code, err.(*cerr.ApplicationError).Code)
+		assert.False(t, feed.IsOpen())
+	}
+}
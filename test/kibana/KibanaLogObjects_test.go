@@ -0,0 +1,118 @@
+package test_kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	ekibana "github.com/pip-services3-go/pip-services3-elasticsearch-go/kibana"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSavedObjectsControls(t *testing.T) {
+	objects := ekibana.NewLogSavedObjects("log,log-*", "")
+
+	fields := make([]string, 0)
+	references := 0
+	for _, object := range objects {
+		if object["id"] != ekibana.LogsControlsId {
+			continue
+		}
+
+		attributes := object["attributes"].(map[string]interface{})
+		var visState struct {
+			Params struct {
+				Controls []struct {
+					FieldName string `json:"fieldName"`
+					Options   struct {
+						DynamicOptions bool `json:"dynamicOptions"`
+					} `json:"options"`
+				} `json:"controls"`
+			} `json:"params"`
+		}
+		err := json.Unmarshal([]byte(attributes["visState"].(string)), &visState)
+		assert.Nil(t, err)
+
+		for _, control := range visState.Params.Controls {
+			fields = append(fields, control.FieldName)
+			assert.Equal(t, control.FieldName == "correlation_id", control.Options.DynamicOptions)
+		}
+		references = len(object["references"].([]interface{}))
+	}
+
+	assert.Equal(t, []string{"level", "source", "correlation_id"}, fields)
+	assert.Equal(t, 3, references)
+}
+
+func TestLogSavedObjectsErrorsQuery(t *testing.T) {
+	objects := ekibana.NewLogSavedObjects("log,log-*", "")
+
+	var query string
+	for _, object := range objects {
+		if object["id"] != ekibana.LogsErrorsSearchId {
+			continue
+		}
+
+		attributes := object["attributes"].(map[string]interface{})
+		meta := attributes["kibanaSavedObjectMeta"].(map[string]interface{})
+		var searchSource struct {
+			Query struct {
+				Query string `json:"query"`
+			} `json:"query"`
+		}
+		err := json.Unmarshal([]byte(meta["searchSourceJSON"].(string)), &searchSource)
+		assert.Nil(t, err)
+		query = searchSource.Query.Query
+	}
+
+	// None = 0 is not matched
+	assert.Equal(t, "level:(1 or 2)", query)
+}
+
+func TestEcsLogSavedObjects(t *testing.T) {
+	objects := ekibana.NewEcsLogSavedObjects("log,log-*", "")
+
+	var timeField string
+	var query string
+	var columns []string
+	fields := make([]string, 0)
+	for _, object := range objects {
+		attributes := object["attributes"].(map[string]interface{})
+		switch object["id"] {
+		case ekibana.LogsIndexPatternId:
+			timeField = attributes["timeFieldName"].(string)
+		case ekibana.LogsErrorsSearchId:
+			columns = attributes["columns"].([]string)
+			meta := attributes["kibanaSavedObjectMeta"].(map[string]interface{})
+			var searchSource struct {
+				Query struct {
+					Query string `json:"query"`
+				} `json:"query"`
+			}
+			err := json.Unmarshal([]byte(meta["searchSourceJSON"].(string)), &searchSource)
+			assert.Nil(t, err)
+			query = searchSource.Query.Query
+		case ekibana.LogsControlsId:
+			var visState struct {
+				Params struct {
+					Controls []struct {
+						FieldName string `json:"fieldName"`
+						Options   struct {
+							DynamicOptions bool `json:"dynamicOptions"`
+						} `json:"options"`
+					} `json:"controls"`
+				} `json:"params"`
+			}
+			err := json.Unmarshal([]byte(attributes["visState"].(string)), &visState)
+			assert.Nil(t, err)
+			for _, control := range visState.Params.Controls {
+				fields = append(fields, control.FieldName)
+				assert.Equal(t, control.FieldName == "labels.correlation_id", control.Options.DynamicOptions)
+			}
+		}
+	}
+
+	assert.Equal(t, "@timestamp", timeField)
+	assert.Equal(t, "log.level:(error or fatal)", query)
+	assert.Equal(t, []string{"log.level", "service.name", "labels.correlation_id", "message"}, columns)
+	assert.Equal(t, []string{"log.level", "service.name", "labels.correlation_id"}, fields)
+}
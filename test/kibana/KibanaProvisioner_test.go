@@ -0,0 +1,81 @@
+package test_kibana
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	ekibana "github.com/pip-services3-go/pip-services3-elasticsearch-go/kibana"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKibanaProvisioner(t *testing.T) {
+	var imported string
+	var username string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/saved_objects/_import", r.URL.Path)
+		assert.Equal(t, "true", r.Header.Get("kbn-xsrf"))
+		username, _, _ = r.BasicAuth()
+
+		file, _, err := r.FormFile("file")
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(file)
+		imported = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"successCount":5}`))
+	}))
+	defer server.Close()
+
+	provisioner := ekibana.NewKibanaProvisioner()
+	provisioner.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"credential.username", "kibana",
+		"credential.password", "pass",
+		"options.index", "log",
+	))
+
+	err := provisioner.Open("")
+	assert.Nil(t, err)
+	assert.True(t, provisioner.IsOpen())
+	defer provisioner.Close("")
+
+	assert.Equal(t, "kibana", username)
+	assert.Equal(t, 5, len(strings.Split(strings.TrimSpace(imported), "\n")))
+	assert.True(t, strings.Contains(imported, `"title":"log,log-*"`))
+	assert.True(t, strings.Contains(imported, ekibana.LogsDashboardId))
+}
+
+func TestKibanaProvisionerEcs(t *testing.T) {
+	var imported string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(file)
+		imported = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"successCount":5}`))
+	}))
+	defer server.Close()
+
+	provisioner := ekibana.NewKibanaProvisioner()
+	provisioner.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.schema", "ecs",
+	))
+
+	err := provisioner.Open("")
+	assert.Nil(t, err)
+	defer provisioner.Close("")
+
+	assert.Contains(t, imported, `"timeFieldName":"@timestamp"`)
+	assert.Contains(t, imported, `log.level:(error or fatal)`)
+	assert.Contains(t, imported, `"labels.correlation_id"`)
+	assert.NotContains(t, imported, `level:(1 or 2)`)
+}
@@ -0,0 +1,212 @@
+package test_log
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient emulates ElasticSearch responses to the logger requests.
+type fakeClient struct {
+	lock     sync.Mutex
+	bulks    int
+	bulkErr  error
+	bulkBody string
+	request  esapi.BulkRequest
+}
+
+func (c *fakeClient) respond(status int, body string) *esapi.Response {
+	return &esapi.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func (c *fakeClient) Info(o ...func(*esapi.InfoRequest)) (*esapi.Response, error) {
+	return c.respond(200, "{}"), nil
+}
+
+func (c *fakeClient) Bulk(body io.Reader, o ...func(*esapi.BulkRequest)) (*esapi.Response, error) {
+	ioutil.ReadAll(body)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.bulks++
+	c.request = esapi.BulkRequest{}
+	for _, option := range o {
+		option(&c.request)
+	}
+	if c.bulkErr != nil {
+		return nil, c.bulkErr
+	}
+	return c.respond(200, c.bulkBody), nil
+}
+
+func (c *fakeClient) IndicesExists(index []string, o ...func(*esapi.IndicesExistsRequest)) (*esapi.Response, error) {
+	return c.respond(200, ""), nil
+}
+
+func (c *fakeClient) IndicesCreate(index string, o ...func(*esapi.IndicesCreateRequest)) (*esapi.Response, error) {
+	return c.respond(200, "{}"), nil
+}
+
+type recordingFailureListener struct {
+	failures []*elog.BulkItemFailure
+}
+
+func (c *recordingFailureListener) OnBulkFailure(correlationId string, failures []*elog.BulkItemFailure) {
+	c.failures = append(c.failures, failures...)
+}
+
+func TestElasticSearchLoggerCustomClient(t *testing.T) {
+	client := &fakeClient{
+		bulkBody: `{"took":1,"errors":true,"items":[` +
+			`{"index":{"status":201}},` +
+			`{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`,
+	}
+	listener := &recordingFailureListener{}
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"level", "trace",
+	))
+	logger.SetClient(client)
+	logger.AddBulkFailureListener(listener)
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Info("", "First")
+	logger.Info("", "Second")
+	err = logger.Dump()
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, client.bulks)
+	assert.Len(t, listener.failures, 1)
+	assert.Equal(t, "Second", listener.failures[0].Message.Message)
+	assert.Equal(t, 400, listener.failures[0].Status)
+
+	// Failed requests are reported to the error callback
+	client.bulkErr = errors.New("timeout")
+	failed := 0
+	logger.SetOnError(func(correlationId string, err error, batchSize int) {
+		failed += batchSize
+	})
+
+	logger.Info("", "Third")
+	err = logger.Dump()
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, failed)
+}
+
+func TestElasticSearchLoggerRefresh(t *testing.T) {
+	client := &fakeClient{bulkBody: `{"took":1,"errors":false,"items":[]}`}
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"options.refresh", "wait_for",
+		"options.pipeline", "logs",
+	))
+	logger.SetClient(client)
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Info("", "Searchable message")
+	err = logger.Dump()
+	assert.Nil(t, err)
+	assert.Equal(t, "wait_for", client.request.Refresh)
+	assert.Equal(t, "log", client.request.Index)
+	// Pipelines are not created by custom clients but referenced in bulk requests
+	assert.Equal(t, "logs", client.request.Pipeline)
+
+	logger = elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"options.refresh", "always",
+	))
+	err = logger.Open("")
+	assert.NotNil(t, err)
+}
+
+func TestElasticSearchLoggerVerifyAcks(t *testing.T) {
+	client := &fakeClient{
+		bulkBody: `{"took":1,"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}}]}`,
+	}
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"options.verify_acks", true,
+		"options.prioritize_errors", false,
+	))
+	logger.SetClient(client)
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Info("", "First")
+	logger.Info("", "Second")
+	assert.Nil(t, logger.Dump())
+
+	// Silently lost documents are reported instead of being treated as written
+	client.bulkBody = `{"took":1,"errors":false,"items":[{"index":{"status":201}}]}`
+	logger.Info("", "Third")
+	logger.Info("", "Fourth")
+	err = logger.Dump()
+	assert.NotNil(t, err)
+
+	appErr, ok := err.(*cerr.ApplicationError)
+	assert.True(t, ok)
+	assert.Equal(t, "ACK_MISMATCH", appErr.Code)
+	assert.Equal(t, 2, appErr.Details["sent"])
+	assert.Equal(t, 1, appErr.Details["acknowledged"])
+	assert.Equal(t, 1, appErr.Details["missing"])
+
+	client.bulkBody = `{"took":1,"errors":true,"items":[{"index":{"status":201}},` +
+		`{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue is full"}}}]}`
+	logger.Info("", "Fifth")
+	err = logger.Dump()
+	assert.NotNil(t, err)
+
+	appErr, _ = err.(*cerr.ApplicationError)
+	assert.Equal(t, 1, appErr.Details["rejected"])
+}
+
+func TestElasticSearchLoggerCreateConflicts(t *testing.T) {
+	client := &fakeClient{
+		bulkBody: `{"took":1,"errors":true,"items":[{"create":{"status":201}},` +
+			`{"create":{"status":409,"error":{"type":"version_conflict_engine_exception","reason":"document already exists"}}}]}`,
+	}
+	listener := &recordingFailureListener{}
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"options.op_type", "create",
+		"options.verify_acks", true,
+	))
+	logger.SetClient(client)
+	logger.AddBulkFailureListener(listener)
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Info("", "First")
+	logger.Info("", "Second")
+	assert.Nil(t, logger.Dump())
+	assert.Len(t, listener.failures, 0)
+}
@@ -0,0 +1,131 @@
+package test_log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingNotifier struct {
+	alerts []*elog.LogAlert
+}
+
+func (c *recordingNotifier) NotifyAlert(correlationId string, alert *elog.LogAlert) error {
+	c.alerts = append(c.alerts, alert)
+	return nil
+}
+
+func TestElasticSearchLogAlerter(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/log,log-*/_search", r.URL.Path)
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"total":{"value":17}},"aggregations":{"sources":{"buckets":[` +
+			`{"key":"orders","doc_count":12},{"key":"billing","doc_count":5}]}}}`))
+	}))
+	defer server.Close()
+
+	notifier := &recordingNotifier{}
+
+	alerter := elog.NewElasticSearchLogAlerter()
+	alerter.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.threshold", 10,
+		"thresholds.billing", 3,
+	))
+	alerter.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("test", "alert-notifier", "memory", "default", "1.0"), notifier,
+	))
+
+	err := alerter.Open("")
+	assert.Nil(t, err)
+	defer alerter.Close("")
+
+	alerts, err := alerter.Check("123")
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 2)
+	assert.Len(t, notifier.alerts, 2)
+	assert.True(t, strings.Contains(query, `"field":"source"`))
+
+	// Sources are not alerted again within the cooldown
+	alerts, err = alerter.Check("123")
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 0)
+	assert.Len(t, notifier.alerts, 2)
+}
+
+func TestElasticSearchLogAlerterWrongInterval(t *testing.T) {
+	for _, interval := range []int{0, -1000} {
+		alerter := elog.NewElasticSearchLogAlerter()
+		alerter.Configure(cconf.NewConfigParamsFromTuples(
+			"connection.uri", "http://localhost:9200",
+			"options.interval", interval,
+		))
+
+		err := alerter.Open("")
+		assert.NotNil(t, err)
+		assert.Equal(t, "WRONG_INTERVAL", err.(*cerr.ApplicationError).Code)
+		assert.False(t, alerter.IsOpen())
+	}
+}
+
+func TestElasticSearchLogAlerterIndices(t *testing.T) {
+	// Errors counted per source in the logger indices, the alerts index that mirrors them
+	// and a neighbouring Filebeat index
+	indices := map[string]string{
+		"log-20210304":        `{"key":"orders","doc_count":6}`,
+		"log-orders-20210304": `{"key":"orders","doc_count":2}`,
+		"log-alerts-20210304": `{"key":"orders","doc_count":8}`,
+		"logs-x":              `{"key":"orders","doc_count":20}`,
+	}
+
+	var searched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		searched = matchIndices(indices, parts[0])
+
+		count := 0
+		for _, index := range searched {
+			var bucket struct {
+				DocCount int `json:"doc_count"`
+			}
+			json.Unmarshal([]byte(indices[index]), &bucket)
+			count += bucket.DocCount
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"aggregations":{"sources":{"buckets":[{"key":"orders","doc_count":` +
+			strconv.Itoa(count) + `}]}}}`))
+	}))
+	defer server.Close()
+
+	alerter := elog.NewElasticSearchLogAlerter()
+	alerter.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.alerts_index", "log-alerts",
+		"options.threshold", 10,
+	))
+
+	err := alerter.Open("")
+	assert.Nil(t, err)
+	defer alerter.Close("")
+
+	// 8 errors in the logger indices stay below the threshold
+	alerts, err := alerter.Check("123")
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 0)
+	assert.Equal(t, []string{"log-20210304", "log-orders-20210304"}, searched)
+}
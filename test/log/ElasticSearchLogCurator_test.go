@@ -0,0 +1,75 @@
+package test_log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchLogCurator(t *testing.T) {
+	now := time.Now().UTC()
+	indexAt := func(days int) string {
+		return "log-" + now.AddDate(0, 0, -days).Format("20060102")
+	}
+
+	var lock sync.Mutex
+	actions := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/_cat/indices/log-*" {
+			w.Write([]byte(`[{"index":"` + indexAt(0) + `"},{"index":"` + indexAt(2) + `"},` +
+				`{"index":"` + indexAt(10) + `"},{"index":"` + indexAt(40) + `-shrunk"},{"index":"log-alerts"}]`))
+			return
+		}
+		lock.Lock()
+		actions = append(actions, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	curator := elog.NewElasticSearchLogCurator()
+	curator.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.schedule", "30 2 * * *",
+		"options.rollover_alias", "log-write",
+		"options.forcemerge_after", 1,
+		"options.close_after", 7,
+		"options.delete_after", 30,
+	))
+
+	err := curator.Open("")
+	assert.Nil(t, err)
+	defer curator.Close("")
+
+	err = curator.Run("123")
+	assert.Nil(t, err)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.Equal(t, []string{
+		"POST /log-write/_rollover",
+		"DELETE /" + indexAt(40) + "-shrunk",
+		"POST /" + indexAt(10) + "/_close",
+		"POST /" + indexAt(2) + "/_forcemerge",
+	}, actions)
+}
+
+func TestElasticSearchLogCuratorSchedule(t *testing.T) {
+	curator := elog.NewElasticSearchLogCurator()
+	curator.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", "http://localhost:9200",
+		"options.schedule", "61 * * * *",
+	))
+
+	err := curator.Open("")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,881 @@
+package test_log
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchLogReader(t *testing.T) {
+	queries := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		queries[r.URL.Path] = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/log,log-*/_search":
+			w.Write([]byte(`{"hits":{"hits":[` +
+				`{"_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":4,"correlation_id":"abc","message":"Started"}},` +
+				`{"_source":{"time":"2021-03-04T05:06:08Z","source":"orders","level":2,"correlation_id":"abc","message":"Failed",` +
+				`"error":{"code":"TEST","message":"Test error"}}}]}}`))
+		case "/log,log-*/_count":
+			w.Write([]byte(`{"count":7}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	messages, err := reader.GetMessagesByCorrelationId("123", "abc", 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "Started", messages[0].Message)
+	assert.Equal(t, clog.Info, messages[0].Level)
+	assert.Equal(t, "orders", messages[0].Source)
+	assert.Equal(t, "abc", messages[0].CorrelationId)
+	assert.Equal(t, 2021, messages[0].Time.Year())
+	assert.Equal(t, clog.Error, messages[1].Level)
+	assert.Equal(t, "TEST", messages[1].Error.Code)
+	assert.True(t, strings.Contains(queries["/log,log-*/_search"], `"term":{"correlation_id":"abc"}`))
+	assert.True(t, strings.Contains(queries["/log,log-*/_search"], `"size":10`))
+
+	messages, err = reader.GetMessagesByTraceId("123", "4BF92F3577B34DA6A3CE929D0E0E4736", 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 2)
+	assert.True(t, strings.Contains(queries["/log,log-*/_search"], `"term":{"trace.id":"4bf92f3577b34da6a3ce929d0e0e4736"}`))
+
+	count, err := reader.CountErrorsSince("123", time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), count)
+	assert.True(t, strings.Contains(queries["/log,log-*/_count"], `"terms":{"level":[1,2]}`))
+	assert.True(t, strings.Contains(queries["/log,log-*/_count"], `"range":{"time"`))
+
+	count, err = reader.CountMessages("123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), count)
+	assert.Equal(t, `{"query":{"match_all":{}}}`, queries["/log,log-*/_count"])
+}
+
+// matchIndices resolves a multi-target expression with wildcards and exclusions against the indices.
+func matchIndices(indices map[string]string, expression string) []string {
+	matched := []string{}
+	for index := range indices {
+		included := false
+		for _, pattern := range strings.Split(expression, ",") {
+			if strings.HasPrefix(pattern, "-") {
+				if ok, _ := path.Match(pattern[1:], index); ok {
+					included = false
+				}
+			} else if ok, _ := path.Match(pattern, index); ok {
+				included = true
+			}
+		}
+		if included {
+			matched = append(matched, index)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+func TestElasticSearchLogReaderIndices(t *testing.T) {
+	// Documents of the logger, its alerts index and a neighbouring Filebeat index
+	indices := map[string]string{
+		"log-20210304":        `{"_source":{"time":"2021-03-04T05:06:07Z","level":2,"correlation_id":"abc","message":"Failed"}}`,
+		"log-orders-20210304": `{"_source":{"time":"2021-03-04T05:06:08Z","level":4,"correlation_id":"abc","message":"Retried"}}`,
+		"log_alerts-20210304": `{"_source":{"time":"2021-03-04T05:06:07Z","level":2,"correlation_id":"abc","message":"Failed"}}`,
+		"logs-x":              `{"_source":{"time":"2021-03-04T05:06:09Z","level":2,"correlation_id":"abc","message":"Foreign"}}`,
+	}
+	var searched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		searched = matchIndices(indices, parts[0])
+
+		w.Header().Set("Content-Type", "application/json")
+		if parts[1] == "_count" {
+			w.Write([]byte(`{"count":` + strconv.Itoa(len(searched)) + `}`))
+			return
+		}
+		hits := []string{}
+		for _, index := range searched {
+			hits = append(hits, indices[index])
+		}
+		w.Write([]byte(`{"hits":{"hits":[` + strings.Join(hits, ",") + `]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.alerts_index", "log_alerts",
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	messages, err := reader.GetMessagesByCorrelationId("123", "abc", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"log-20210304", "log-orders-20210304"}, searched)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "Failed", messages[0].Message)
+		assert.Equal(t, "Retried", messages[1].Message)
+	}
+
+	count, err := reader.CountErrorsSince("123", time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, []string{"log-20210304", "log-orders-20210304"}, searched)
+
+	// The default index follows the naming of the logger
+	reader = elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.naming", "logstash",
+	))
+
+	err = reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	indices["logstash-2021.03.04"] = indices["log-20210304"]
+	_, err = reader.CountMessages("123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"logstash-2021.03.04"}, searched)
+}
+
+func TestElasticSearchLogReaderGetByIds(t *testing.T) {
+	var paths []string
+	var queries []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path)
+		queries = append(queries, string(data))
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(queries) == 1 {
+			w.Write([]byte(`{"docs":[` +
+				`{"_id":"a","found":true,"_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":4,"message":"Started"}},` +
+				`{"_id":"b","found":false}]}`))
+		} else {
+			w.Write([]byte(`{"docs":[` +
+				`{"_id":"c","found":true,"_source":{"time":"2021-03-04T05:06:08Z","source":"orders","level":2,"message":"Failed"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.max_page_size", 2,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	messages, err := reader.GetMessagesByIds("123", "log-20210304", []string{"a", "b", "c"})
+	assert.Nil(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "Started", messages[0].Message)
+	assert.Equal(t, "Failed", messages[1].Message)
+
+	// Ids are sent in chunks of max_page_size
+	assert.Equal(t, []string{"/log-20210304/_mget", "/log-20210304/_mget"}, paths)
+	assert.Equal(t, []string{`{"ids":["a","b"]}`, `{"ids":["c"]}`}, queries)
+
+	// No ids, no requests
+	messages, err = reader.GetMessagesByIds("123", "log-20210304", nil)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 0)
+	assert.Len(t, queries, 2)
+}
+
+func TestElasticSearchLogReaderGetOneRandom(t *testing.T) {
+	var query string
+	hits := `{"_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":2,"message":"Failed"}}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[` + hits + `]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	message, err := reader.GetOneRandom("123", map[string]interface{}{
+		"term": map[string]interface{}{"source": "orders"},
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, message) {
+		assert.Equal(t, "Failed", message.Message)
+	}
+	assert.Contains(t, query, `"size":1`)
+	assert.Contains(t, query, `"function_score":{"boost_mode":"replace","query":{"term":{"source":`)
+	assert.Contains(t, query, `"random_score":{}`)
+
+	// Empty result is not an error
+	hits = ""
+	message, err = reader.GetOneRandom("123", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, message)
+	assert.Contains(t, query, `"query":{"match_all":{}}`)
+}
+
+func TestElasticSearchLogReaderEcs(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[` +
+			`{"_source":{"@timestamp":"2021-03-04T05:06:07Z","message":"Failed",` +
+			`"log":{"level":"error","logger":"orders"},"service":{"name":"orders"},` +
+			`"labels":{"correlation_id":"abc"},"error":{"type":"Application","code":"TEST","message":"Test error"}}}]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.schema", "ecs",
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	messages, err := reader.GetMessagesByCorrelationId("123", "abc", 0)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, clog.Error, messages[0].Level)
+	assert.Equal(t, "orders", messages[0].Source)
+	assert.Equal(t, "abc", messages[0].CorrelationId)
+	assert.Equal(t, "TEST", messages[0].Error.Code)
+	assert.True(t, strings.Contains(query, `"term":{"labels.correlation_id":"abc"}`))
+	assert.True(t, strings.Contains(query, `"@timestamp":{"order":"asc"`))
+}
+
+func TestElasticSearchLogReaderTail(t *testing.T) {
+	var lock sync.Mutex
+	polls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		polls++
+		poll := polls
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		hits := `{"_id":"1","_source":{"time":"2021-03-04T05:06:07Z","level":4,"message":"First"}}`
+		if poll > 1 {
+			hits += `,{"_id":"2","_source":{"time":"2021-03-04T05:06:08Z","level":4,"message":"Second"}}`
+		}
+		w.Write([]byte(`{"hits":{"hits":[` + hits + `]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.tail_interval", 10,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages := make([]string, 0)
+
+	err = reader.TailMessages(ctx, nil, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+		func(message *clog.LogMessage) error {
+			messages = append(messages, message.Message)
+			if len(messages) == 2 {
+				cancel()
+			}
+			return nil
+		})
+	assert.Nil(t, err)
+	// Messages already returned by previous polls are not repeated
+	assert.Equal(t, []string{"First", "Second"}, messages)
+}
+
+type tailDocument struct {
+	id   string
+	time time.Time
+}
+
+// tailServer emulates searches of TailMessages: a time range, sorting by time and id and search_after.
+type tailServer struct {
+	lock sync.Mutex
+	docs []tailDocument
+}
+
+func (c *tailServer) add(id string, messageTime time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.docs = append(c.docs, tailDocument{id: id, time: messageTime})
+}
+
+func (c *tailServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var body struct {
+		Size  int `json:"size"`
+		Query struct {
+			Bool struct {
+				Filter []struct {
+					Range struct {
+						Time struct {
+							Gte time.Time `json:"gte"`
+						} `json:"time"`
+					} `json:"range"`
+				} `json:"filter"`
+			} `json:"bool"`
+		} `json:"query"`
+		SearchAfter []interface{} `json:"search_after"`
+	}
+	data, _ := ioutil.ReadAll(r.Body)
+	json.Unmarshal(data, &body)
+
+	docs := append([]tailDocument{}, c.docs...)
+	sort.Slice(docs, func(i, j int) bool {
+		if !docs[i].time.Equal(docs[j].time) {
+			return docs[i].time.Before(docs[j].time)
+		}
+		return docs[i].id < docs[j].id
+	})
+
+	hits := make([]interface{}, 0)
+	for _, doc := range docs {
+		millis := float64(doc.time.UnixNano() / int64(time.Millisecond))
+		if doc.time.Before(body.Query.Bool.Filter[0].Range.Time.Gte) {
+			continue
+		}
+		if len(body.SearchAfter) == 2 {
+			after := body.SearchAfter[0].(float64)
+			if millis < after || (millis == after && doc.id <= body.SearchAfter[1].(string)) {
+				continue
+			}
+		}
+		if len(hits) < body.Size {
+			hits = append(hits, map[string]interface{}{
+				"_id":     doc.id,
+				"_source": map[string]interface{}{"time": doc.time.Format(time.RFC3339Nano), "level": 4, "message": doc.id},
+				"sort":    []interface{}{millis, doc.id},
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	result, _ := json.Marshal(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+	w.Write(result)
+}
+
+func TestElasticSearchLogReaderTailPages(t *testing.T) {
+	start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	backend := &tailServer{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		backend.add(id, start)
+	}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.max_page_size", 2,
+		"options.tail_interval", 10,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	messages := make([]string, 0)
+
+	// More messages share the same time than fit into a page
+	err = reader.TailMessages(ctx, nil, start, func(message *clog.LogMessage) error {
+		messages = append(messages, message.Message)
+		if len(messages) == 5 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, messages)
+}
+
+func TestElasticSearchLogReaderTailLookback(t *testing.T) {
+	start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	backend := &tailServer{}
+	backend.add("first", start.Add(2*time.Second))
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.tail_interval", 10,
+		"options.tail_lookback", 5000,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	messages := make([]string, 0)
+
+	err = reader.TailMessages(ctx, nil, start, func(message *clog.LogMessage) error {
+		messages = append(messages, message.Message)
+		switch len(messages) {
+		case 1:
+			// Becomes searchable after the newer message was delivered
+			backend.add("late", start.Add(time.Second))
+		case 2:
+			cancel()
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "late"}, messages)
+}
+
+func TestElasticSearchLogReaderRaw(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[` +
+			`{"_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":2,"message":"Failed"}}]},` +
+			`"aggregations":{"sources":{"buckets":[{"key":"orders","doc_count":3}]}}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	messages, err := reader.SearchRaw("123", `{"query":{"match":{"message":"failed"}}}`)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "Failed", messages[0].Message)
+	assert.Equal(t, `{"query":{"match":{"message":"failed"}}}`, query)
+
+	aggregations, err := reader.AggregateRaw("123", map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"sources": map[string]interface{}{"terms": map[string]interface{}{"field": "source"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, query, `"aggs":{"sources"`)
+	sources := aggregations["sources"].(map[string]interface{})
+	assert.Len(t, sources["buckets"], 1)
+
+	_, err = reader.SearchRaw("123", nil)
+	assert.NotNil(t, err)
+}
+
+func TestElasticSearchLogReaderHighlight(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[` +
+			`{"_id":"1","_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":2,"message":"Payment failed"},` +
+			`"highlight":{"message":["Payment <b>failed</b>"]}},` +
+			`{"_id":"2","_source":{"time":"2021-03-04T05:06:08Z","source":"orders","level":2,"message":"Failure"}}]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	messages, err := reader.SearchHighlighted("123", equery.NewMatchQuery("message", "failed").Source(),
+		equery.NewHighlight("message").Tags("<b>", "</b>").FragmentSize(50), 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "Payment failed", messages[0].Message.Message)
+	assert.Equal(t, []string{"Payment <b>failed</b>"}, messages[0].Highlights["message"])
+	assert.Len(t, messages[1].Highlights, 0)
+
+	assert.Contains(t, query, `"highlight":{`)
+	assert.Contains(t, query, `"pre_tags":["\u003cb\u003e"]`)
+	assert.Contains(t, query, `"fragment_size":50`)
+
+	_, err = reader.SearchHighlighted("123", nil, nil, 10)
+	assert.Nil(t, err)
+	assert.Contains(t, query, `"fields":{"message":{}}`)
+}
+
+func TestElasticSearchLogReaderSuggest(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[]},"suggest":{"spelling":[` +
+			`{"text":"paymnt","offset":0,"length":6,"options":[{"text":"payment","score":0.83,"freq":12}]}]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	suggestions, err := reader.Suggest("123", equery.NewTermSuggester("spelling", "paymnt", "message").Size(3))
+	assert.Nil(t, err)
+	assert.Contains(t, query, `"suggest":{"spelling":{"term":{"field":"message","size":3},"text":"paymnt"}}`)
+	assert.Len(t, suggestions["spelling"], 1)
+	assert.Equal(t, "payment", suggestions["spelling"][0].Options[0].Text)
+	assert.Equal(t, int64(12), suggestions["spelling"][0].Options[0].Frequency)
+
+	_, err = reader.Suggest("123")
+	assert.NotNil(t, err)
+}
+
+func TestElasticSearchLogReaderSearchTemplate(t *testing.T) {
+	requests := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		requests[r.Method+" "+r.URL.Path] = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"found":false}`))
+		case strings.HasSuffix(r.URL.Path, "/_search/template"):
+			w.Write([]byte(`{"hits":{"hits":[` +
+				`{"_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":2,"message":"Failed"}}]}}`))
+		default:
+			w.Write([]byte(`{"acknowledged":true}`))
+		}
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	err = reader.PutSearchTemplate("123", "by_source", `{"query":{"term":{"source":"{{source}}"}}}`)
+	assert.NoError(t, err)
+	assert.Contains(t, requests["PUT /_scripts/by_source"], `"lang":"mustache"`)
+
+	messages, err := reader.SearchTemplate("123", "by_source", map[string]interface{}{"source": "orders"})
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "orders", messages[0].Source)
+	assert.JSONEq(t, `{"id":"by_source","params":{"source":"orders"}}`, requests["GET /log,log-*/_search/template"])
+
+	err = reader.DeleteSearchTemplate("123", "by_source")
+	assert.NoError(t, err)
+
+	err = reader.PutSearchTemplate("123", "empty", nil)
+	assert.NotNil(t, err)
+}
+
+func TestElasticSearchLogReaderRuntimeFields(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[` +
+			`{"_id":"1","_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":2,"message":"Failed in 1200 ms"},` +
+			`"fields":{"duration":[1200],"seconds":[1.2]}}]},` +
+			`"aggregations":{"slow":{"doc_count":1}}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.max_page_size", 50,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	request := equery.NewSearchRequest().
+		RuntimeField("duration", "long", "emit(Long.parseLong(params._source.message.replaceAll('\\\\D', '')))").
+		Query(equery.NewRangeQuery("duration").Gte(1000)).
+		Fields("duration").
+		ScriptField("seconds", "doc['duration'].value / 1000.0", nil).
+		Aggregation("slow", map[string]interface{}{
+			"filter": equery.NewRangeQuery("duration").Gte(1000).Source(),
+		})
+
+	result, err := reader.Search("123", request)
+	assert.Nil(t, err)
+	assert.Len(t, result.Hits, 1)
+	assert.Equal(t, "Failed in 1200 ms", result.Hits[0].Message.Message)
+	assert.Equal(t, []interface{}{float64(1200)}, result.Hits[0].Fields["duration"])
+	assert.Equal(t, []interface{}{1.2}, result.Hits[0].Fields["seconds"])
+	assert.Contains(t, result.Aggregations, "slow")
+
+	assert.Contains(t, query, `"runtime_mappings":{"duration":{"script":{"source":`)
+	assert.Contains(t, query, `"fields":["duration"]`)
+	assert.Contains(t, query, `"script_fields":{"seconds":`)
+	assert.Contains(t, query, `"size":50`)
+
+	_, err = reader.Search("123", equery.NewSearchRequest().Query(equery.NewWildcardQuery("source", "*s")))
+	assert.NotNil(t, err)
+}
+
+func TestElasticSearchLogReaderCollapse(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"hits":[` +
+			`{"_id":"2","_source":{"time":"2021-03-04T05:06:08Z","source":"orders","level":2,"message":"Second"},` +
+			`"inner_hits":{"recent":{"hits":{"hits":[` +
+			`{"_id":"2","_source":{"time":"2021-03-04T05:06:08Z","source":"orders","level":2,"message":"Second"}},` +
+			`{"_id":"1","_source":{"time":"2021-03-04T05:06:07Z","source":"orders","level":2,"message":"First"}}]}}}},` +
+			`{"_id":"3","_source":{"time":"2021-03-04T05:06:09Z","source":"billing","level":2,"message":"Third"}}]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	request := equery.NewSearchRequest().
+		Sort("time", false).
+		Collapse("source").
+		CollapseInnerHits("recent", 5, "time")
+
+	result, err := reader.Search("123", request)
+	assert.Nil(t, err)
+	assert.Len(t, result.Hits, 2)
+	assert.Equal(t, "Second", result.Hits[0].Message.Message)
+	assert.Len(t, result.Hits[0].InnerHits["recent"], 2)
+	assert.Equal(t, "First", result.Hits[0].InnerHits["recent"][1].Message)
+	assert.Len(t, result.Hits[1].InnerHits, 0)
+
+	assert.Contains(t, query, `"collapse":{"field":"source","inner_hits":{"name":"recent"`)
+}
+
+func TestElasticSearchLogReaderAsyncSearch(t *testing.T) {
+	requests := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			w.Write([]byte(`{"id":"abc","is_running":true,"is_partial":true,"response":{"hits":{"hits":[]}}}`))
+		case http.MethodGet:
+			w.Write([]byte(`{"id":"abc","is_running":false,"is_partial":false,"response":{"hits":{"hits":[]},` +
+				`"aggregations":{"sources":{"buckets":[{"key":"orders","doc_count":3}]}}}}`))
+		default:
+			w.Write([]byte(`{"acknowledged":true}`))
+		}
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	request := equery.NewSearchRequest().Size(0).
+		Aggregation("sources", map[string]interface{}{"terms": map[string]interface{}{"field": "source"}})
+	search, err := reader.SubmitAsyncSearch("123", request, time.Second, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", search.Id)
+	assert.True(t, search.Running)
+	assert.Contains(t, requests[0], "POST /log,log-*/_async_search?")
+	assert.Contains(t, requests[0], "wait_for_completion_timeout=1000ms")
+
+	search, err = reader.GetAsyncSearch("123", "abc", 0)
+	assert.Nil(t, err)
+	assert.False(t, search.Running)
+	assert.Contains(t, search.Result.Aggregations, "sources")
+
+	err = reader.DeleteAsyncSearch("123", "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE /_async_search/abc?", requests[2])
+}
+
+func TestElasticSearchLogReaderSql(t *testing.T) {
+	requests := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.URL.Path+" "+string(data))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/close"):
+			w.Write([]byte(`{"succeeded":true}`))
+		case strings.Contains(string(data), `"cursor"`):
+			w.Write([]byte(`{"rows":[["billing",1]]}`))
+		default:
+			w.Write([]byte(`{"columns":[{"name":"source","type":"keyword"},{"name":"errors","type":"long"}],` +
+				`"rows":[["orders",3]],"cursor":"c1"}`))
+		}
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	result, err := reader.QuerySql("123",
+		`SELECT source, COUNT(*) AS errors FROM "log*" WHERE level <= ? GROUP BY source`, []interface{}{2}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "c1", result.Cursor)
+	assert.Equal(t, []map[string]interface{}{{"source": "orders", "errors": float64(3)}}, result.Rows)
+	assert.Contains(t, requests[0], `"params":[2]`)
+	assert.Contains(t, requests[0], `"fetch_size":1`)
+
+	result, err = reader.NextSqlPage("123", result)
+	assert.Nil(t, err)
+	assert.Equal(t, "", result.Cursor)
+	assert.Equal(t, "billing", result.Rows[0]["source"])
+
+	_, err = reader.NextSqlPage("123", result)
+	assert.NotNil(t, err)
+
+	err = reader.CloseSqlCursor("123", "c2")
+	assert.NoError(t, err)
+	assert.Equal(t, `/_sql/close {"cursor":"c2"}`, requests[2])
+}
+
+func TestElasticSearchLogReaderEql(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = r.URL.Path + " " + string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":{"sequences":[{"join_keys":["123"],"events":[` +
+			`{"_source":{"time":"2021-03-04T05:06:07Z","source":"auth","level":3,"message":"Login failed"}},` +
+			`{"_source":{"time":"2021-03-04T05:06:08Z","source":"auth","level":4,"message":"Password reset"}}]}]}}`))
+	}))
+	defer server.Close()
+
+	reader := elog.NewElasticSearchLogReader()
+	reader.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := reader.Open("")
+	assert.Nil(t, err)
+	defer reader.Close("")
+
+	result, err := reader.SearchEql("123",
+		`sequence by correlation_id [auth where message : "*failed*"] [auth where message : "*reset*"]`, 10)
+	assert.Nil(t, err)
+	assert.Len(t, result.Messages, 0)
+	assert.Len(t, result.Sequences, 1)
+	assert.Equal(t, []interface{}{"123"}, result.Sequences[0].JoinKeys)
+	assert.Equal(t, "Password reset", result.Sequences[0].Messages[1].Message)
+
+	assert.True(t, strings.HasPrefix(query, "/log,log-*/_eql/search "))
+	assert.Contains(t, query, `"event_category_field":"source"`)
+	assert.Contains(t, query, `"timestamp_field":"time"`)
+
+	_, err = reader.SearchEql("123", "", 10)
+	assert.NotNil(t, err)
+}
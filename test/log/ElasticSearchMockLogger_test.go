@@ -0,0 +1,113 @@
+package test_log
+
+import (
+	"errors"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-elasticsearch-go/fixtures"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchMockLogger(t *testing.T) {
+	logger := elog.NewElasticSearchMockLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"source", "orders",
+		"level", "trace",
+		"options.alerts_index", "alerts",
+	))
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Info("abc", "Order %s created", "123")
+	logger.Error("abc", errors.New("Test error"), "Order %s failed", "456")
+
+	messages := logger.Messages()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "Order 123 created", messages[0].Message)
+	assert.Equal(t, clog.Info, messages[0].Level)
+	assert.Equal(t, "orders", messages[0].Source)
+	assert.Equal(t, "abc", messages[0].CorrelationId)
+	assert.Equal(t, clog.Error, messages[1].Level)
+	assert.Equal(t, "Test error", messages[1].Error.Message)
+
+	// The error is mirrored to the alerts index
+	documents := logger.Documents()
+	assert.Len(t, documents, 3)
+	assert.Contains(t, logger.Indices(), "log")
+	assert.Contains(t, logger.Indices(), "alerts")
+
+	logger.Reset()
+	assert.Len(t, logger.Documents(), 0)
+}
+
+func TestElasticSearchMockLoggerEcs(t *testing.T) {
+	logger := elog.NewElasticSearchMockLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"source", "orders",
+		"options.schema", "ecs",
+	))
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Warn("abc", "Order %s delayed", "123")
+
+	messages := logger.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, clog.Warn, messages[0].Level)
+	assert.Equal(t, "orders", messages[0].Source)
+	assert.Equal(t, "abc", messages[0].CorrelationId)
+	assert.Equal(t, "Order 123 delayed", messages[0].Message)
+}
+
+func TestElasticSearchMockLoggerFixture(t *testing.T) {
+	logger := elog.NewElasticSearchMockLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"source", "test",
+	))
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	fixture := fixtures.NewLoggerFixture(logger.ElasticSearchLogger, logger)
+
+	t.Run("Log Level", fixture.TestLogLevel)
+	t.Run("Read Back", fixture.TestReadBack)
+}
+
+func TestElasticSearchMockLoggerVerifyAcks(t *testing.T) {
+	logger := elog.NewElasticSearchMockLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"source", "orders",
+		"options.alerts_index", "alerts",
+		"options.verify_acks", true,
+	))
+
+	var flushErr error
+	logger.SetOnError(func(correlationId string, err error, count int) {
+		flushErr = err
+	})
+
+	// Messages dumped before the logger is opened are discarded
+	logger.Info("abc", "Order %s created", "123")
+	assert.Len(t, logger.Documents(), 0)
+	assert.Len(t, logger.Cache, 0)
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	logger.Error("abc", errors.New("Test error"), "Order %s failed", "456")
+
+	// Every sent document is acknowledged, so the batch leaves the cache once
+	assert.Len(t, logger.Documents(), 2)
+	assert.Len(t, logger.Documents(), 2)
+	assert.Nil(t, flushErr)
+}
@@ -0,0 +1,129 @@
+package test_log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchLoggerMaintain(t *testing.T) {
+	yesterday := "log-" + time.Now().UTC().Add(-24*time.Hour).Format("20060102")
+	today := "log-" + time.Now().UTC().Format("20060102")
+
+	var lock sync.Mutex
+	merged := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path != "/":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/_cat/indices/log*":
+			w.Write([]byte(`[{"index":"` + yesterday + `"},{"index":"` + today + `"},{"index":"logstash"}]`))
+		case r.Method == http.MethodPost:
+			lock.Lock()
+			merged = append(merged, r.URL.Path+"?"+r.URL.RawQuery)
+			lock.Unlock()
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.daily", true,
+		"options.forcemerge", true,
+	))
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	err = logger.Maintain("123")
+	assert.Nil(t, err)
+	// Maintained indices are skipped
+	err = logger.Maintain("123")
+	assert.Nil(t, err)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.Equal(t, []string{"/" + yesterday + "/_forcemerge?max_num_segments=1"}, merged)
+}
+
+func TestElasticSearchLoggerFailedShrink(t *testing.T) {
+	yesterday := "log-" + time.Now().UTC().Add(-24*time.Hour).Format("20060102")
+
+	var lock sync.Mutex
+	settings := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path != "/":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/_cat/indices/log*":
+			w.Write([]byte(`[{"index":"` + yesterday + `"}]`))
+		case r.URL.Path == "/"+yesterday+"/_settings":
+			body, _ := ioutil.ReadAll(r.Body)
+			lock.Lock()
+			settings = append(settings, string(body))
+			lock.Unlock()
+			w.Write([]byte(`{"acknowledged":true}`))
+		case strings.HasPrefix(r.URL.Path, "/"+yesterday+"/_shrink/"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"type":"illegal_state_exception","reason":"shards are not on one node"},"status":400}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.daily", true,
+		"options.shards", 2,
+		"options.shrink", true,
+		"options.allocation_require", "box_type=warm",
+	))
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	defer logger.Close("")
+
+	err = logger.Maintain("123")
+	assert.NotNil(t, err)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Write block is removed from the original index
+	assert.Equal(t, []string{`{"index.blocks.write":true}`, `{"index.blocks.write":null}`}, settings)
+}
+
+func TestElasticSearchLoggerShrinkWithoutAllocation(t *testing.T) {
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", "http://localhost:9200",
+		"options.daily", true,
+		"options.shards", 2,
+		"options.shrink", true,
+	))
+
+	err := logger.Open("")
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_SHRINK_ALLOCATION", err.(*cerr.ApplicationError).Code)
+}
@@ -0,0 +1,131 @@
+package test_log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTransport emulates ElasticSearch and records requests sent by the logger.
+type recordingTransport struct {
+	lock    sync.Mutex
+	paths   []string
+	bulks   []string
+	indices map[string]string
+	headers []http.Header
+	hosts   []string
+}
+
+func (c *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		data, _ := ioutil.ReadAll(req.Body)
+		body = string(data)
+	}
+
+	c.lock.Lock()
+	c.paths = append(c.paths, req.Method+" "+req.URL.Path)
+	c.headers = append(c.headers, req.Header)
+	c.hosts = append(c.hosts, req.URL.Host)
+	if strings.HasSuffix(req.URL.Path, "/_bulk") {
+		c.bulks = append(c.bulks, body)
+	} else if req.Method == http.MethodPut {
+		if c.indices == nil {
+			c.indices = map[string]string{}
+		}
+		c.indices[strings.TrimPrefix(req.URL.Path, "/")] = body
+	}
+	c.lock.Unlock()
+
+	status := http.StatusOK
+	response := "{}"
+	if req.Method == http.MethodHead && req.URL.Path != "/" {
+		status = http.StatusNotFound
+	}
+	if strings.HasSuffix(req.URL.Path, "/_bulk") {
+		response = `{"took":1,"errors":false,"items":[]}`
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(response)),
+		Request:    req,
+	}, nil
+}
+
+// recordedPaths returns methods and paths of the recorded requests.
+func (c *recordingTransport) recordedPaths() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string{}, c.paths...)
+}
+
+// openRecordingLogger opens a logger that sends requests through a new recordingTransport.
+// The tuples are added to the connection configuration, the logger is closed when the test completes.
+func openRecordingLogger(t *testing.T, tuples ...interface{}) (*elog.ElasticSearchLogger, *recordingTransport) {
+	transport := &recordingTransport{}
+
+	logger := elog.NewElasticSearchLogger()
+	logger.Configure(cconf.NewConfigParamsFromTuples(
+		append([]interface{}{"connection.uri", "http://elasticsearch:9200"}, tuples...)...,
+	))
+	logger.SetTransport(transport)
+
+	err := logger.Open("")
+	assert.Nil(t, err)
+	t.Cleanup(func() { logger.Close("") })
+
+	return logger, transport
+}
+
+// dumpBulk dumps cached messages and returns the body of the only recorded bulk request.
+func dumpBulk(t *testing.T, logger *elog.ElasticSearchLogger, transport *recordingTransport) string {
+	err := logger.Dump()
+	assert.Nil(t, err)
+
+	transport.lock.Lock()
+	defer transport.lock.Unlock()
+
+	if !assert.Len(t, transport.bulks, 1) {
+		return ""
+	}
+	return transport.bulks[0]
+}
+
+// capturingLogger records messages the logger reports to the referenced loggers.
+type capturingLogger struct {
+	*clog.Logger
+	lock     sync.Mutex
+	messages []string
+}
+
+func newCapturingLogger() *capturingLogger {
+	c := &capturingLogger{}
+	c.Logger = clog.InheritLogger(c)
+	c.SetLevel(clog.Trace)
+	return c
+}
+
+func (c *capturingLogger) Write(level int, correlationId string, err error, message string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.messages = append(c.messages, clog.LogLevelConverter.ToString(level)+" "+message)
+}
+
+// captured returns levels and texts of the recorded messages.
+func (c *capturingLogger) captured() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string{}, c.messages...)
+}
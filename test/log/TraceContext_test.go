@@ -0,0 +1,64 @@
+package test_log
+
+import (
+	"testing"
+
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	elog "github.com/pip-services3-go/pip-services3-elasticsearch-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceId, spanId, ok := elog.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceId)
+	assert.Equal(t, "00f067aa0ba902b7", spanId)
+
+	traceId, _, ok = elog.ParseTraceparent("order-123;00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-00")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceId)
+
+	_, _, ok = elog.ParseTraceparent("123")
+	assert.False(t, ok)
+
+	_, _, ok = elog.ParseTraceparent("00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	assert.False(t, ok)
+
+	_, _, ok = elog.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01")
+	assert.False(t, ok)
+
+	_, _, ok = elog.ParseTraceparent("ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.False(t, ok)
+
+	// Traceparent must not be a part of longer tokens
+	_, _, ok = elog.ParseTraceparent("x00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.False(t, ok)
+	_, _, ok = elog.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.False(t, ok)
+	_, _, ok = elog.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e47360-00f067aa0ba902b7-01")
+	assert.False(t, ok)
+}
+
+// staticTraceProvider resolves the same trace context for every correlation id.
+type staticTraceProvider struct {
+	traceId string
+	spanId  string
+}
+
+func (c *staticTraceProvider) GetTraceContext(correlationId string) (string, string, bool) {
+	return c.traceId, c.spanId, true
+}
+
+func TestElasticSearchLoggerTraceProvider(t *testing.T) {
+	logger, transport := openRecordingLogger(t)
+	logger.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("test", "tracer", "memory", "default", "1.0"),
+		&staticTraceProvider{traceId: "4BF92F3577B34DA6A3CE929D0E0E4736", spanId: "00F067AA0BA902B7"},
+	))
+
+	// Ids of tracers are indexed in lower case to be found by GetMessagesByTraceId
+	logger.Info("order-123", "Test message")
+	body := dumpBulk(t, logger, transport)
+	assert.Contains(t, body, `"trace":{"id":"4bf92f3577b34da6a3ce929d0e0e4736"}`)
+	assert.Contains(t, body, `"span":{"id":"00f067aa0ba902b7"}`)
+}
@@ -0,0 +1,152 @@
+package test_query
+
+import (
+	"encoding/json"
+	"testing"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	equery "github.com/pip-services3-go/pip-services3-elasticsearch-go/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldQueries(t *testing.T) {
+	data, err := equery.Marshal(equery.NewTermQuery("level", 2))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"term":{"level":2}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewTermQuery("source", "Orders").CaseInsensitive(true))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"term":{"source":{"value":"Orders","case_insensitive":true}}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewTermsQuery("level", 1, 2))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"terms":{"level":[1,2]}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewRangeQuery("time").Gte("now-1h").Lt("now").TimeZone("+01:00"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"range":{"time":{"gte":"now-1h","lt":"now","time_zone":"+01:00"}}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewMatchQuery("message", "payment failed"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"match":{"message":"payment failed"}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewMatchQuery("message", "payment failed").Operator("and"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"match":{"message":{"query":"payment failed","operator":"and"}}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewExistsQuery("error"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"exists":{"field":"error"}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewWildcardQuery("source", "orders-*"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"wildcard":{"source":"orders-*"}}`, string(data))
+}
+
+func TestCompoundQueries(t *testing.T) {
+	query := equery.NewBoolQuery().
+		Filter(equery.NewTermQuery("source", "orders"), equery.NewRangeQuery("time").Gte("now-1h")).
+		Must(equery.NewMatchQuery("message", "failed")).
+		Should(equery.NewTermQuery("level", 1), equery.NewTermQuery("level", 2)).
+		MinimumShouldMatch(1).
+		MustNot(equery.NewExistsQuery("test"))
+
+	data, err := equery.Marshal(query)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"bool":{
+		"filter":[{"term":{"source":"orders"}},{"range":{"time":{"gte":"now-1h"}}}],
+		"must":[{"match":{"message":"failed"}}],
+		"should":[{"term":{"level":1}},{"term":{"level":2}}],
+		"minimum_should_match":1,
+		"must_not":[{"exists":{"field":"test"}}]
+	}}`, string(data))
+
+	nested := equery.NewNestedQuery("error",
+		equery.NewBoolQuery().Filter(equery.NewTermQuery("error.code", "TIMEOUT"))).ScoreMode("none")
+	data, err = equery.Marshal(nested)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"nested":{"path":"error","score_mode":"none",
+		"query":{"bool":{"filter":[{"term":{"error.code":"TIMEOUT"}}]}}}}`, string(data))
+
+	empty := equery.NewBoolQuery()
+	assert.True(t, empty.IsEmpty())
+	data, err = equery.Marshal(empty.Filter(equery.Raw(map[string]interface{}{
+		"ids": map[string]interface{}{"values": []string{"1"}},
+	})))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"bool":{"filter":[{"ids":{"values":["1"]}}]}}`, string(data))
+}
+
+func TestSuggesters(t *testing.T) {
+	source := equery.SuggestSource(
+		equery.NewTermSuggester("spelling", "paymnt", "message").SuggestMode("popular"),
+		equery.NewCompletionSuggester("sources", "ord", "source_suggest").Size(5).Fuzzy("AUTO"),
+	)
+
+	data, err := json.Marshal(source)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"spelling":{"text":"paymnt","term":{"field":"message","suggest_mode":"popular"}},
+		"sources":{"prefix":"ord","completion":{"field":"source_suggest","size":5,"fuzzy":{"fuzziness":"AUTO"}}}
+	}`, string(data))
+
+	assert.Equal(t, map[string]interface{}{"type": "completion", "analyzer": "simple"}, equery.CompletionMapping(""))
+}
+
+func TestFuzzyQueries(t *testing.T) {
+	data, err := equery.Marshal(equery.NewFuzzyQuery("source", "odrers").Fuzziness("AUTO").PrefixLength(1))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"fuzzy":{"source":{"value":"odrers","fuzziness":"AUTO","prefix_length":1}}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewPrefixQuery("source", "ord"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"prefix":{"source":"ord"}}`, string(data))
+
+	data, err = equery.Marshal(equery.NewMatchQuery("message", "paymnt").Fuzziness(1))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"match":{"message":{"query":"paymnt","fuzziness":1}}}`, string(data))
+
+	leading := equery.NewWildcardQuery("source", "*orders")
+	_, err = equery.Marshal(equery.NewBoolQuery().Filter(equery.NewNestedQuery("error", leading)))
+	assert.NotNil(t, err)
+	assert.Equal(t, "LEADING_WILDCARD", err.(*cerr.ApplicationError).Code)
+
+	data, err = equery.Marshal(leading.AllowLeadingWildcard(true))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"wildcard":{"source":"*orders"}}`, string(data))
+}
+
+func TestSearchRequest(t *testing.T) {
+	request := equery.NewSearchRequest().
+		Query(equery.NewTermQuery("source", "orders")).
+		Size(10).
+		Sort("time", false).
+		RuntimeField("day", "keyword", "emit(doc['time'].value.dayOfWeekEnum.toString())").
+		Aggregation("days", map[string]interface{}{"terms": map[string]interface{}{"field": "day"}})
+
+	size, ok := request.GetSize()
+	assert.True(t, ok)
+	assert.Equal(t, 10, size)
+
+	data, err := equery.Marshal(request)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"query":{"term":{"source":"orders"}},
+		"size":10,
+		"sort":[{"time":{"order":"desc"}}],
+		"runtime_mappings":{"day":{"type":"keyword","script":{"source":"emit(doc['time'].value.dayOfWeekEnum.toString())"}}},
+		"aggs":{"days":{"terms":{"field":"day"}}}
+	}`, string(data))
+}
+
+func TestSearchRequestScriptFields(t *testing.T) {
+	request := equery.NewSearchRequest().
+		ScriptField("seconds", "doc['duration'].value / params.scale", map[string]interface{}{"scale": 1000})
+
+	data, err := equery.Marshal(request)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"_source":true,
+		"script_fields":{"seconds":{"script":{"source":"doc['duration'].value / params.scale","params":{"scale":1000}}}}
+	}`, string(data))
+}
@@ -0,0 +1,246 @@
+package test_queue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ebuild "github.com/pip-services3-go/pip-services3-elasticsearch-go/build"
+	equeue "github.com/pip-services3-go/pip-services3-elasticsearch-go/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+type storedDocument struct {
+	id     string
+	seqNo  int
+	source map[string]interface{}
+}
+
+// documentServer emulates document APIs of a single ElasticSearch index
+// with sequence number checks of conditional writes.
+type documentServer struct {
+	lock    sync.Mutex
+	index   string
+	created bool
+	seqNo   int
+	docs    []*storedDocument
+}
+
+func (c *documentServer) find(id string) (int, *storedDocument) {
+	for i, doc := range c.docs {
+		if doc.id == id {
+			return i, doc
+		}
+	}
+	return -1, nil
+}
+
+func writeConflict(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusConflict)
+	w.Write([]byte(`{"error":{"type":"version_conflict_engine_exception","reason":"version conflict"},"status":409}`))
+}
+
+func (c *documentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.TrimPrefix(r.URL.Path, "/"+c.index)
+	query := r.URL.Query()
+
+	switch {
+	case path == "" && r.Method == http.MethodHead:
+		if !c.created {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case path == "" && r.Method == http.MethodPut:
+		c.created = true
+		w.Write([]byte(`{"acknowledged":true}`))
+	case strings.HasPrefix(path, "/_doc/"):
+		id := strings.TrimPrefix(path, "/_doc/")
+		i, doc := c.find(id)
+		if ifSeqNo := query.Get("if_seq_no"); ifSeqNo != "" && (doc == nil || strconv.Itoa(doc.seqNo) != ifSeqNo) {
+			writeConflict(w)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			c.docs = append(c.docs[:i], c.docs[i+1:]...)
+			w.Write([]byte(`{"result":"deleted"}`))
+			return
+		}
+		if doc != nil && query.Get("op_type") == "create" {
+			writeConflict(w)
+			return
+		}
+		if doc == nil {
+			doc = &storedDocument{id: id}
+			c.docs = append(c.docs, doc)
+		}
+		c.seqNo++
+		doc.seqNo = c.seqNo
+		json.Unmarshal(data, &doc.source)
+		w.Write([]byte(`{"_id":"` + id + `","_seq_no":` + strconv.Itoa(doc.seqNo) + `,"_primary_term":1}`))
+	case path == "/_search":
+		var body struct {
+			Size  int `json:"size"`
+			Query struct {
+				Bool struct {
+					Filter []struct {
+						Range struct {
+							LockedUntil struct {
+								Lt float64 `json:"lt"`
+							} `json:"locked_until"`
+						} `json:"range"`
+					} `json:"filter"`
+				} `json:"bool"`
+			} `json:"query"`
+		}
+		json.Unmarshal(data, &body)
+		now := body.Query.Bool.Filter[0].Range.LockedUntil.Lt
+
+		hits := make([]interface{}, 0)
+		for _, doc := range c.docs {
+			if doc.source["locked_until"].(float64) < now && len(hits) < body.Size {
+				hits = append(hits, map[string]interface{}{
+					"_id": doc.id, "_seq_no": doc.seqNo, "_primary_term": 1, "_source": doc.source,
+				})
+			}
+		}
+		result, _ := json.Marshal(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+		w.Write(result)
+	case path == "/_count":
+		w.Write([]byte(`{"count":` + strconv.Itoa(len(c.docs)) + `}`))
+	case path == "/_delete_by_query":
+		c.docs = nil
+		w.Write([]byte(`{"deleted":1}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestElasticSearchMessageQueue(t *testing.T) {
+	documents := &documentServer{index: "queue_jobs"}
+	server := httptest.NewServer(documents)
+	defer server.Close()
+
+	queue := equeue.NewElasticSearchMessageQueue("jobs")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.receive_interval", 10,
+	))
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	assert.True(t, documents.created)
+
+	err = queue.Send("123", equeue.NewMessageEnvelope("123", "resize", []byte("image-1.png")))
+	assert.Nil(t, err)
+	message, _ := equeue.NewMessageEnvelopeFromObject("", "resize", map[string]string{"image": "image-2.png"})
+	err = queue.Send("123", message)
+	assert.Nil(t, err)
+	assert.Equal(t, "123", message.CorrelationId)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	first, err := queue.Receive("123", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "image-1.png", first.GetMessageAsString())
+	assert.Equal(t, 1, first.ReceiveCount())
+
+	peeked, err := queue.Peek("123")
+	assert.Nil(t, err)
+	assert.Equal(t, message.MessageId, peeked.MessageId)
+
+	second, err := queue.Receive("123", 0)
+	assert.Nil(t, err)
+	var content map[string]string
+	err = second.GetMessageAsObject(&content)
+	assert.Nil(t, err)
+	assert.Equal(t, "image-2.png", content["image"])
+
+	// All messages are locked
+	none, err := queue.Receive("123", 30*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, none)
+
+	err = queue.Abandon(second)
+	assert.Nil(t, err)
+	again, err := queue.Receive("123", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, second.MessageId, again.MessageId)
+	assert.Equal(t, 2, again.ReceiveCount())
+
+	err = queue.Complete(again)
+	assert.Nil(t, err)
+	count, _ = queue.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+
+	// Expire the lock to let another receiver take the message
+	err = queue.RenewLock(first, 0)
+	assert.Nil(t, err)
+	time.Sleep(5 * time.Millisecond)
+	stolen, err := queue.Receive("123", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, first.MessageId, stolen.MessageId)
+
+	err = queue.Complete(first)
+	assert.NotNil(t, err)
+	assert.Equal(t, "LOCK_LOST", err.(*cerr.ApplicationError).Code)
+
+	err = queue.Complete(stolen)
+	assert.Nil(t, err)
+
+	err = queue.Complete(equeue.NewMessageEnvelope("", "resize", nil))
+	assert.NotNil(t, err)
+
+	err = queue.Clear("123")
+	assert.NoError(t, err)
+}
+
+func TestElasticSearchMessageQueueFactory(t *testing.T) {
+	factory := ebuild.NewDefaultElasticSearchFactory()
+
+	component, err := factory.Create(cref.NewDescriptor("pip-services", "message-queue", "elasticsearch", "jobs", "1.0"))
+	assert.Nil(t, err)
+	queue, ok := component.(*equeue.ElasticSearchMessageQueue)
+	assert.True(t, ok)
+	assert.Equal(t, "jobs", queue.Name())
+}
+
+func TestElasticSearchMessageQueueWrongOptions(t *testing.T) {
+	options := []struct {
+		name  string
+		value int
+		code  string
+	}{
+		{"options.receive_interval", 0, "WRONG_RECEIVE_INTERVAL"},
+		{"options.receive_interval", -1000, "WRONG_RECEIVE_INTERVAL"},
+		{"options.batch_size", 0, "WRONG_BATCH_SIZE"},
+		{"options.batch_size", -1, "WRONG_BATCH_SIZE"},
+	}
+	for _, option := range options {
+		queue := equeue.NewElasticSearchMessageQueue("orders")
+		queue.Configure(cconf.NewConfigParamsFromTuples(
+			"connection.uri", "http://localhost:9200",
+			option.name, option.value,
+		))
+
+		err := queue.Open("")
+		assert.NotNil(t, err)
+		assert.Equal(t, option.code, err.(*cerr.ApplicationError).Code)
+		assert.False(t, queue.IsOpen())
+	}
+}